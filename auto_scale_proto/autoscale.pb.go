@@ -10,6 +10,8 @@ import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	math "math"
 )
 
@@ -228,39 +230,346 @@ func (m *ResumeAndGetTopologyResponse) GetTopology() *GetTopologyResponse {
 	return nil
 }
 
+type GetTenantConfigRequest struct {
+	TidbClusterID        string   `protobuf:"bytes,1,opt,name=tidbClusterID,proto3" json:"tidbClusterID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTenantConfigRequest) Reset()         { *m = GetTenantConfigRequest{} }
+func (m *GetTenantConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTenantConfigRequest) ProtoMessage()    {}
+func (*GetTenantConfigRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc6a430c3808dc1b, []int{4}
+}
+
+func (m *GetTenantConfigRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTenantConfigRequest.Unmarshal(m, b)
+}
+func (m *GetTenantConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTenantConfigRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTenantConfigRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTenantConfigRequest.Merge(m, src)
+}
+func (m *GetTenantConfigRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTenantConfigRequest.Size(m)
+}
+func (m *GetTenantConfigRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTenantConfigRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTenantConfigRequest proto.InternalMessageInfo
+
+func (m *GetTenantConfigRequest) GetTidbClusterID() string {
+	if m != nil {
+		return m.TidbClusterID
+	}
+	return ""
+}
+
+type GetTenantConfigResponse struct {
+	HasErr               bool     `protobuf:"varint,1,opt,name=hasErr,proto3" json:"hasErr,omitempty"`
+	ErrInfo              string   `protobuf:"bytes,2,opt,name=errInfo,proto3" json:"errInfo,omitempty"`
+	ConfigJson           string   `protobuf:"bytes,3,opt,name=configJson,proto3" json:"configJson,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTenantConfigResponse) Reset()         { *m = GetTenantConfigResponse{} }
+func (m *GetTenantConfigResponse) String() string { return proto.CompactTextString(m) }
+func (*GetTenantConfigResponse) ProtoMessage()    {}
+func (*GetTenantConfigResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc6a430c3808dc1b, []int{5}
+}
+
+func (m *GetTenantConfigResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTenantConfigResponse.Unmarshal(m, b)
+}
+func (m *GetTenantConfigResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTenantConfigResponse.Marshal(b, m, deterministic)
+}
+func (m *GetTenantConfigResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTenantConfigResponse.Merge(m, src)
+}
+func (m *GetTenantConfigResponse) XXX_Size() int {
+	return xxx_messageInfo_GetTenantConfigResponse.Size(m)
+}
+func (m *GetTenantConfigResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTenantConfigResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTenantConfigResponse proto.InternalMessageInfo
+
+func (m *GetTenantConfigResponse) GetHasErr() bool {
+	if m != nil {
+		return m.HasErr
+	}
+	return false
+}
+
+func (m *GetTenantConfigResponse) GetErrInfo() string {
+	if m != nil {
+		return m.ErrInfo
+	}
+	return ""
+}
+
+func (m *GetTenantConfigResponse) GetConfigJson() string {
+	if m != nil {
+		return m.ConfigJson
+	}
+	return ""
+}
+
+type SetTenantConfigRequest struct {
+	TidbClusterID        string   `protobuf:"bytes,1,opt,name=tidbClusterID,proto3" json:"tidbClusterID,omitempty"`
+	ConfigJson           string   `protobuf:"bytes,2,opt,name=configJson,proto3" json:"configJson,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetTenantConfigRequest) Reset()         { *m = SetTenantConfigRequest{} }
+func (m *SetTenantConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*SetTenantConfigRequest) ProtoMessage()    {}
+func (*SetTenantConfigRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc6a430c3808dc1b, []int{6}
+}
+
+func (m *SetTenantConfigRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetTenantConfigRequest.Unmarshal(m, b)
+}
+func (m *SetTenantConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetTenantConfigRequest.Marshal(b, m, deterministic)
+}
+func (m *SetTenantConfigRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetTenantConfigRequest.Merge(m, src)
+}
+func (m *SetTenantConfigRequest) XXX_Size() int {
+	return xxx_messageInfo_SetTenantConfigRequest.Size(m)
+}
+func (m *SetTenantConfigRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetTenantConfigRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetTenantConfigRequest proto.InternalMessageInfo
+
+func (m *SetTenantConfigRequest) GetTidbClusterID() string {
+	if m != nil {
+		return m.TidbClusterID
+	}
+	return ""
+}
+
+func (m *SetTenantConfigRequest) GetConfigJson() string {
+	if m != nil {
+		return m.ConfigJson
+	}
+	return ""
+}
+
+type DeleteTenantConfigRequest struct {
+	TidbClusterID        string   `protobuf:"bytes,1,opt,name=tidbClusterID,proto3" json:"tidbClusterID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteTenantConfigRequest) Reset()         { *m = DeleteTenantConfigRequest{} }
+func (m *DeleteTenantConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteTenantConfigRequest) ProtoMessage()    {}
+func (*DeleteTenantConfigRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc6a430c3808dc1b, []int{7}
+}
+
+func (m *DeleteTenantConfigRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteTenantConfigRequest.Unmarshal(m, b)
+}
+func (m *DeleteTenantConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteTenantConfigRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteTenantConfigRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteTenantConfigRequest.Merge(m, src)
+}
+func (m *DeleteTenantConfigRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteTenantConfigRequest.Size(m)
+}
+func (m *DeleteTenantConfigRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteTenantConfigRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteTenantConfigRequest proto.InternalMessageInfo
+
+func (m *DeleteTenantConfigRequest) GetTidbClusterID() string {
+	if m != nil {
+		return m.TidbClusterID
+	}
+	return ""
+}
+
+type FieldError struct {
+	Field                string   `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Reason               string   `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FieldError) Reset()         { *m = FieldError{} }
+func (m *FieldError) String() string { return proto.CompactTextString(m) }
+func (*FieldError) ProtoMessage()    {}
+func (*FieldError) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc6a430c3808dc1b, []int{8}
+}
+
+func (m *FieldError) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FieldError.Unmarshal(m, b)
+}
+func (m *FieldError) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FieldError.Marshal(b, m, deterministic)
+}
+func (m *FieldError) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FieldError.Merge(m, src)
+}
+func (m *FieldError) XXX_Size() int {
+	return xxx_messageInfo_FieldError.Size(m)
+}
+func (m *FieldError) XXX_DiscardUnknown() {
+	xxx_messageInfo_FieldError.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FieldError proto.InternalMessageInfo
+
+func (m *FieldError) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *FieldError) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *FieldError) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type TenantConfigResponse struct {
+	HasErr               bool          `protobuf:"varint,1,opt,name=hasErr,proto3" json:"hasErr,omitempty"`
+	ErrInfo              string        `protobuf:"bytes,2,opt,name=errInfo,proto3" json:"errInfo,omitempty"`
+	FieldErrors          []*FieldError `protobuf:"bytes,3,rep,name=fieldErrors,proto3" json:"fieldErrors,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *TenantConfigResponse) Reset()         { *m = TenantConfigResponse{} }
+func (m *TenantConfigResponse) String() string { return proto.CompactTextString(m) }
+func (*TenantConfigResponse) ProtoMessage()    {}
+func (*TenantConfigResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc6a430c3808dc1b, []int{9}
+}
+
+func (m *TenantConfigResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TenantConfigResponse.Unmarshal(m, b)
+}
+func (m *TenantConfigResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TenantConfigResponse.Marshal(b, m, deterministic)
+}
+func (m *TenantConfigResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TenantConfigResponse.Merge(m, src)
+}
+func (m *TenantConfigResponse) XXX_Size() int {
+	return xxx_messageInfo_TenantConfigResponse.Size(m)
+}
+func (m *TenantConfigResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TenantConfigResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TenantConfigResponse proto.InternalMessageInfo
+
+func (m *TenantConfigResponse) GetHasErr() bool {
+	if m != nil {
+		return m.HasErr
+	}
+	return false
+}
+
+func (m *TenantConfigResponse) GetErrInfo() string {
+	if m != nil {
+		return m.ErrInfo
+	}
+	return ""
+}
+
+func (m *TenantConfigResponse) GetFieldErrors() []*FieldError {
+	if m != nil {
+		return m.FieldErrors
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*GetTopologyRequest)(nil), "autoscale.GetTopologyRequest")
 	proto.RegisterType((*ResumeAndGetTopologyRequest)(nil), "autoscale.ResumeAndGetTopologyRequest")
 	proto.RegisterType((*GetTopologyResponse)(nil), "autoscale.GetTopologyResponse")
 	proto.RegisterType((*ResumeAndGetTopologyResponse)(nil), "autoscale.ResumeAndGetTopologyResponse")
+	proto.RegisterType((*GetTenantConfigRequest)(nil), "autoscale.GetTenantConfigRequest")
+	proto.RegisterType((*GetTenantConfigResponse)(nil), "autoscale.GetTenantConfigResponse")
+	proto.RegisterType((*SetTenantConfigRequest)(nil), "autoscale.SetTenantConfigRequest")
+	proto.RegisterType((*DeleteTenantConfigRequest)(nil), "autoscale.DeleteTenantConfigRequest")
+	proto.RegisterType((*FieldError)(nil), "autoscale.FieldError")
+	proto.RegisterType((*TenantConfigResponse)(nil), "autoscale.TenantConfigResponse")
 }
 
 func init() { proto.RegisterFile("autoscale.proto", fileDescriptor_dc6a430c3808dc1b) }
 
 var fileDescriptor_dc6a430c3808dc1b = []byte{
-	// 343 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x92, 0x41, 0x4b, 0xe3, 0x50,
-	0x10, 0xc7, 0x37, 0x9b, 0xa5, 0xdb, 0x4c, 0x77, 0x59, 0x78, 0x2b, 0x12, 0x6a, 0x95, 0x12, 0x44,
-	0x7b, 0x4a, 0xa1, 0xc5, 0x4b, 0x6f, 0xb5, 0x8a, 0x14, 0x44, 0x24, 0xf5, 0xe4, 0xed, 0xb5, 0x1d,
-	0x9f, 0x81, 0x24, 0x13, 0xdf, 0x9b, 0x80, 0x1e, 0xfc, 0x3e, 0x7e, 0x12, 0x3f, 0x97, 0xf8, 0x48,
-	0xd3, 0x16, 0xaa, 0x15, 0x6f, 0x99, 0x7f, 0xfe, 0xf9, 0xff, 0x66, 0x32, 0x03, 0xff, 0x64, 0xc1,
-	0x64, 0x66, 0x32, 0xc1, 0x30, 0xd7, 0xc4, 0x24, 0xbc, 0x4a, 0x08, 0x06, 0x20, 0x2e, 0x90, 0x6f,
-	0x28, 0xa7, 0x84, 0xd4, 0x53, 0x84, 0x0f, 0x05, 0x1a, 0x16, 0x87, 0xf0, 0x97, 0xe3, 0xf9, 0x74,
-	0x94, 0x14, 0x86, 0x51, 0x8f, 0xcf, 0x7c, 0xa7, 0xed, 0x74, 0xbc, 0x68, 0x5d, 0x0c, 0x14, 0xec,
-	0x45, 0x68, 0x8a, 0x14, 0x87, 0xd9, 0xfc, 0xbb, 0x21, 0x22, 0x80, 0x3f, 0x2c, 0xb5, 0x42, 0xbe,
-	0xa6, 0xf9, 0x28, 0x63, 0xff, 0xa7, 0x35, 0xad, 0x69, 0xc1, 0x33, 0xfc, 0x5f, 0xcb, 0x37, 0x39,
-	0x65, 0x06, 0xbf, 0x08, 0x68, 0x81, 0xc7, 0x71, 0x8a, 0x86, 0x65, 0x9a, 0xdb, 0x74, 0x37, 0x5a,
-	0x0a, 0x16, 0x5f, 0xe6, 0x5e, 0xc6, 0x86, 0x7d, 0xb7, 0xed, 0x5a, 0xfc, 0x8a, 0x16, 0xbc, 0x38,
-	0xd0, 0xda, 0x3c, 0x68, 0xd9, 0xc8, 0x2e, 0xd4, 0xee, 0xa5, 0x39, 0xd7, 0xda, 0x76, 0x50, 0x8f,
-	0xca, 0x4a, 0xf8, 0xf0, 0x1b, 0xb5, 0x1e, 0x67, 0x77, 0x54, 0x8e, 0xb5, 0x28, 0x45, 0x13, 0xea,
-	0xb3, 0x42, 0x4f, 0x58, 0x32, 0xfa, 0xae, 0x7d, 0x55, 0xd5, 0x62, 0x00, 0xf5, 0x05, 0xde, 0xff,
-	0xd5, 0x76, 0x3a, 0x8d, 0xde, 0x41, 0xb8, 0xdc, 0xe0, 0x06, 0x7e, 0x54, 0xf9, 0x7b, 0xaf, 0x0e,
-	0x78, 0xc3, 0x82, 0x69, 0xf2, 0xee, 0x15, 0x57, 0xd0, 0x58, 0xb1, 0x8b, 0xfd, 0x8f, 0x62, 0xec,
-	0xbe, 0x9a, 0x5b, 0x28, 0xc1, 0x0f, 0x11, 0xc3, 0xce, 0xa6, 0xff, 0x20, 0x8e, 0x56, 0xbe, 0xfc,
-	0xe4, 0x22, 0x9a, 0xc7, 0x5b, 0x7d, 0x0b, 0xd4, 0xe9, 0xc9, 0x6d, 0x5f, 0x11, 0xa9, 0x04, 0x43,
-	0x45, 0x89, 0xcc, 0x54, 0x48, 0x5a, 0x75, 0x95, 0xce, 0x67, 0x5d, 0x7c, 0x94, 0x69, 0x9e, 0xa0,
-	0xe9, 0x56, 0x59, 0xcb, 0xa7, 0x69, 0xcd, 0x1e, 0x78, 0xff, 0x2d, 0x00, 0x00, 0xff, 0xff, 0x65,
-	0x04, 0xc4, 0x6d, 0xf3, 0x02, 0x00, 0x00,
+	// 519 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0x5d, 0x6f, 0xd3, 0x30,
+	0x14, 0x25, 0xeb, 0x18, 0xed, 0x2d, 0x68, 0x92, 0x29, 0x25, 0x84, 0x31, 0x8a, 0x35, 0xc1, 0x9e,
+	0x5a, 0xa9, 0x13, 0x42, 0xda, 0x03, 0x52, 0xe9, 0x06, 0x1a, 0x42, 0x68, 0x4a, 0xe1, 0x65, 0x12,
+	0x48, 0x5e, 0x73, 0x6b, 0x22, 0xd2, 0x38, 0xd8, 0x0e, 0x82, 0x07, 0x1e, 0xf8, 0x37, 0xfc, 0x05,
+	0xfe, 0x1d, 0x8a, 0x97, 0x25, 0xee, 0xd7, 0x56, 0x0a, 0x7b, 0xeb, 0x3d, 0x3d, 0x3e, 0xf7, 0xdc,
+	0xeb, 0xe3, 0x16, 0x36, 0x59, 0xaa, 0x85, 0x1a, 0xb2, 0x08, 0xdb, 0x89, 0x14, 0x5a, 0x90, 0x5a,
+	0x01, 0xd0, 0x7d, 0x20, 0xaf, 0x50, 0xbf, 0x13, 0x89, 0x88, 0x04, 0xff, 0xee, 0xe3, 0x97, 0x14,
+	0x95, 0x26, 0x3b, 0x70, 0x4b, 0x87, 0xc1, 0x69, 0x3f, 0x4a, 0x95, 0x46, 0x79, 0x74, 0xe0, 0x3a,
+	0x2d, 0x67, 0xb7, 0xe6, 0x4f, 0x82, 0x94, 0xc3, 0x7d, 0x1f, 0x55, 0x3a, 0xc6, 0x5e, 0x1c, 0xac,
+	0x2a, 0x42, 0x28, 0xdc, 0xd4, 0x4c, 0x72, 0xd4, 0xc7, 0x22, 0xe8, 0xc7, 0xda, 0x5d, 0x33, 0xa4,
+	0x09, 0x8c, 0xfe, 0x80, 0xdb, 0x13, 0xfa, 0x2a, 0x11, 0xb1, 0xc2, 0x25, 0x1b, 0x6c, 0x41, 0x4d,
+	0x87, 0x63, 0x54, 0x9a, 0x8d, 0x13, 0xa3, 0x5e, 0xf1, 0x4b, 0xc0, 0xb4, 0xcf, 0x75, 0xdf, 0x84,
+	0x4a, 0xbb, 0x95, 0x56, 0xc5, 0xb4, 0xb7, 0x30, 0xfa, 0xcb, 0x81, 0xad, 0xf9, 0x83, 0xe6, 0x46,
+	0x9a, 0xb0, 0xf1, 0x89, 0xa9, 0x43, 0x29, 0x8d, 0x83, 0xaa, 0x9f, 0x57, 0xc4, 0x85, 0x1b, 0x28,
+	0xe5, 0x51, 0x3c, 0x12, 0xf9, 0x58, 0xe7, 0x25, 0xf1, 0xa0, 0x3a, 0x4c, 0xe5, 0x40, 0x33, 0x8d,
+	0x6e, 0xc5, 0x7c, 0x55, 0xd4, 0x64, 0x1f, 0xaa, 0xe7, 0xed, 0xdd, 0xf5, 0x96, 0xb3, 0x5b, 0xef,
+	0x6e, 0xb7, 0xcb, 0x1b, 0x9c, 0xd3, 0xdf, 0x2f, 0xf8, 0xf4, 0x39, 0x34, 0x33, 0x02, 0xc6, 0x2c,
+	0xd6, 0x7d, 0x11, 0x8f, 0x42, 0xfe, 0x77, 0x57, 0xfa, 0x19, 0xee, 0xce, 0x9c, 0x5f, 0x79, 0xc8,
+	0x6d, 0x80, 0xa1, 0xd1, 0x78, 0xad, 0x44, 0x9c, 0x8f, 0x69, 0x21, 0xf4, 0x23, 0x34, 0x07, 0xff,
+	0x60, 0x76, 0x4a, 0x7f, 0x6d, 0x46, 0xbf, 0x07, 0xf7, 0x0e, 0x30, 0x42, 0x8d, 0xab, 0xef, 0xe3,
+	0x18, 0xe0, 0x65, 0x88, 0x51, 0x70, 0x28, 0xa5, 0x90, 0xa4, 0x01, 0xd7, 0x47, 0x59, 0x95, 0x73,
+	0xcf, 0x8a, 0x0c, 0xfd, 0xca, 0xa2, 0x14, 0x73, 0x07, 0x67, 0x45, 0xb6, 0x2e, 0x89, 0xac, 0x1c,
+	0x3c, 0xaf, 0xe8, 0x4f, 0x07, 0x1a, 0xff, 0x69, 0xbf, 0xcf, 0xa0, 0x3e, 0x2a, 0xcc, 0x29, 0x13,
+	0xdd, 0x7a, 0xf7, 0x8e, 0x95, 0x95, 0xd2, 0xba, 0x6f, 0x33, 0xbb, 0xbf, 0xd7, 0xa1, 0xd6, 0x4b,
+	0xb5, 0x18, 0x64, 0x2c, 0xf2, 0x16, 0xea, 0x56, 0xa8, 0xc8, 0x83, 0x45, 0x61, 0x33, 0x7b, 0xf3,
+	0x2e, 0xc9, 0x22, 0xbd, 0x46, 0x42, 0x68, 0xcc, 0x7b, 0x2d, 0xe4, 0xb1, 0x75, 0xf2, 0x82, 0xdf,
+	0x0d, 0xef, 0xc9, 0xa5, 0xbc, 0xa2, 0xd5, 0x09, 0x6c, 0x4e, 0xc5, 0x95, 0x3c, 0x9a, 0xf2, 0x37,
+	0x7b, 0xf5, 0x1e, 0xbd, 0x88, 0x62, 0x69, 0x93, 0xbe, 0x44, 0xa6, 0x71, 0xa1, 0xfc, 0xfc, 0xf0,
+	0x7a, 0x0f, 0x2d, 0xca, 0x62, 0xed, 0xf7, 0x49, 0x70, 0x35, 0xda, 0x1f, 0x80, 0xcc, 0xa6, 0x9e,
+	0xec, 0x58, 0x07, 0x17, 0x3e, 0x8a, 0x25, 0xe4, 0x5f, 0x3c, 0x3d, 0xd9, 0xe3, 0x42, 0xf0, 0x08,
+	0xdb, 0x5c, 0x44, 0x2c, 0xe6, 0x6d, 0x21, 0x79, 0x87, 0xcb, 0x64, 0xd8, 0xc1, 0x6f, 0x6c, 0x9c,
+	0x44, 0xa8, 0x3a, 0x85, 0x46, 0xf9, 0xe9, 0x74, 0xc3, 0xfc, 0xf3, 0xec, 0xfd, 0x19, 0x00, 0x99,
+	0x20, 0x9e, 0x69, 0x8c, 0x06, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -277,6 +586,10 @@ const _ = grpc.SupportPackageIsVersion4
 type AutoScaleClient interface {
 	GetTopology(ctx context.Context, in *GetTopologyRequest, opts ...grpc.CallOption) (*GetTopologyResponse, error)
 	ResumeAndGetTopology(ctx context.Context, in *ResumeAndGetTopologyRequest, opts ...grpc.CallOption) (*ResumeAndGetTopologyResponse, error)
+	GetTenantConfig(ctx context.Context, in *GetTenantConfigRequest, opts ...grpc.CallOption) (*GetTenantConfigResponse, error)
+	CreateTenantConfig(ctx context.Context, in *SetTenantConfigRequest, opts ...grpc.CallOption) (*TenantConfigResponse, error)
+	UpdateTenantConfig(ctx context.Context, in *SetTenantConfigRequest, opts ...grpc.CallOption) (*TenantConfigResponse, error)
+	DeleteTenantConfig(ctx context.Context, in *DeleteTenantConfigRequest, opts ...grpc.CallOption) (*TenantConfigResponse, error)
 }
 
 type autoScaleClient struct {
@@ -305,10 +618,73 @@ func (c *autoScaleClient) ResumeAndGetTopology(ctx context.Context, in *ResumeAn
 	return out, nil
 }
 
+func (c *autoScaleClient) GetTenantConfig(ctx context.Context, in *GetTenantConfigRequest, opts ...grpc.CallOption) (*GetTenantConfigResponse, error) {
+	out := new(GetTenantConfigResponse)
+	err := c.cc.Invoke(ctx, "/autoscale.AutoScale/GetTenantConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *autoScaleClient) CreateTenantConfig(ctx context.Context, in *SetTenantConfigRequest, opts ...grpc.CallOption) (*TenantConfigResponse, error) {
+	out := new(TenantConfigResponse)
+	err := c.cc.Invoke(ctx, "/autoscale.AutoScale/CreateTenantConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *autoScaleClient) UpdateTenantConfig(ctx context.Context, in *SetTenantConfigRequest, opts ...grpc.CallOption) (*TenantConfigResponse, error) {
+	out := new(TenantConfigResponse)
+	err := c.cc.Invoke(ctx, "/autoscale.AutoScale/UpdateTenantConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *autoScaleClient) DeleteTenantConfig(ctx context.Context, in *DeleteTenantConfigRequest, opts ...grpc.CallOption) (*TenantConfigResponse, error) {
+	out := new(TenantConfigResponse)
+	err := c.cc.Invoke(ctx, "/autoscale.AutoScale/DeleteTenantConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AutoScaleServer is the server API for AutoScale service.
 type AutoScaleServer interface {
 	GetTopology(context.Context, *GetTopologyRequest) (*GetTopologyResponse, error)
 	ResumeAndGetTopology(context.Context, *ResumeAndGetTopologyRequest) (*ResumeAndGetTopologyResponse, error)
+	GetTenantConfig(context.Context, *GetTenantConfigRequest) (*GetTenantConfigResponse, error)
+	CreateTenantConfig(context.Context, *SetTenantConfigRequest) (*TenantConfigResponse, error)
+	UpdateTenantConfig(context.Context, *SetTenantConfigRequest) (*TenantConfigResponse, error)
+	DeleteTenantConfig(context.Context, *DeleteTenantConfigRequest) (*TenantConfigResponse, error)
+}
+
+// UnimplementedAutoScaleServer can be embedded to have forward compatible implementations.
+type UnimplementedAutoScaleServer struct {
+}
+
+func (*UnimplementedAutoScaleServer) GetTopology(ctx context.Context, req *GetTopologyRequest) (*GetTopologyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTopology not implemented")
+}
+func (*UnimplementedAutoScaleServer) ResumeAndGetTopology(ctx context.Context, req *ResumeAndGetTopologyRequest) (*ResumeAndGetTopologyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeAndGetTopology not implemented")
+}
+func (*UnimplementedAutoScaleServer) GetTenantConfig(ctx context.Context, req *GetTenantConfigRequest) (*GetTenantConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTenantConfig not implemented")
+}
+func (*UnimplementedAutoScaleServer) CreateTenantConfig(ctx context.Context, req *SetTenantConfigRequest) (*TenantConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTenantConfig not implemented")
+}
+func (*UnimplementedAutoScaleServer) UpdateTenantConfig(ctx context.Context, req *SetTenantConfigRequest) (*TenantConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTenantConfig not implemented")
+}
+func (*UnimplementedAutoScaleServer) DeleteTenantConfig(ctx context.Context, req *DeleteTenantConfigRequest) (*TenantConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTenantConfig not implemented")
 }
 
 func RegisterAutoScaleServer(s *grpc.Server, srv AutoScaleServer) {
@@ -351,6 +727,78 @@ func _AutoScale_ResumeAndGetTopology_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AutoScale_GetTenantConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTenantConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AutoScaleServer).GetTenantConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/autoscale.AutoScale/GetTenantConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AutoScaleServer).GetTenantConfig(ctx, req.(*GetTenantConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AutoScale_CreateTenantConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetTenantConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AutoScaleServer).CreateTenantConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/autoscale.AutoScale/CreateTenantConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AutoScaleServer).CreateTenantConfig(ctx, req.(*SetTenantConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AutoScale_UpdateTenantConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetTenantConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AutoScaleServer).UpdateTenantConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/autoscale.AutoScale/UpdateTenantConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AutoScaleServer).UpdateTenantConfig(ctx, req.(*SetTenantConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AutoScale_DeleteTenantConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTenantConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AutoScaleServer).DeleteTenantConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/autoscale.AutoScale/DeleteTenantConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AutoScaleServer).DeleteTenantConfig(ctx, req.(*DeleteTenantConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _AutoScale_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "autoscale.AutoScale",
 	HandlerType: (*AutoScaleServer)(nil),
@@ -363,6 +811,22 @@ var _AutoScale_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ResumeAndGetTopology",
 			Handler:    _AutoScale_ResumeAndGetTopology_Handler,
 		},
+		{
+			MethodName: "GetTenantConfig",
+			Handler:    _AutoScale_GetTenantConfig_Handler,
+		},
+		{
+			MethodName: "CreateTenantConfig",
+			Handler:    _AutoScale_CreateTenantConfig_Handler,
+		},
+		{
+			MethodName: "UpdateTenantConfig",
+			Handler:    _AutoScale_UpdateTenantConfig_Handler,
+		},
+		{
+			MethodName: "DeleteTenantConfig",
+			Handler:    _AutoScale_DeleteTenantConfig_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "autoscale.proto",
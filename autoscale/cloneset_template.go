@@ -0,0 +1,232 @@
+package autoscale
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+
+	"github.com/openkruise/kruise-api/apps/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"sigs.k8s.io/yaml"
+)
+
+// AnnotationKeyOfLastAppliedCloneSet keeps the cloneset applied last time, so that fields removed from template are removed from cloneset too
+const AnnotationKeyOfLastAppliedCloneSet = "tiflash.autoscale/last-applied-cloneset"
+
+// Template of compute pod cloneset is loaded from CloneSetTemplateConfigMap first, then CloneSetTemplateFile, then the built-in one
+var (
+	CloneSetTemplateFile         = ""
+	CloneSetTemplateConfigMap    = ""
+	CloneSetTemplateConfigMapKey = "cloneset.yaml"
+)
+
+//go:embed readnode_cloneset.yaml
+var defaultCloneSetTemplate string
+
+// RenderCloneSetTemplate substitutes ${NAME} in template by vars, unknown variable is an error
+func RenderCloneSetTemplate(tmpl string, vars map[string]string) (*v1alpha1.CloneSet, error) {
+	var unknownVars []string
+	rendered := os.Expand(tmpl, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		v, ok := vars[name]
+		if !ok {
+			unknownVars = append(unknownVars, name)
+		}
+		return v
+	})
+	if len(unknownVars) > 0 {
+		return nil, fmt.Errorf("unknown variables of cloneset template: %v", unknownVars)
+	}
+	cloneSet := &v1alpha1.CloneSet{}
+	if err := yaml.UnmarshalStrict([]byte(rendered), cloneSet); err != nil {
+		return nil, fmt.Errorf("invalid cloneset template: %v", err)
+	}
+	if len(cloneSet.Spec.Template.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("invalid cloneset template: no container")
+	}
+	return cloneSet, nil
+}
+
+func (c *ClusterManager) loadCloneSetTemplate() (string, error) {
+	if CloneSetTemplateConfigMap != "" {
+		configMap, err := c.K8sCli.CoreV1().ConfigMaps(c.Namespace).Get(context.TODO(), CloneSetTemplateConfigMap, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		tmpl, ok := configMap.Data[CloneSetTemplateConfigMapKey]
+		if !ok {
+			return "", fmt.Errorf("key %v not found in configmap %v", CloneSetTemplateConfigMapKey, CloneSetTemplateConfigMap)
+		}
+		return tmpl, nil
+	}
+	if CloneSetTemplateFile != "" {
+		tmpl, err := os.ReadFile(CloneSetTemplateFile)
+		if err != nil {
+			return "", err
+		}
+		return string(tmpl), nil
+	}
+	return defaultCloneSetTemplate, nil
+}
+
+func (c *ClusterManager) getVarsOfCloneSetTemplate(podClass *PodClass, replicas int) map[string]string {
+	return map[string]string{
+		"CLONESET_NAME":    podClass.GetCloneSetName(),
+		"POD_CLASS":        podClass.Name,
+		"NAMESPACE":        c.Namespace,
+		"REPLICAS":         strconv.Itoa(replicas),
+		"SUPERVISOR_IMAGE": GetSupervisorDockerImager(),
+		"BUSYBOX_IMAGE":    GetBusyBoxDockerImager(),
+		"S3_BUCKET":        ReadNodeLogUploadS3Bucket,
+	}
+}
+
+// buildDesiredCloneSet renders template of pod class, then overrides fields that autoscaler depends on
+func (c *ClusterManager) buildDesiredCloneSet(tmpl string, podClass *PodClass, replicas int) (*v1alpha1.CloneSet, error) {
+	cloneSet, err := RenderCloneSetTemplate(tmpl, c.getVarsOfCloneSetTemplate(podClass, replicas))
+	if err != nil {
+		return nil, err
+	}
+	cloneSetName := podClass.GetCloneSetName()
+	cloneSet.Name = cloneSetName
+	if cloneSet.Labels == nil {
+		cloneSet.Labels = make(map[string]string)
+	}
+	cloneSet.Labels["app"] = cloneSetName
+	cloneSet.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": cloneSetName}}
+	if cloneSet.Spec.Replicas == nil {
+		cloneSet.Spec.Replicas = Int32Ptr(int32(replicas))
+	}
+	podTemplate := &cloneSet.Spec.Template
+	if podTemplate.Labels == nil {
+		podTemplate.Labels = make(map[string]string)
+	}
+	podTemplate.Labels["app"] = cloneSetName
+	if len(podTemplate.Spec.Tolerations) == 0 {
+		podTemplate.Spec.Tolerations = c.getComputePodToleration()
+	}
+	if podTemplate.Spec.Affinity == nil || podTemplate.Spec.Affinity.PodAntiAffinity == nil {
+		if antiAffinity := c.getComputePodAntiAffinity(); antiAffinity != nil {
+			if podTemplate.Spec.Affinity == nil {
+				podTemplate.Spec.Affinity = &v1.Affinity{}
+			}
+			podTemplate.Spec.Affinity.PodAntiAffinity = antiAffinity
+		}
+	}
+	// scaling assumes cores of pod class, so resources of supervisor are always from config
+	podTemplate.Spec.Containers[0].Resources = podClass.GetResourceRequirements()
+	return cloneSet, nil
+}
+
+// toAppliedJSON drops fields that are never patched: status, creation timestamp, replicas and partition which are managed by autoscaler
+func toAppliedJSON(cloneSet *v1alpha1.CloneSet) ([]byte, error) {
+	data, err := json.Marshal(cloneSet)
+	if err != nil {
+		return nil, err
+	}
+	obj := make(map[string]interface{})
+	if err = json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	delete(obj, "status")
+	if objMeta, ok := obj["metadata"].(map[string]interface{}); ok {
+		delete(objMeta, "creationTimestamp")
+	}
+	if spec, ok := obj["spec"].(map[string]interface{}); ok {
+		delete(spec, "replicas")
+		if updateStrategy, ok := spec["updateStrategy"].(map[string]interface{}); ok {
+			delete(updateStrategy, "partition")
+		}
+	}
+	return json.Marshal(obj)
+}
+
+// setLastAppliedAnnotation is called before cloneset is created, so that the first patch knows what was applied
+func setLastAppliedAnnotation(cloneSet *v1alpha1.CloneSet) error {
+	delete(cloneSet.Annotations, AnnotationKeyOfLastAppliedCloneSet)
+	lastApplied, err := toAppliedJSON(cloneSet)
+	if err != nil {
+		return err
+	}
+	if cloneSet.Annotations == nil {
+		cloneSet.Annotations = make(map[string]string)
+	}
+	cloneSet.Annotations[AnnotationKeyOfLastAppliedCloneSet] = string(lastApplied)
+	return nil
+}
+
+// isAppliedJSONEqual compares applied json by value, so that order of keys doesn't matter
+func isAppliedJSONEqual(a []byte, b []byte) bool {
+	var objA, objB interface{}
+	if json.Unmarshal(a, &objA) != nil || json.Unmarshal(b, &objB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(objA, objB)
+}
+
+func getPodTemplateOfAppliedJSON(data []byte) interface{} {
+	obj := make(map[string]interface{})
+	if json.Unmarshal(data, &obj) != nil {
+		return nil
+	}
+	if spec, ok := obj["spec"].(map[string]interface{}); ok {
+		return spec["template"]
+	}
+	return nil
+}
+
+// isPodTemplateChanged compares pod template of desired cloneset with the one applied last time,
+// it's true if live cloneset is created by old version without last applied annotation
+func isPodTemplateChanged(desired *v1alpha1.CloneSet, live *v1alpha1.CloneSet) bool {
+	lastApplied, ok := live.Annotations[AnnotationKeyOfLastAppliedCloneSet]
+	if !ok {
+		return true
+	}
+	desiredJSON, err := toAppliedJSON(desired)
+	if err != nil {
+		return true
+	}
+	return !reflect.DeepEqual(getPodTemplateOfAppliedJSON([]byte(lastApplied)), getPodTemplateOfAppliedJSON(desiredJSON))
+}
+
+// CreateCloneSetPatch returns three-way json merge patch from live cloneset to desired one, nil if nothing changed.
+// Whether cloneset changes is decided by last applied annotation instead of live cloneset, since containers of live one
+// carry defaults set by server, and merge patch would replace the whole list every time.
+func CreateCloneSetPatch(desired *v1alpha1.CloneSet, live *v1alpha1.CloneSet) ([]byte, error) {
+	modified := desired.DeepCopy()
+	if err := setLastAppliedAnnotation(modified); err != nil {
+		return nil, err
+	}
+	if lastApplied, ok := live.Annotations[AnnotationKeyOfLastAppliedCloneSet]; ok &&
+		isAppliedJSONEqual([]byte(lastApplied), []byte(modified.Annotations[AnnotationKeyOfLastAppliedCloneSet])) {
+		return nil, nil
+	}
+	modifiedJSON, err := toAppliedJSON(modified)
+	if err != nil {
+		return nil, err
+	}
+	currentJSON, err := json.Marshal(live)
+	if err != nil {
+		return nil, err
+	}
+	var originalJSON []byte
+	if v, ok := live.Annotations[AnnotationKeyOfLastAppliedCloneSet]; ok {
+		originalJSON = []byte(v)
+	}
+	patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(originalJSON, modifiedJSON, currentJSON)
+	if err != nil {
+		return nil, err
+	}
+	if string(patch) == "{}" {
+		return nil, nil
+	}
+	return patch, nil
+}
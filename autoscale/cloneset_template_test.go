@@ -0,0 +1,91 @@
+package autoscale
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestRenderCloneSetTemplate(t *testing.T) {
+	InitTestEnv()
+	oldRunMode, oldBucket := OptionRunMode, ReadNodeLogUploadS3Bucket
+	defer func() {
+		OptionRunMode, ReadNodeLogUploadS3Bucket = oldRunMode, oldBucket
+	}()
+	OptionRunMode, ReadNodeLogUploadS3Bucket = RunModeServeless, "s3-bucket"
+	c := &ClusterManager{Namespace: "tiflash-autoscale"}
+	podClass := &PodClass{Name: "small", CoresOfPod: 4}
+
+	cloneSet, err := c.buildDesiredCloneSet(defaultCloneSetTemplate, podClass, 3)
+	assertEqual(t, err, nil)
+	assertEqual(t, cloneSet.Name, ReadNodeCloneSetName+"-small")
+	assertEqual(t, cloneSet.Spec.Selector.MatchLabels["app"], ReadNodeCloneSetName+"-small")
+	assertEqual(t, cloneSet.Spec.Template.Labels["app"], ReadNodeCloneSetName+"-small")
+	assertEqual(t, *cloneSet.Spec.Replicas, int32(3))
+	supervisor := cloneSet.Spec.Template.Spec.Containers[0]
+	assertEqual(t, supervisor.Image, GetSupervisorDockerImager())
+	assertEqual(t, supervisor.Env[2].Value, "s3-bucket")
+	assertEqual(t, DumpResourceRequirements(supervisor.Resources), DumpResourceRequirements(podClass.GetResourceRequirements()))
+	assertEqual(t, len(cloneSet.Spec.Template.Spec.Tolerations), 1)
+	assertEqual(t, cloneSet.Spec.Template.Spec.Affinity.PodAntiAffinity != nil, true)
+
+	// variables are checked, "$$" is kept
+	_, err = RenderCloneSetTemplate("metadata:\n  name: ${UNKNOWN}\n", nil)
+	assertEqual(t, err != nil, true)
+	_, err = RenderCloneSetTemplate("spec:\n  replica: 1\n", nil)
+	assertEqual(t, err != nil, true)
+	tmpl := strings.Replace(defaultCloneSetTemplate, "tail -n0 -F /tiflash/log/tiflash.log;", "tail -n0 -F $${LOG};", 1)
+	cloneSet, err = c.buildDesiredCloneSet(tmpl, podClass, 3)
+	assertEqual(t, err, nil)
+	assertEqual(t, cloneSet.Spec.Template.Spec.Containers[1].Args[2], "touch /tiflash/log/tiflash.log; tail -n0 -F ${LOG};")
+}
+
+func TestCreateCloneSetPatch(t *testing.T) {
+	InitTestEnv()
+	c := &ClusterManager{Namespace: "tiflash-autoscale"}
+	podClass := GetPodClass("")
+	desired, err := c.buildDesiredCloneSet(defaultCloneSetTemplate, podClass, 1)
+	assertEqual(t, err, nil)
+	live := desired.DeepCopy()
+	assertEqual(t, setLastAppliedAnnotation(live), nil)
+
+	// replicas and status are managed by others
+	live.Spec.Replicas = Int32Ptr(5)
+	live.Status.Replicas = 5
+	patch, err := CreateCloneSetPatch(desired, live)
+	assertEqual(t, err, nil)
+	assertEqual(t, patch == nil, true)
+
+	// live cloneset carries defaults set by server, it's unchanged as long as template is unchanged
+	for i := range live.Spec.Template.Spec.Containers {
+		container := &live.Spec.Template.Spec.Containers[i]
+		container.TerminationMessagePath = v1.TerminationMessagePathDefault
+		container.TerminationMessagePolicy = v1.TerminationMessageReadFile
+		container.ImagePullPolicy = v1.PullIfNotPresent
+	}
+	live.Spec.Template.Spec.RestartPolicy = v1.RestartPolicyAlways
+	live.Spec.Template.Spec.DNSPolicy = v1.DNSClusterFirst
+	live.Spec.Template.Spec.SchedulerName = v1.DefaultSchedulerName
+	patch, err = CreateCloneSetPatch(desired, live)
+	assertEqual(t, err, nil)
+	assertEqual(t, patch == nil, true)
+
+	desired.Spec.Template.Spec.Containers[0].Image = "supervisor:new"
+	desired.Spec.Template.Spec.NodeSelector = nil
+	patch, err = CreateCloneSetPatch(desired, live)
+	assertEqual(t, err, nil)
+	assertEqual(t, strings.Contains(string(patch), "supervisor:new"), true)
+	assertEqual(t, strings.Contains(string(patch), `"nodeSelector":null`), true)
+	assertEqual(t, strings.Contains(string(patch), "replicas"), false)
+
+	// cloneset created by old version has no last applied annotation, its extra fields are kept
+	live = desired.DeepCopy()
+	live.Annotations = map[string]string{"tiflash.autoscale.rdversion": "1"}
+	live.Spec.Template.Spec.Containers[0].Resources = v1.ResourceRequirements{}
+	patch, err = CreateCloneSetPatch(desired, live)
+	assertEqual(t, err, nil)
+	assertEqual(t, strings.Contains(string(patch), "rdversion"), false)
+	assertEqual(t, strings.Contains(string(patch), "resources"), true)
+	assertEqual(t, strings.Contains(string(patch), AnnotationKeyOfLastAppliedCloneSet), true)
+}
@@ -13,9 +13,11 @@ import (
 	"github.com/openkruise/kruise-api/apps/v1alpha1"
 	kruiseclientset "github.com/openkruise/kruise-api/client/clientset/versioned"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	restclient "k8s.io/client-go/rest"
@@ -31,8 +33,6 @@ const (
 	RunModeCustom
 )
 
-const AnnotationKeyOfSupervisorRDVersionn = "tiflash.autoscale.rdversion"
-
 func GetSupervisorDockerImager() string {
 	if HardCodeSupervisorImage != "" {
 		return HardCodeSupervisorImage
@@ -92,14 +92,15 @@ func getK8sConfig() (*restclient.Config, error) {
 // TODO mutex protection
 type ClusterManager struct {
 	Namespace     string
-	CloneSetName  string
+	CloneSetName  string // cloneset of default pod class, pods of other classes are in clonesets named after it
 	SnsManager    *AwsSnsManager
 	PromClient    *PromClient
 	AutoScaleMeta *AutoScaleMeta
 	K8sCli        *kubernetes.Clientset
 	MetricsCli    *metricsv.Clientset
 	Cli           *kruiseclientset.Clientset
-	CloneSet      *v1alpha1.CloneSet
+	DynamicCli    dynamic.Interface             // only for TiFlashTenant resources, nil if TenantCRDEnabled is off
+	CloneSets     map[string]*v1alpha1.CloneSet // pod class -> cloneset, guarded by muOfCloneSet
 	wg            sync.WaitGroup
 	shutdown      int32 // atomic
 	watchMu       sync.Mutex
@@ -110,6 +111,14 @@ type ClusterManager struct {
 	tsContainer            *TimeSeriesContainer
 	lstTsMap               map[string]int64 // TODO remove it
 	analyzeTaskMap         sync.Map         //map[string]*AnalyzeTask
+	predictor              *Predictor
+	decisions              *DecisionRecorder
+	upgrades               *UpgradeTracker
+
+	identity       string      // identity in leader election, "podName_podIP"
+	isLeader       atomic.Bool // only leader changes pods and cloneset, followers keep a read-only view
+	muOfLeader     sync.Mutex
+	leaderIdentity string
 }
 
 // cnt: want, create, get
@@ -175,25 +184,41 @@ func (c *ClusterManager) collectMetricsLoop(metricsTopic MetricsTopic, fromMetri
 		Logger.Infof("[collectMetrics] query %v, fromMetricServer: %v", metricsTopic.String(), fromMetricServer)
 		lastQueryTs = time.Now().Unix()
 		var metricOfPods map[string]*TimeValPair
+		var memOfPods map[string]*TimeValPair
+		var taskCntOfPods map[string]*TimeValPair
 		var err error
 
 		if metricsTopic == MetricsTopicCpu {
 			if fromMetricServer {
-				labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"app": c.CloneSetName}}
 				// st := time.Now().UnixNano()
 				podMetricsList, err := c.MetricsCli.MetricsV1beta1().PodMetricses(c.Namespace).List(
-					context.TODO(), metav1.ListOptions{LabelSelector: labels.Set(labelSelector.MatchLabels).String()})
+					context.TODO(), metav1.ListOptions{LabelSelector: GetLabelSelectorOfComputePods()})
 				if err == nil {
 					metricOfPods = make(map[string]*TimeValPair)
+					memOfPods = make(map[string]*TimeValPair)
 					for _, pod := range podMetricsList.Items {
 						metricOfPods[pod.Name] = &TimeValPair{
 							time:  pod.Timestamp.Unix(),
 							value: pod.Containers[0].Usage.Cpu().AsApproximateFloat64(),
 						}
+						memOfPods[pod.Name] = &TimeValPair{
+							time:  pod.Timestamp.Unix(),
+							value: pod.Containers[0].Usage.Memory().AsApproximateFloat64(),
+						}
 					}
 				}
 			} else {
 				metricOfPods, err = c.PromClient.QueryCpu()
+				var memErr error
+				memOfPods, memErr = c.PromClient.QueryMemWorkingSet()
+				if memErr != nil {
+					Logger.Warnf("[collectMetrics]fail to query memory, skip it in this round, err:%v", memErr.Error())
+				}
+			}
+			var taskCntErr error
+			taskCntOfPods, taskCntErr = c.PromClient.QueryHandlingRequestCnt()
+			if taskCntErr != nil {
+				Logger.Warnf("[collectMetrics]fail to query handling request count, skip it in this round, err:%v", taskCntErr.Error())
 			}
 		} else if metricsTopic == MetricsTopicTaskCnt {
 			metricOfPods, err = c.PromClient.QueryComputeTask()
@@ -220,11 +245,17 @@ func (c *ClusterManager) collectMetricsLoop(metricsTopic MetricsTopic, fromMetri
 				continue
 			}
 			if metricsTopic == MetricsTopicCpu {
+				memVal := math.NaN()
+				if v, ok := memOfPods[podName]; ok {
+					memVal = v.value
+				}
+				taskCntVal := math.NaN()
+				if v, ok := taskCntOfPods[podName]; ok {
+					taskCntVal = v.value
+				}
 				tsContainer.InsertWithUserCfg(podName, metric.time,
-					[]float64{
-						metric.value,
-						0.0, //TODO remove this dummy mem metric
-					}, tenantDesc.GetScaleIntervalSec(), MetricsTopicCpu)
+					NewResourceSample(metric.value, memVal, taskCntVal),
+					tenantDesc.GetScaleIntervalSec(), MetricsTopicCpu)
 			} else if metricsTopic == MetricsTopicTaskCnt {
 				autoPauseIntervalSeconds := tenantDesc.GetAutoPauseIntervalSec()
 				if autoPauseIntervalSeconds == 0 {
@@ -322,7 +353,7 @@ func (task *AnalyzeTask) analyzeTaskLoop(c *ClusterManager) {
 
 		cntOfPods := tenant.GetCntOfPods()
 		if tenant.IsDisabled() {
-			ok := c.AsyncPause(tenant.Name)
+			ok := c.pauseTenant(tenant, DecisionContext{Rule: "disabled", Reason: "tenant disabled"})
 			Logger.Infof("[analyzeTaskLoop][%v]tenant disabled, try to pause return %v, cntOfPods:%v ", tenant.Name, ok, cntOfPods)
 			continue
 		}
@@ -331,18 +362,33 @@ func (task *AnalyzeTask) analyzeTaskLoop(c *ClusterManager) {
 		if !isStateCorrect {
 			Logger.Errorf("[analyzeTaskLoop][%v] incorrect state:%v expect_state:%v", tenant.Name, TenantState2String(tenant.GetState()), TenantState2String(expectedStateIfIncorrect))
 			if expectedStateIfIncorrect == TenantStateResumed {
-				c.Resume(tenant.Name)
+				c.resumeTenant(tenant, DecisionContext{Rule: "state-correction", Reason: "paused tenant has pods, expect resumed"})
 			} else if expectedStateIfIncorrect == TenantStatePaused {
-				c.AsyncPause(tenant.Name)
+				c.pauseTenant(tenant, DecisionContext{Rule: "state-correction", Reason: "expect paused"})
 			}
 			continue
 		}
 
+		// scheduled scale rules, merged with reactive recommendation below
+		scheduled := tenant.GetScheduledScaleResult(roundBeginTime)
+		if len(scheduled.Active) > 0 {
+			Logger.Debugf("[analyzeTaskLoop][%v]active schedules:%v, min_pods:%v, max_pods:%v, force_resume:%v", tenant.Name, scheduled.Active, scheduled.MinCntOfPod, scheduled.MaxCntOfPod, scheduled.ForceResume)
+		}
+		if scheduled.ForceResume && tenant.GetState() == TenantStatePaused {
+			Logger.Infof("[analyzeTaskLoop][%v]force resume by schedules:%v", tenant.Name, scheduled.Active)
+			c.resumeTenant(tenant, DecisionContext{Rule: "schedule", Reason: fmt.Sprintf("force resume by schedules:%v", scheduled.Active)})
+			continue
+		}
+
 		//anto scale/pause analyze of tenant
 		if tenant.GetState() != TenantStateResumed { // tenant not available
 			Logger.Infof("[analyzeTaskLoop][%v]tenant's state is not resumed! current:%v", tenant.Name, tenant.GetState())
 			continue
 		}
+		if tenant.isScalingOut.Load() { // pods of tenant are changing, they are analyzed in next round
+			Logger.Infof("[analyzeTaskLoop][%v]scale-out is in progress, skip", tenant.Name)
+			continue
+		}
 
 		// PreCondition of Analytics:
 		//    1. now - max(startTimeOfAssignOfPod) >= AnalyzeInterval(scale/autopuase)
@@ -351,7 +397,9 @@ func (task *AnalyzeTask) analyzeTaskLoop(c *ClusterManager) {
 
 		// Auto Pause
 		autoPauseIntervalSec := tenant.GetAutoPauseIntervalSec()
-		if autoPauseIntervalSec != 0 { // if auto-pause is on
+		if autoPauseIntervalSec != 0 && scheduled.ForceResume {
+			Logger.Debugf("[analyzeTaskLoop][%v]auto pause is suppressed by schedules:%v", tenant.Name, scheduled.Active)
+		} else if autoPauseIntervalSec != 0 { // if auto-pause is on
 			taskCntStats, _, _, _, tenantMetricDesc := c.AutoScaleMeta.ComputeStatisticsOfTenant(tenant.Name, c.tsContainer, "AutoPauseAnalytics", MetricsTopicTaskCnt)
 			if taskCntStats != nil {
 				// autoPauseIntervalSec := tenant.GetAutoPauseIntervalSec()
@@ -360,7 +408,12 @@ func (task *AnalyzeTask) analyzeTaskLoop(c *ClusterManager) {
 					totalTaskCnt := taskCntStats[0].Sum()
 					if totalTaskCnt < 1 { //test is zero, since it's a float, "< 1" may be better
 						Logger.Infof("[analyzeTaskLoop][%v]auto pause, tenant: %v MinOfPodTimeseriesSize:%v MinOfMetricInterval:%v AutoPauseIntervalSec:%v   ", tenant.Name, tenant.Name, tenantMetricDesc.MinOfPodTimeseriesSize, now-tenantMetricDesc.MaxOfPodMinTime, autoPauseIntervalSec)
-						c.AsyncPause(tenant.Name)
+						c.pauseTenant(tenant, DecisionContext{
+							Rule:       "auto-pause",
+							Reason:     fmt.Sprintf("no task in last %vs", autoPauseIntervalSec),
+							Metrics:    map[string]float64{"taskcnt_sum": totalTaskCnt},
+							Thresholds: map[string]float64{"auto_pause_interval_sec": float64(autoPauseIntervalSec)},
+						})
 						// continue //skip auto scale TODO revert
 					}
 				} else {
@@ -375,12 +428,13 @@ func (task *AnalyzeTask) analyzeTaskLoop(c *ClusterManager) {
 
 		// Auto Scale
 		cntOfPods = tenant.GetCntOfPods()
-		if cntOfPods < tenant.GetMinCntOfPod() {
-			Logger.Infof("[analyzeTaskLoop][%v] StateResume and cntOfPods < tenant.MinCntOfPod, add more pods if curCntofPods != 0, curCntofPods:%v minCntOfPods:%v tenant: %v", tenant.Name, cntOfPods, tenant.GetMinCntOfPod(), tenant.Name)
-			c.AutoScaleMeta.ResizePodsOfTenant(cntOfPods, tenant.GetInitCntOfPod(), tenant.Name, c.tsContainer)
-			if c.SnsManager != nil {
-				c.SnsManager.TryToPublishTopology(tenant.Name, time.Now().UnixNano(), tenant.GetPodNames()) // public latest topology into SNS
-			}
+		if cntOfPods < scheduled.MinCntOfPod {
+			Logger.Infof("[analyzeTaskLoop][%v] StateResume and cntOfPods < tenant.MinCntOfPod, add more pods if curCntofPods != 0, curCntofPods:%v minCntOfPods:%v schedules:%v tenant: %v", tenant.Name, cntOfPods, scheduled.MinCntOfPod, scheduled.Active, tenant.Name)
+			c.resizeTenant(tenant, cntOfPods, MaxInt(tenant.GetInitCntOfPod(), scheduled.MinCntOfPod), DecisionContext{
+				Rule:       "min-pods",
+				Reason:     fmt.Sprintf("pods less than min, schedules:%v", scheduled.Active),
+				Thresholds: map[string]float64{"min_pods": float64(scheduled.MinCntOfPod)},
+			})
 		} else {
 			stats, podCpuMap, _, _, tenantMetricDesc := c.AutoScaleMeta.ComputeStatisticsOfTenant(tenant.Name, c.tsContainer, "analyzeMetrics", MetricsTopicCpu)
 			/// TODO use tenantMetricDesc to check preCondition of auto scale of this tenant
@@ -391,19 +445,41 @@ func (task *AnalyzeTask) analyzeTaskLoop(c *ClusterManager) {
 				tenantMetricDesc.PodCnt, podCnt,
 				tenantMetricDesc.MinOfPodTimeseriesSize, now-tenantMetricDesc.MaxOfPodMinTime, autoScaleIntervalSec)
 			if stats != nil && tenantMetricDesc.MinOfPodTimeseriesSize >= 2 && tenantMetricDesc.MaxOfPodMinTime < now-int64(autoScaleIntervalSec)+30 {
-				cpuusage := stats[0].Avg()
-
 				Logger.Infof("[analyzeTaskLoop][%v]ComputeStatisticsOfTenant, Tenant %v , cpu usage: %v %v , PodsCpuMap: %+v ", tenant.Name, tenant.Name,
 					stats[0].Avg(), stats[0].Cnt(), podCpuMap)
 
-				minCpuUsageThreshold, maxCpuUsageThreshold := tenant.GetLowerAndUpperCpuScaleThreshold()
-				bestPods, _ := ComputeBestPodsInRuleOfCompute(tenant, cpuusage, minCpuUsageThreshold, maxCpuUsageThreshold)
-				if bestPods != -1 && cntOfPods != bestPods {
-					Logger.Infof("[analyzeTaskLoop][%v] resize pods, from %v to  %v , tenant: %v", tenant.Name, tenant.GetCntOfPods(), bestPods, tenant.Name)
-					c.AutoScaleMeta.ResizePodsOfTenant(cntOfPods, bestPods, tenant.Name, c.tsContainer)
-					if c.SnsManager != nil {
-						c.SnsManager.TryToPublishTopology(tenant.Name, time.Now().UnixNano(), tenant.GetPodNames()) // public latest topology into SNS
+				snapshot := &TenantScaleSnapshot{
+					Name:        tenant.Name,
+					CntOfPods:   cntOfPods,
+					MinCntOfPod: scheduled.MinCntOfPod,
+					MaxCntOfPod: scheduled.MaxCntOfPod,
+					Stats:       map[MetricsTopic][]AvgSigma{MetricsTopicCpu: stats},
+					MetricDescs: map[MetricsTopic]*DescOfTenantTimeSeries{MetricsTopicCpu: tenantMetricDesc},
+					Conf:        tenant.GetConf(),
+				}
+				policy := GetScalePolicy(snapshot.Conf.ScalePolicyName)
+				bestPods, reason := policy.ComputeTargetCntOfPods(snapshot)
+				if forecastCores, ok := c.predictor.GetForecast(tenant.Name, now); ok {
+					_, upperLimit := snapshot.Conf.GetLowerAndUpperCpuScaleThreshold()
+					predictedPods := ComputeTargetCntOfPodsByForecast(forecastCores, snapshot.Conf.GetCoresOfPod(), upperLimit, snapshot.MinCntOfPod, snapshot.MaxCntOfPod)
+					if predictedPods > MaxInt(bestPods, cntOfPods) {
+						Logger.Infof("[analyzeTaskLoop][%v] pre-scale by forecast, forecast_cores:%.3f, target of policy:%v, predicted target:%v", tenant.Name, forecastCores, bestPods, predictedPods)
+						bestPods = predictedPods
+						reason = fmt.Sprintf("%v; forecast:%.3f cores, predicted target %v", reason, forecastCores, predictedPods)
 					}
+				}
+				stabilizedPods, holdReason := tenant.StabilizeTarget(now, cntOfPods, bestPods)
+				if holdReason != "" {
+					Logger.Infof("[analyzeTaskLoop][%v] target of policy %v is stabilized to %v, tenant: %v, reason: %v", tenant.Name, bestPods, stabilizedPods, tenant.Name, holdReason)
+					reason = fmt.Sprintf("%v; %v", reason, holdReason)
+				}
+				bestPods = stabilizedPods
+				if bestPods == -1 || bestPods <= cntOfPods { // tenant needs no more pods than it has, drop demand which is not met yet
+					c.AutoScaleMeta.clearOutstandingDemandOfTenant(tenant)
+				}
+				if bestPods != -1 && cntOfPods != bestPods {
+					Logger.Infof("[analyzeTaskLoop][%v] resize pods, from %v to  %v , tenant: %v, policy: %v, reason: %v", tenant.Name, tenant.GetCntOfPods(), bestPods, tenant.Name, policy.Name(), reason)
+					c.resizeTenant(tenant, cntOfPods, bestPods, NewDecisionContextOfSnapshot(snapshot, policy.Name(), reason))
 				} else {
 					// unchanged
 
@@ -497,13 +573,18 @@ func (c *ClusterManager) AsyncPause(tenant string) bool {
 }
 
 func (c *ClusterManager) Resume(tenant string) bool {
+	ret, _ := c.ResumeWithResult(tenant)
+	return ret
+}
+
+func (c *ClusterManager) ResumeWithResult(tenant string) (bool, PodsChangeResult) {
 	// resultChan := make(chan int)
 	resultChan, ret := c.AutoScaleMeta.AsyncResume(tenant, c.tsContainer)
-	addPodsResult := int(-1)
+	addPodsResult := PodsChangeResult{FailCnt: -1}
 	if resultChan != nil {
 		addPodsResult = <-resultChan
 	}
-	return ret && addPodsResult != -1
+	return ret && addPodsResult.FailCnt != -1, addPodsResult
 }
 
 // checked
@@ -514,10 +595,9 @@ func (c *ClusterManager) watchPodsLoop(resourceVersion string) {
 		if atomic.LoadInt32(&c.shutdown) != 0 {
 			return
 		}
-		labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"app": c.CloneSetName}}
 		watcher, err := c.K8sCli.CoreV1().Pods(c.Namespace).Watch(context.TODO(),
 			metav1.ListOptions{
-				LabelSelector:   labels.Set(labelSelector.MatchLabels).String(),
+				LabelSelector:   GetLabelSelectorOfComputePods(),
 				ResourceVersion: resourceVersion,
 			})
 
@@ -578,9 +658,8 @@ func (c *ClusterManager) watchPodsLoop(resourceVersion string) {
 
 // checked
 func (c *ClusterManager) loadPods() string {
-	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"app": c.CloneSetName}}
 	pods, err := c.K8sCli.CoreV1().Pods(c.Namespace).List(context.TODO(),
-		metav1.ListOptions{LabelSelector: labels.Set(labelSelector.MatchLabels).String()})
+		metav1.ListOptions{LabelSelector: GetLabelSelectorOfComputePods()})
 	if err != nil {
 		Logger.Errorf("[loadPodsAtStartup] error, %v", err.Error())
 		panic(err)
@@ -610,7 +689,7 @@ func (c *ClusterManager) getComputePodAntiAffinity() *v1.PodAntiAffinity {
 							{
 								Key:      "app",
 								Operator: "In",
-								Values:   []string{c.CloneSetName, "autoscale"},
+								Values:   c.getAppLabelsOfComputeAndAutoscalePods(),
 							},
 						},
 					},
@@ -620,6 +699,15 @@ func (c *ClusterManager) getComputePodAntiAffinity() *v1.PodAntiAffinity {
 	}
 }
 
+// one compute pod per node regardless of its class, and no compute pod on the node of autoscaler
+func (c *ClusterManager) getAppLabelsOfComputeAndAutoscalePods() []string {
+	ret := make([]string, 0, 4)
+	for _, name := range GetPodClassNames() {
+		ret = append(ret, GetCloneSetNameOfPodClass(name))
+	}
+	return append(ret, "autoscale")
+}
+
 func (c *ClusterManager) getComputePodToleration() []v1.Toleration {
 	if OptionRunMode == RunModeServeless {
 		return []v1.Toleration{
@@ -635,268 +723,109 @@ func (c *ClusterManager) getComputePodToleration() []v1.Toleration {
 	}
 }
 
-func (c *ClusterManager) createCloneSet(cloneSet v1alpha1.CloneSet) (*v1alpha1.CloneSet, error) {
-	Logger.Infof("[initK8sComponents]create clonneSet")
-	c.AutoScaleMeta.PrewarmPool.cntOfPending.Add(*cloneSet.Spec.Replicas)
+func (c *ClusterManager) createCloneSet(pool *PrewarmPool, cloneSet v1alpha1.CloneSet) (*v1alpha1.CloneSet, error) {
+	Logger.Infof("[initK8sComponents]create clonneSet %v", cloneSet.Name)
+	pool.cntOfPending.Add(*cloneSet.Spec.Replicas)
 	return c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Create(context.TODO(), &cloneSet, metav1.CreateOptions{})
 }
 
 // checked
 // TODO pod storage volume
 func (c *ClusterManager) initK8sComponents() {
-	// create cloneset if not exist
-	cloneSetList, err := c.Cli.AppsV1alpha1().CloneSets(c.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if c.IsLeader() {
+		c.initCloneSet()
+	}
+
+	// load k8s pods of cloneset
+	resVer := c.loadPods()
+
+	c.AutoScaleMeta.ScanStateOfPods(true)
+	c.AutoScaleMeta.IsRuntimeReady.Store(true)
+
+	// watch changes of pods
+	c.wg.Add(1)
+
+	go c.watchPodsLoop(resVer)
+}
+
+// create cloneset of each pod class
+func (c *ClusterManager) initCloneSet() {
+	tmpl, err := c.loadCloneSetTemplate()
 	if err != nil {
 		panic(err.Error())
 	}
-	Logger.Infof("list clonneSet: %+v ", len(cloneSetList.Items))
-	found := false
-	for _, cloneSet := range cloneSetList.Items {
-		if cloneSet.Name == c.CloneSetName {
-			found = true
-			break
-		}
+	for _, name := range GetPodClassNames() {
+		c.initCloneSetOfPodClass(tmpl, GetPodClass(name))
 	}
-	var retCloneset *v1alpha1.CloneSet
-	desiredCloneSet := v1alpha1.CloneSet{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: c.CloneSetName,
-			Labels: map[string]string{
-				"app": c.CloneSetName,
-			},
-			Annotations: map[string]string{
-				AnnotationKeyOfSupervisorRDVersionn: "1",
-			},
-		},
-		Spec: v1alpha1.CloneSetSpec{
-			Replicas: Int32Ptr(int32(c.AutoScaleMeta.SoftLimit)),
-			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app": c.CloneSetName,
-				}},
-			Template: v1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"app": c.CloneSetName,
-					},
-					Annotations: map[string]string{
-						"prometheus.io/path":   "/metrics",
-						"prometheus.io/port":   "8234",
-						"prometheus.io/scrape": "true",
-					},
-				},
-				// pod anti affinity
-				Spec: v1.PodSpec{
-					NodeSelector: map[string]string{
-						"tiflash.used-for-compute": "true",
-						// "node.kubernetes.io/instance-type": "m6a.2xlarge", // TODO use a non-hack way to bind readnode pod to specific nodes
-					},
-					Tolerations: c.getComputePodToleration(),
-					Affinity: &v1.Affinity{
-						PodAntiAffinity: c.getComputePodAntiAffinity(),
-					},
-					ServiceAccountName: "default",
-					// container
-					Containers: []v1.Container{
-						{
-							// ENV
-							Env: []v1.EnvVar{
-								{
-									Name: "POD_IP",
-									ValueFrom: &v1.EnvVarSource{
-										FieldRef: &v1.ObjectFieldSelector{
-											FieldPath: "status.podIP",
-										},
-									},
-								},
-								{
-									Name: "POD_NAME",
-									ValueFrom: &v1.EnvVarSource{
-										FieldRef: &v1.ObjectFieldSelector{
-											FieldPath: "metadata.name",
-										},
-									},
-								},
-								{
-									Name:  "S3_FOR_TIFLASH_LOG",
-									Value: ReadNodeLogUploadS3Bucket,
-								},
-							},
-							Name: "supervisor",
-							// docker image
-							Image:           GetSupervisorDockerImager(),
-							ImagePullPolicy: "IfNotPresent",
-							VolumeMounts: []v1.VolumeMount{
-								{
-									Name:      "sharedtmpdisk",
-									MountPath: "/tiflash/log/",
-								}},
-						},
-						/*
-							- name: count-log-1
-								image: busybox:1.28
-								args: [/bin/sh, -c, 'tail -n+1 -F /var/log/1.log']
-								volumeMounts:
-								- name: varlog
-								  mountPath: /var/log */
-						{
-							Name:            "tiflash-log",
-							Image:           GetBusyBoxDockerImager(),
-							ImagePullPolicy: "IfNotPresent",
-							Args: []string{
-								"/bin/sh",
-								"-c",
-								"touch /tiflash/log/tiflash.log; tail -n0 -F /tiflash/log/tiflash.log;",
-							},
-							VolumeMounts: []v1.VolumeMount{
-								{
-									Name:      "sharedtmpdisk",
-									MountPath: "/tiflash/log/",
-								},
-							},
-						},
-						{
-							Name:            "tiflash-err-log",
-							Image:           GetBusyBoxDockerImager(),
-							ImagePullPolicy: "IfNotPresent",
-							Args: []string{
-								"/bin/sh",
-								"-c",
-								"touch /tiflash/log/tiflash_error.log; tail -n0 -F /tiflash/log/tiflash_error.log;",
-							},
-							VolumeMounts: []v1.VolumeMount{
-								{
-									Name:      "sharedtmpdisk",
-									MountPath: "/tiflash/log/",
-								},
-							},
-						},
-					},
-					Volumes: []v1.Volume{
-						{
-							Name: "sharedtmpdisk",
-							// EmptyDir: nil,
-						},
-					},
-				},
-			},
-			// VolumeClaimTemplates: []v1.PersistentVolumeClaim{
-			// 	{
-			// 		ObjectMeta: metav1.ObjectMeta{
-			// 			Name: volumeName,
-			// 		},
-			// 		Spec: v1.PersistentVolumeClaimSpec{
-			// 			AccessModes: []v1.PersistentVolumeAccessMode{
-			// 				"ReadWriteOnce",
-			// 			},
-			// 			Resources: v1.ResourceRequirements{
-			// 				Requests: v1.ResourceList{
-			// 					"storage": resource.MustParse("20Gi"),
-			// 				},
-			// 			},
-			// 		},
-			// 	},
-			// },
-		},
+}
+
+// create cloneset of pod class if not exist, or patch it if it differs from template
+func (c *ClusterManager) initCloneSetOfPodClass(tmpl string, podClass *PodClass) {
+	cloneSetName := podClass.GetCloneSetName()
+	pool := c.AutoScaleMeta.GetPrewarmPool(podClass.Name)
+	desiredCloneSet, err := c.buildDesiredCloneSet(tmpl, podClass, pool.SoftLimit)
+	if err != nil {
+		panic(err.Error())
 	}
-	if !found {
+	Logger.Infof("[initK8sComponents]resources of supervisor of clonneSet %v: %v", cloneSetName, DumpResourceRequirements(desiredCloneSet.Spec.Template.Spec.Containers[0].Resources))
+	var retCloneset *v1alpha1.CloneSet
+	retCloneset, err = c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Get(context.TODO(), cloneSetName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
 		//create cloneSet since there is no desired cloneSet
-		retCloneset, err = c.createCloneSet(desiredCloneSet)
-	} else {
-		isCloneSetNeedRecreate := false
-		Logger.Infof("get clonneSet")
-		retCloneset, err = c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Get(context.TODO(), c.CloneSetName, metav1.GetOptions{})
+		if err = setLastAppliedAnnotation(desiredCloneSet); err != nil {
+			panic(err.Error())
+		}
+		retCloneset, err = c.createCloneSet(pool, *desiredCloneSet)
 		if err != nil {
 			panic(err.Error())
 		}
-		if retCloneset.ObjectMeta.Annotations != nil {
-			v, ok := retCloneset.ObjectMeta.Annotations[AnnotationKeyOfSupervisorRDVersionn]
-			if ok { // old annotation key exist
-				if desiredCloneSet.ObjectMeta.Annotations != nil {
-					v2, ok2 := desiredCloneSet.ObjectMeta.Annotations[AnnotationKeyOfSupervisorRDVersionn]
-					if ok2 {
-						if v2 != v {
-							isCloneSetNeedRecreate = true
-						}
-					}
-				}
-			} else { // old annotation key not exist
-				if desiredCloneSet.ObjectMeta.Annotations != nil {
-					_, ok2 := desiredCloneSet.ObjectMeta.Annotations[AnnotationKeyOfSupervisorRDVersionn]
-					if ok2 {
-						isCloneSetNeedRecreate = true
-					}
-				}
-			}
-		} else { // old annotations not exist
-			if desiredCloneSet.ObjectMeta.Annotations != nil {
-				_, ok2 := desiredCloneSet.ObjectMeta.Annotations[AnnotationKeyOfSupervisorRDVersionn]
-				if ok2 {
-					isCloneSetNeedRecreate = true
-				}
-			}
+	} else if err != nil {
+		panic(err.Error())
+	} else {
+		patch, err := CreateCloneSetPatch(desiredCloneSet, retCloneset)
+		if err == nil && patch != nil {
+			patch, err = setPartitionOfPatch(patch, retCloneset, isPodTemplateChanged(desiredCloneSet, retCloneset))
 		}
-		Logger.Infof("[initK8sComponents]old annotations:%+v, new annotations:%+v", retCloneset.ObjectMeta.Annotations, desiredCloneSet.ObjectMeta.Annotations)
-		if isCloneSetNeedRecreate {
-			MaxRetryTimes := 100
-			RetryIntervalSec := 1
-			retryCnt := 0
-			Logger.Infof("[initK8sComponents]recreate clonneSet")
-			Logger.Infof("[initK8sComponents]delete clonneSet")
-			err = c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Delete(context.TODO(), c.CloneSetName, metav1.DeleteOptions{})
-			for err != nil && retryCnt < MaxRetryTimes {
-				retryCnt++
-				time.Sleep(time.Duration(RetryIntervalSec) * time.Second)
-				Logger.Infof("[initK8sComponents][retry]delete clonneSet")
-				err = c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Delete(context.TODO(), c.CloneSetName, metav1.DeleteOptions{})
-			}
-			if err == nil {
-				retCloneset, err = c.createCloneSet(desiredCloneSet)
+		if err != nil {
+			panic(err.Error())
+		}
+		if patch != nil {
+			Logger.Infof("[initK8sComponents]patch clonneSet %v: %v", cloneSetName, string(patch))
+			retCloneset, err = CloneSetMergePatch(c.Cli, c.Namespace, cloneSetName, patch)
+			if err != nil {
+				panic(err.Error())
 			}
 		} else {
-			expectedImage := GetSupervisorDockerImager()
-			if expectedImage != retCloneset.Spec.Template.Spec.Containers[0].Image {
-				Logger.Warnf("[initK8sComponents]image has changed! from: %v to %v", retCloneset.Spec.Template.Spec.Containers[0].Image, expectedImage)
-				err = CloneSetPatchImage(c.Cli, c.Namespace, c.CloneSetName, expectedImage)
-				if err != nil {
-					panic(err.Error())
-				}
-				Logger.Infof("[initK8sComponents]image update done. from: %v to %v", retCloneset.Spec.Template.Spec.Containers[0].Image, expectedImage)
-				retCloneset, err = c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Get(context.TODO(), c.CloneSetName, metav1.GetOptions{})
-			}
+			Logger.Infof("[initK8sComponents]clonneSet %v is up to date", cloneSetName)
 		}
 	}
-	if err != nil {
-		panic(err.Error())
-	} else {
-		c.CloneSet = retCloneset.DeepCopy()
-	}
-
-	// load k8s pods of cloneset
-	resVer := c.loadPods()
-
-	c.AutoScaleMeta.ScanStateOfPods(true)
-	c.AutoScaleMeta.IsRuntimeReady.Store(true)
-
-	// watch changes of pods
-	c.wg.Add(2)
-
-	go c.watchPodsLoop(resVer)
-
-	// pod prepare & GC
-	go c.podPrepareLoop()
+	c.muOfCloneSet.Lock()
+	c.CloneSets[podClass.Name] = retCloneset.DeepCopy()
+	c.muOfCloneSet.Unlock()
 }
 
 func (c *ClusterManager) scanPodsStatesLoop() {
 	c.wg.Add(1)
 	defer c.wg.Done()
-	periodSec := 60
+	lastTs := time.Now().Unix()
 	for {
-		time.Sleep(time.Duration(periodSec) * time.Second)
+		time.Sleep(time.Second)
 		if atomic.LoadInt32(&c.shutdown) != 0 {
 			return
 		}
-		c.AutoScaleMeta.ScanStateOfPods(false)
+		periodSec := int64(60)
+		if !c.IsLeader() {
+			periodSec = int64(FollowerSyncIntervalSec)
+		}
+		if time.Now().Unix() < lastTs+periodSec {
+			continue
+		}
+		lastTs = time.Now().Unix()
+		if c.IsLeader() {
+			c.AutoScaleMeta.ScanStateOfPods(false)
+		} else {
+			c.syncViewOfFollower()
+		}
 	}
 }
 
@@ -987,42 +916,62 @@ func NewClusterManager(region string, isSnsEnabled bool) *ClusterManager {
 	ret := &ClusterManager{
 		Namespace:     namespace,
 		CloneSetName:  ReadNodeCloneSetName,
+		CloneSets:     make(map[string]*v1alpha1.CloneSet),
 		SnsManager:    snsManager,
 		PromClient:    promCli,
 		AutoScaleMeta: NewAutoScaleMeta(k8sConfig),
 		tsContainer:   NewTimeSeriesContainer(promCli),
 		lstTsMap:      make(map[string]int64),
+		predictor:     NewPredictor(),
+		decisions:     NewDecisionRecorder(DecisionRingCapOfTenant, DecisionAuditLogPath),
+		upgrades:      NewUpgradeTracker(),
 
 		K8sCli:                 K8sCli,
 		MetricsCli:             MetricsCli,
 		Cli:                    Cli,
 		ExternalFixPoolReplica: atomic.Int32{},
+		identity:               getIdentityOfSelf(),
 	}
 	ret.ExternalFixPoolReplica.Store(FixPoolDefaultReplica)
+	ret.AutoScaleMeta.drainer = ret
+	ret.AutoScaleMeta.tsContainer = ret.tsContainer
+	ret.AutoScaleMeta.decisions = ret.decisions
+	ret.AutoScaleMeta.isLeader = &ret.isLeader
+	if TenantCRDEnabled {
+		ret.DynamicCli = dynamic.NewForConfigOrDie(k8sConfig)
+	}
+	if !LeaderElectionEnabled {
+		ret.isLeader.Store(true)
+		ret.leaderIdentity = ret.identity
+		MetricOfIsLeader.Set(1)
+	}
 	ret.initK8sComponents()
 
 	ret.initRangeMetricsFromPromethues(HardCodeMaxScaleIntervalSecOfCfg)
 
 	go ret.collectMetricsFromPromethuesLoop()
-	go ret.manageAnalyzeTasks()
 	go ret.collectTaskCntMetricsFromPromethuesLoop()
 	go ret.scanPodsStatesLoop()
-	go ret.checkFixPoolReplicaLoop()
+	if LeaderElectionEnabled {
+		go ret.runLeaderElection()
+	} else {
+		ret.startLeaderLoops()
+	}
 
 	return ret
 }
 
 // checked
 // return is successful to handle
-func (c *ClusterManager) handleCloneSetApiError(err error, caller string) bool {
+func (c *ClusterManager) handleCloneSetApiError(podClass string, err error, caller string) bool {
 	errStr := err.Error()
-	Logger.Errorf("[error][%v]handleClonesetApiError, err: %+v", caller, errStr)
+	Logger.Errorf("[error][%v][%v]handleClonesetApiError, err: %+v", caller, podClass, errStr)
 	// if strings.Contains(errStr, "please apply your changes to the latest version") {
-	ret, err := c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Get(context.TODO(), c.CloneSetName, metav1.GetOptions{})
+	ret, err := c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Get(context.TODO(), GetCloneSetNameOfPodClass(podClass), metav1.GetOptions{})
 	if err != nil {
-		Logger.Errorf("[error][%v]handleClonesetApiError again, failed to get latest version of cloneset, err: %+v", caller, err.Error())
+		Logger.Errorf("[error][%v][%v]handleClonesetApiError again, failed to get latest version of cloneset, err: %+v", caller, podClass, err.Error())
 	} else {
-		c.CloneSet = ret
+		c.CloneSets[podClass] = ret
 		return true
 	}
 	// }
@@ -1030,8 +979,12 @@ func (c *ClusterManager) handleCloneSetApiError(err error, caller string) bool {
 }
 
 // checked
-func (c *ClusterManager) addNewPods(delta int32, retryCnt int) (*v1alpha1.CloneSet, error) {
+func (c *ClusterManager) addNewPods(podClass string, delta int32, retryCnt int) (*v1alpha1.CloneSet, error) {
 	c.muOfCloneSet.Lock()
+	if c.CloneSets[podClass] == nil {
+		c.muOfCloneSet.Unlock()
+		return nil, fmt.Errorf("cloneset of pod class %v is not initialized", podClass)
+	}
 	// defer c.muOfCloneSet.Unlock()
 	// if delta <= 0 {
 	// 	return cloneSet, fmt.Errorf("delta <= 0")
@@ -1039,27 +992,27 @@ func (c *ClusterManager) addNewPods(delta int32, retryCnt int) (*v1alpha1.CloneS
 	// if int32(from) != *cloneSet.Spec.Replicas {
 	// 	return cloneSet, fmt.Errorf("int32(from) != *cloneSet.Spec.Replicas")
 	// }
-	oldRelica := *c.CloneSet.Spec.Replicas
+	oldRelica := *c.CloneSets[podClass].Spec.Replicas
 	newReplicas := new(int32)
-	*newReplicas = int32(*c.CloneSet.Spec.Replicas + delta)
-	c.CloneSet.Spec.Replicas = newReplicas
-	ret, err := c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Update(context.TODO(), c.CloneSet, metav1.UpdateOptions{})
+	*newReplicas = int32(*c.CloneSets[podClass].Spec.Replicas + delta)
+	c.CloneSets[podClass].Spec.Replicas = newReplicas
+	ret, err := c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Update(context.TODO(), c.CloneSets[podClass], metav1.UpdateOptions{})
 	if err != nil {
-		*c.CloneSet.Spec.Replicas = oldRelica
-		Logger.Infof("[ClusterManager][addPods] failed, curReplica:%v newReplica:%v, error: %v", oldRelica, *newReplicas, err.Error())
-		if c.handleCloneSetApiError(err, "ClusterManager.addNewPods") {
+		*c.CloneSets[podClass].Spec.Replicas = oldRelica
+		Logger.Infof("[ClusterManager][addPods][%v] failed, curReplica:%v newReplica:%v, error: %v", podClass, oldRelica, *newReplicas, err.Error())
+		if c.handleCloneSetApiError(podClass, err, "ClusterManager.addNewPods") {
 			if retryCnt > 0 {
 				c.muOfCloneSet.Unlock()
-				return c.addNewPods(delta, retryCnt-1)
+				return c.addNewPods(podClass, delta, retryCnt-1)
 			}
 		}
 		c.muOfCloneSet.Unlock()
 		MetricOfClonesetReplicaAddFailedCnt.Add(float64(delta))
-		return c.CloneSet, fmt.Errorf(err.Error())
+		return c.CloneSets[podClass], fmt.Errorf(err.Error())
 	} else {
-		c.CloneSet = ret.DeepCopy()
+		c.CloneSets[podClass] = ret.DeepCopy()
 		c.muOfCloneSet.Unlock()
-		Logger.Infof("[ClusterManager][addPods] addNewPods, curReplica:%v newReplica:%v", oldRelica, *newReplicas)
+		Logger.Infof("[ClusterManager][addPods][%v] addNewPods, curReplica:%v newReplica:%v", podClass, oldRelica, *newReplicas)
 		MetricOfClonesetReplicaAddSuccessCnt.Add(float64(delta))
 		return ret, nil
 	}
@@ -1067,34 +1020,75 @@ func (c *ClusterManager) addNewPods(delta int32, retryCnt int) (*v1alpha1.CloneS
 }
 
 // checked
-func (c *ClusterManager) removePods(pods2del []string, retryCnt int) (*v1alpha1.CloneSet, error) {
+func (c *ClusterManager) removePods(podClass string, pods2del []string, retryCnt int) (*v1alpha1.CloneSet, error) {
+	return c.removePodsAndSetPartition(podClass, pods2del, nil, retryCnt)
+}
+
+// getPartitionAfterRemovingPods lowers partition of cloneset by pods of old revision in pods2del, nil if it's unchanged.
+// Otherwise OpenKruise recreates pods of old revision to keep partition, while coordinated upgrade is in progress.
+func (c *ClusterManager) getPartitionAfterRemovingPods(cloneSet *v1alpha1.CloneSet, pods2del []string) *int32 {
+	partition := getPartitionOfCloneSet(cloneSet)
+	if partition == 0 {
+		return nil
+	}
+	cntOfOldPods := 0
+	c.AutoScaleMeta.mu.Lock()
+	for _, name := range pods2del {
+		if pod, ok := c.AutoScaleMeta.PodDescMap[name]; ok {
+			if revision := pod.GetRevision(); revision != "" && !isPodOfRevision(revision, cloneSet.Status.UpdateRevision) {
+				cntOfOldPods++
+			}
+		}
+	}
+	c.AutoScaleMeta.mu.Unlock()
+	if cntOfOldPods == 0 {
+		return nil
+	}
+	return Int32Ptr(int32(MaxInt(partition-cntOfOldPods, 0)))
+}
+
+// removePodsAndSetPartition also sets partition of cloneset if it's not nil, which is the number of pods kept in old revision.
+// If it's nil, partition is lowered by pods of old revision which are removed.
+func (c *ClusterManager) removePodsAndSetPartition(podClass string, pods2del []string, partition *int32, retryCnt int) (*v1alpha1.CloneSet, error) {
 	c.muOfCloneSet.Lock()
+	if c.CloneSets[podClass] == nil {
+		c.muOfCloneSet.Unlock()
+		return nil, fmt.Errorf("cloneset of pod class %v is not initialized", podClass)
+	}
+	if partition == nil {
+		partition = c.getPartitionAfterRemovingPods(c.CloneSets[podClass], pods2del)
+	}
 	// defer c.muOfCloneSet.Unlock()
-	oldRelica := *c.CloneSet.Spec.Replicas
+	oldRelica := *c.CloneSets[podClass].Spec.Replicas
 	newReplicas := new(int32)
-	*newReplicas = int32(*c.CloneSet.Spec.Replicas - int32(len(pods2del)))
-	c.CloneSet.Spec.Replicas = newReplicas
-	c.CloneSet.Spec.ScaleStrategy.PodsToDelete = pods2del
-	ret, err := c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Update(context.TODO(), c.CloneSet, metav1.UpdateOptions{})
+	*newReplicas = int32(*c.CloneSets[podClass].Spec.Replicas - int32(len(pods2del)))
+	c.CloneSets[podClass].Spec.Replicas = newReplicas
+	c.CloneSets[podClass].Spec.ScaleStrategy.PodsToDelete = pods2del
+	oldPartition := c.CloneSets[podClass].Spec.UpdateStrategy.Partition
+	if partition != nil {
+		c.CloneSets[podClass].Spec.UpdateStrategy.Partition = &intstr.IntOrString{Type: intstr.Int, IntVal: *partition}
+	}
+	ret, err := c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Update(context.TODO(), c.CloneSets[podClass], metav1.UpdateOptions{})
 	if err != nil {
-		*c.CloneSet.Spec.Replicas = oldRelica
-		c.CloneSet.Spec.ScaleStrategy.PodsToDelete = make([]string, 0)
+		*c.CloneSets[podClass].Spec.Replicas = oldRelica
+		c.CloneSets[podClass].Spec.ScaleStrategy.PodsToDelete = make([]string, 0)
+		c.CloneSets[podClass].Spec.UpdateStrategy.Partition = oldPartition
 		// Logger.Errorf("[error][ClusterManager.addNewPods] error encountered! err:%v", err.Error())
-		Logger.Errorf("[ClusterManager][removePods] failed, curReplica:%v newReplica:%v, error: %v", oldRelica, *newReplicas, err.Error())
-		if c.handleCloneSetApiError(err, "ClusterManager.removePods") {
+		Logger.Errorf("[ClusterManager][removePods][%v] failed, curReplica:%v newReplica:%v, error: %v", podClass, oldRelica, *newReplicas, err.Error())
+		if c.handleCloneSetApiError(podClass, err, "ClusterManager.removePods") {
 			if retryCnt > 0 {
 				c.muOfCloneSet.Unlock()
-				return c.removePods(pods2del, retryCnt-1)
+				return c.removePodsAndSetPartition(podClass, pods2del, partition, retryCnt-1)
 			}
 		}
 		c.muOfCloneSet.Unlock()
 		MetricOfClonesetReplicaDelFailedCnt.Add(float64(len(pods2del)))
-		return c.CloneSet, fmt.Errorf(err.Error())
+		return c.CloneSets[podClass], fmt.Errorf(err.Error())
 	} else {
-		c.CloneSet = ret.DeepCopy()
+		c.CloneSets[podClass] = ret.DeepCopy()
 		ret.Spec.ScaleStrategy.PodsToDelete = nil // reset field Spec.ScaleStrategy.PodsToDelete
 		c.muOfCloneSet.Unlock()
-		Logger.Infof("[ClusterManager][removePods] removePods, curReplica:%v newReplica:%v, pods2del: %+v", oldRelica, *newReplicas, pods2del)
+		Logger.Infof("[ClusterManager][removePods][%v] removePods, curReplica:%v newReplica:%v, pods2del: %+v partition: %v", podClass, oldRelica, *newReplicas, pods2del, getPartitionOfCloneSet(ret))
 		MetricOfClonesetReplicaDelSuccessCnt.Add(float64(len(pods2del)))
 		return ret, nil
 	}
@@ -1107,7 +1101,11 @@ func (c *ClusterManager) podPrepareLoop() {
 		if atomic.LoadInt32(&c.shutdown) != 0 {
 			return
 		}
-		c.AutoScaleMeta.PrewarmPool.DoPodsWarm(c)
+		for _, pool := range c.AutoScaleMeta.GetPrewarmPools() {
+			pool.DoPodsWarm(c)
+		}
+		MetricOfTenantCntSnapshot.Set(float64(c.AutoScaleMeta.GetTenantCnt()))
+		MetricOfPodCntSnapshot.Set(float64(c.AutoScaleMeta.GetPodCnt()))
 	}
 }
 
@@ -2,14 +2,59 @@ package autoscale
 
 import (
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 )
 
+// ConfigManager keeps the latest config of each tenant, TenantDesc reloads from the same holder before each analyze round
 type ConfigManager struct {
-	configMap map[string]*ConfigOfComputeCluster
+	configMap map[string]*ConfigOfComputeClusterHolder
 	mu        sync.Mutex
 }
 
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{configMap: make(map[string]*ConfigOfComputeClusterHolder)}
+}
+
+func (c *ConfigManager) GetHolder(tenant string) *ConfigOfComputeClusterHolder {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.configMap[tenant]
+}
+
+// SetHolder registers holder of tenant, the old one is kept and returned if exists
+func (c *ConfigManager) SetHolder(tenant string, holder *ConfigOfComputeClusterHolder) *ConfigOfComputeClusterHolder {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.configMap[tenant]; ok {
+		return old
+	}
+	c.configMap[tenant] = holder
+	return holder
+}
+
+func (c *ConfigManager) RemoveHolder(tenant string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.configMap, tenant)
+}
+
+// GetConfigs returns a copy of configs of all tenants
+func (c *ConfigManager) GetConfigs() map[string]ConfigOfComputeCluster {
+	c.mu.Lock()
+	holders := make(map[string]*ConfigOfComputeClusterHolder, len(c.configMap))
+	for k, v := range c.configMap {
+		holders[k] = v
+	}
+	c.mu.Unlock()
+	ret := make(map[string]ConfigOfComputeCluster, len(holders))
+	for k, v := range holders {
+		ret[k] = v.DeepCopy()
+	}
+	return ret
+}
+
 // auto: on/off  resume: on/off
 /*  HOW TO Initialize state when new Tenant is setup
 // AutoPauseIntervalSeconds Disabled InitializedState TargetState      Action
@@ -19,32 +64,100 @@ type ConfigManager struct {
 //       non-0			       true   resumed	        paused         pause()
 */
 type ConfigOfComputeCluster struct {
-	Disabled                 bool                 // triger when modified: pause cluster
-	AutoPauseIntervalSeconds int                  // triger when modified: re-range timeseries of metric active_task。 zero means non-auto pause
-	MinCores                 int                  // triger when modified: reload config before next analyze loop
-	MaxCores                 int                  // triger when modified: reload config before next analyze loop
-	InitCores                int                  // triger when modified: reload config before next analyze loop
-	WindowSeconds            int                  // triger when modified: re-range timeseries of metric cpu/mem...
-	CpuScaleRules            *CustomScaleRule     // triger when modified: reload config before next analyze loop
-	ConfigOfTiDBCluster      *ConfigOfTiDBCluster // triger when modified: instantly reload compute pod's config  TODO handle version change case
-	LastModifiedTs           int64
+	Disabled                      bool                  // triger when modified: pause cluster
+	AutoPauseIntervalSeconds      int                   // triger when modified: re-range timeseries of metric active_task。 zero means non-auto pause
+	MinCores                      int                   // triger when modified: reload config before next analyze loop
+	MaxCores                      int                   // triger when modified: reload config before next analyze loop
+	InitCores                     int                   // triger when modified: reload config before next analyze loop
+	WindowSeconds                 int                   // triger when modified: re-range timeseries of metric cpu/mem...
+	CpuScaleRules                 *CustomScaleRule      // triger when modified: reload config before next analyze loop
+	MemScaleRules                 *CustomScaleRule      // triger when modified: reload config before next analyze loop. nil means memory is not considered
+	TaskCntScaleRules             *CustomScaleRule      // triger when modified: reload config before next analyze loop. nil means task count is not considered
+	ScalePolicyName               string                // triger when modified: reload config before next analyze loop. empty means DefaultScalePolicyName
+	ScaleUpCooldownSeconds        int                   // triger when modified: reload config before next analyze loop. zero means no cooldown after resize
+	ScaleDownStabilizationSeconds int                   // triger when modified: reload config before next analyze loop. zero means scale in immediately
+	MaxScaleUpStep                int                   // triger when modified: reload config before next analyze loop. max pods added in one resize, zero means unlimited
+	MaxScaleDownStep              int                   // triger when modified: reload config before next analyze loop. max pods removed in one resize, zero means unlimited
+	MaxScaleUpStepPercent         int                   // triger when modified: reload config before next analyze loop. max pods added in one resize, in percent of current pods, zero means unlimited
+	MaxScaleDownStepPercent       int                   // triger when modified: reload config before next analyze loop. max pods removed in one resize, in percent of current pods, zero means unlimited
+	DryRun                        bool                  // triger when modified: reload config before next analyze loop. decisions are recorded but no pods are moved
+	Schedules                     []*ScheduledScaleRule // triger when modified: reload config before next analyze loop. active schedules raise the floor/ceiling of cores
+	PredictiveScaleRules          *PredictiveScaleRule  // triger when modified: reload config before next forecast. nil means predictive scaling is off
+	ConfigOfTiDBCluster           *ConfigOfTiDBCluster  // triger when modified: instantly reload compute pod's config  TODO handle version change case
+	PodClass                      string                // triger when modified: only allowed when tenant has no pods. empty means DefaultPodClassName
+	DrainTimeoutSeconds           int                   // triger when modified: reload config before next analyze loop. max wait for running tasks before pod is unassigned, zero means DefaultDrainTimeoutSeconds, negative means no drain
+	PriorityClass                 string                // triger when modified: reload config before next analyze loop. share of warm pods when tenants compete, empty means PriorityClassNormal
+	ReservedWarmPods              int                   // triger when modified: reload config before next pool warming. warm pods kept for the tenant while it's paused, zero means no reservation
+	PreAssignReservedPods         bool                  // triger when modified: reload config before next pool warming. reserved pods are assigned to the tenant in advance
+	LastModifiedTs                int64
 }
 
 func (c *ConfigOfComputeCluster) Dump() string {
 	if c == nil {
 		return "nil"
 	}
-	return fmt.Sprintf("ConfigOfComputeCluster{Disabled:%v, AutoPauseIntervalSec:%v, MinCores:%v, MaxCores:%v, InitCores:%v, WindowSec:%v, CpuScaleRules:%v, TidbCluster:%v, LastModifiedTs:%v}",
-		c.Disabled, c.AutoPauseIntervalSeconds, c.MinCores, c.MaxCores, c.InitCores, c.WindowSeconds, c.CpuScaleRules.Dump(), c.ConfigOfTiDBCluster.Dump(), c.LastModifiedTs)
+	return fmt.Sprintf("ConfigOfComputeCluster{Disabled:%v, AutoPauseIntervalSec:%v, MinCores:%v, MaxCores:%v, InitCores:%v, WindowSec:%v, CpuScaleRules:%v, MemScaleRules:%v, TaskCntScaleRules:%v, ScalePolicy:%v, ScaleUpCooldownSeconds:%v, ScaleDownStabilizationSeconds:%v, MaxScaleUpStep:%v, MaxScaleDownStep:%v, MaxScaleUpStepPercent:%v, MaxScaleDownStepPercent:%v, DryRun:%v, Schedules:%v, PredictiveScaleRules:%v, TidbCluster:%v, PodClass:%v, DrainTimeoutSeconds:%v, PriorityClass:%v, ReservedWarmPods:%v, PreAssignReservedPods:%v, LastModifiedTs:%v}",
+		c.Disabled, c.AutoPauseIntervalSeconds, c.MinCores, c.MaxCores, c.InitCores, c.WindowSeconds, c.CpuScaleRules.Dump(), c.MemScaleRules.Dump(), c.TaskCntScaleRules.Dump(), c.ScalePolicyName, c.ScaleUpCooldownSeconds, c.ScaleDownStabilizationSeconds, c.MaxScaleUpStep, c.MaxScaleDownStep, c.MaxScaleUpStepPercent, c.MaxScaleDownStepPercent, c.DryRun, dumpScheduledScaleRules(c.Schedules), c.PredictiveScaleRules.Dump(), c.ConfigOfTiDBCluster.Dump(), NormalizePodClassName(c.PodClass), c.DrainTimeoutSeconds, NormalizePriorityClassName(c.PriorityClass), c.ReservedWarmPods, c.PreAssignReservedPods, c.LastModifiedTs)
+}
+
+func dumpScheduledScaleRules(rules []*ScheduledScaleRule) string {
+	dumps := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		dumps = append(dumps, rule.Dump())
+	}
+	return "[" + strings.Join(dumps, ", ") + "]"
+}
+
+// GetCoresOfPod returns cores of pod class of tenant, DefaultCoreOfPod if class is unknown
+func (c *ConfigOfComputeCluster) GetCoresOfPod() int {
+	podClass := GetPodClass(c.PodClass)
+	if podClass == nil {
+		Logger.Errorf("[error][ConfigOfComputeCluster] unknown pod class:%v, TiDBCluster: %v", c.PodClass, c.ConfigOfTiDBCluster.Name)
+		return DefaultCoreOfPod
+	}
+	return podClass.CoresOfPod
 }
 
 func (c *ConfigOfComputeCluster) GetInitCntOfPod() int {
-	if c.InitCores >= c.MinCores && c.InitCores <= c.MaxCores && c.InitCores%DefaultCoreOfPod == 0 {
-		return c.InitCores / DefaultCoreOfPod
+	coresOfPod := c.GetCoresOfPod()
+	if c.InitCores >= c.MinCores && c.InitCores <= c.MaxCores && c.InitCores%coresOfPod == 0 {
+		return c.InitCores / coresOfPod
 	} else {
 		Logger.Errorf("[error][ConfigOfComputeCluster] invalid initcores, TiDBCluster: %v ,CoreInfo min:%v max:%v init:%v ", c.ConfigOfTiDBCluster.Name, c.MinCores, c.MaxCores, c.InitCores)
-		return c.MinCores / DefaultCoreOfPod
+		return c.MinCores / coresOfPod
+	}
+}
+
+// compute the most restrictive one of absolute step and percent step, -1 means unlimited
+func computeMaxScaleStep(from int, step int, stepPercent int) int {
+	ret := -1
+	if step > 0 {
+		ret = step
+	}
+	if stepPercent > 0 {
+		// at least one pod per resize, otherwise a tenant with few pods could never be resized
+		stepOfPercent := MaxInt((from*stepPercent+99)/100, 1)
+		if ret == -1 || stepOfPercent < ret {
+			ret = stepOfPercent
+		}
+	}
+	return ret
+}
+
+// LimitScaleStep returns target clamped by MaxScaleUpStep/MaxScaleDownStep and their percent variants
+func (c *ConfigOfComputeCluster) LimitScaleStep(from int, target int) int {
+	if target > from {
+		maxStep := computeMaxScaleStep(from, c.MaxScaleUpStep, c.MaxScaleUpStepPercent)
+		if maxStep != -1 && target-from > maxStep {
+			return from + maxStep
+		}
+	} else if target < from {
+		maxStep := computeMaxScaleStep(from, c.MaxScaleDownStep, c.MaxScaleDownStepPercent)
+		if maxStep != -1 && from-target > maxStep {
+			return from - maxStep
+		}
 	}
+	return target
 }
 
 func (c *ConfigOfComputeCluster) GetLowerAndUpperCpuScaleThreshold() (float64, float64) {
@@ -74,10 +187,28 @@ func (c *ConfigOfComputeClusterHolder) DeepCopy() ConfigOfComputeCluster {
 	return ret
 }
 
+// Update replaces config and bumps LastModifiedTs, so that TenantDesc.TryToReloadConf picks it up
+func (c *ConfigOfComputeClusterHolder) Update(conf ConfigOfComputeCluster) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conf.LastModifiedTs = Max(time.Now().UnixNano(), c.Config.LastModifiedTs+1)
+	conf.parseSchedules()
+	c.Config = conf
+}
+
+// Replace sets config saved by another replica, LastModifiedTs of it is kept
+func (c *ConfigOfComputeClusterHolder) Replace(conf ConfigOfComputeCluster) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conf.parseSchedules()
+	c.Config = conf
+}
+
 type ConfigOfTiDBCluster struct {
-	Name    string // TiDBCluster 的全局唯一 ID
-	Version string
-	PD      *ConfigOfPD
+	Name           string // TiDBCluster 的全局唯一 ID
+	Version        string
+	PD             *ConfigOfPD
+	TidbStatusAddr string
 	// TiDB    []*ConfigOfTiDB
 
 	// all fields below may be useless
@@ -90,13 +221,24 @@ func (c *ConfigOfTiDBCluster) Dump() string {
 	if c == nil {
 		return "nil"
 	}
-	return fmt.Sprintf("ConfigOfTiDBCluster{Name:%v, Version:%v}", c.Name, c.Version)
+	pdAddr := ""
+	if c.PD != nil {
+		pdAddr = c.PD.Addr
+	}
+	return fmt.Sprintf("ConfigOfTiDBCluster{Name:%v, Version:%v, PD:%v, TidbStatusAddr:%v}", c.Name, c.Version, pdAddr, c.TidbStatusAddr)
 }
 
+const (
+	ScaleRuleNameCpu     = "cpu"
+	ScaleRuleNameMem     = "mem"
+	ScaleRuleNameTaskCnt = "taskcnt"
+)
+
 type CustomScaleRule struct {
-	// only cpu is supported now
+	// cpu, mem and taskcnt are supported
 	Name string // only for display
-	// min/max for scaling, unit: % for cpu metric
+	// min/max for scaling
+	// unit: % of pod's cores for cpu metric, % of pod's memory for mem metric, count of handling requests per pod for taskcnt metric
 	Threashold *Threashold
 	// window for metric samples
 	// 120s~600s (2m~10m)
@@ -104,19 +246,39 @@ type CustomScaleRule struct {
 }
 
 func NewCpuScaleRule(minPercent int, maxPerent int, title string) *CustomScaleRule {
-	if maxPerent <= minPercent {
-		Logger.Errorf("invalid params: maxPerent <= minPercent, title:%v minPercent:%v maxPercent:%v", title, minPercent, maxPerent)
-		return nil
+	return newCustomScaleRule(ScaleRuleNameCpu, minPercent, maxPerent, title)
+}
+
+func NewMemScaleRule(minPercent int, maxPerent int, title string) *CustomScaleRule {
+	return newCustomScaleRule(ScaleRuleNameMem, minPercent, maxPerent, title)
+}
+
+func NewTaskCntScaleRule(minCntPerPod int, maxCntPerPod int, title string) *CustomScaleRule {
+	return newCustomScaleRule(ScaleRuleNameTaskCnt, minCntPerPod, maxCntPerPod, title)
+}
+
+// newCustomScaleRule never returns nil, invalid thresholds are reported by ConfigOfComputeCluster.Validate
+func newCustomScaleRule(name string, min int, max int, title string) *CustomScaleRule {
+	if max <= min {
+		Logger.Warnf("[warn]invalid params: max <= min, rule:%v title:%v min:%v max:%v", name, title, min, max)
 	}
 	return &CustomScaleRule{
-		Name: "cpu",
+		Name: name,
 		Threashold: &Threashold{
-			Min: minPercent,
-			Max: maxPerent,
+			Min: min,
+			Max: max,
 		},
 	}
 }
 
+// return lower and upper limit of metric, in ratio of capacity of pod for cpu/mem, or in raw value for taskcnt
+func (c *CustomScaleRule) GetLowerAndUpperLimit() (float64, float64) {
+	if c.Name == ScaleRuleNameTaskCnt {
+		return float64(c.Threashold.Min), float64(c.Threashold.Max)
+	}
+	return float64(c.Threashold.Min) / 100.0, float64(c.Threashold.Max) / 100.0
+}
+
 func (c *CustomScaleRule) Dump() string {
 	if c == nil {
 		return "nil"
@@ -136,6 +298,31 @@ func (c *Threashold) Dump() string {
 	return fmt.Sprintf("Threashold{Min:%v, Max:%v}", c.Min, c.Max)
 }
 
+// GetAddrs returns tidb status addr and pd addr which are sent to supervisor on assignment
+func (c *ConfigOfTiDBCluster) GetAddrs() (string, string, error) {
+	if c == nil {
+		return "", "", fmt.Errorf("config of tidb cluster is missing")
+	}
+	if c.PD == nil || c.PD.Addr == "" {
+		return "", "", fmt.Errorf("pd addr of tidb cluster %v is missing", c.Name)
+	}
+	// tenants of serverless tier share one tidb cluster, whose status addr is optional
+	if c.TidbStatusAddr == "" && OptionRunMode == RunModeDedicated {
+		return "", "", fmt.Errorf("tidb status addr of tidb cluster %v is missing", c.Name)
+	}
+	return c.TidbStatusAddr, c.PD.Addr, nil
+}
+
+// FillDefaultAddrs fills unspecified addresses with HardCodeEnv ones, which are defaults of all tenants
+func (c *ConfigOfTiDBCluster) FillDefaultAddrs() {
+	if (c.PD == nil || c.PD.Addr == "") && HardCodeEnvPdAddr != "" {
+		c.PD = &ConfigOfPD{Addr: HardCodeEnvPdAddr}
+	}
+	if c.TidbStatusAddr == "" {
+		c.TidbStatusAddr = HardCodeEnvTidbStatusAddr
+	}
+}
+
 type ConfigOfPD struct {
 	Addr string
 }
@@ -0,0 +1,145 @@
+package autoscale
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	MinWindowSecondsOfCfg = 120
+	MaxWindowSecondsOfCfg = 600
+)
+
+// FieldError describes why one field of config is invalid
+type FieldError struct {
+	Field  string      `json:"field"`
+	Value  interface{} `json:"value"`
+	Reason string      `json:"reason"`
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%v: %v (value: %v)", e.Field, e.Reason, e.Value)
+}
+
+// FieldErrors is returned by Validate, it's never empty if not nil
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		msgs = append(msgs, fieldErr.Error())
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+func (e *FieldErrors) add(field string, value interface{}, reason string) {
+	*e = append(*e, &FieldError{Field: field, Value: value, Reason: reason})
+}
+
+func (e *FieldErrors) validateCores(field string, cores int, coresOfPod int) {
+	if cores <= 0 {
+		e.add(field, cores, "must be positive")
+	} else if cores%coresOfPod != 0 {
+		e.add(field, cores, fmt.Sprintf("must be a multiple of %v cores of pod", coresOfPod))
+	}
+}
+
+func (e *FieldErrors) validateNonNegative(field string, value int) {
+	if value < 0 {
+		e.add(field, value, "must not be negative")
+	}
+}
+
+// validate checks thresholds of rule, field is the name of rule in config
+func (c *CustomScaleRule) validate(field string, errs *FieldErrors) {
+	if c.Threashold == nil {
+		errs.add(field+".Threashold", nil, "is required")
+		return
+	}
+	errs.validateNonNegative(field+".Threashold.Min", c.Threashold.Min)
+	if c.Threashold.Min >= c.Threashold.Max {
+		errs.add(field+".Threashold", c.Threashold.Dump(), "min must be less than max")
+	}
+}
+
+// validate checks schedule, zero cores mean the floor or ceiling is not raised
+func (r *ScheduledScaleRule) validate(field string, coresOfPod int, errs *FieldErrors) {
+	if _, err := ParseCronSchedule(r.Cron); err != nil {
+		errs.add(field+".Cron", r.Cron, err.Error())
+	}
+	if r.MinCores != 0 {
+		errs.validateCores(field+".MinCores", r.MinCores, coresOfPod)
+	}
+	if r.MaxCores != 0 {
+		errs.validateCores(field+".MaxCores", r.MaxCores, coresOfPod)
+	}
+	if r.MinCores > 0 && r.MaxCores > 0 && r.MinCores > r.MaxCores {
+		errs.add(field+".MinCores", r.MinCores, fmt.Sprintf("must not be greater than MaxCores(%v)", r.MaxCores))
+	}
+	errs.validateNonNegative(field+".DurationSeconds", r.DurationSeconds)
+	errs.validateNonNegative(field+".PrewarmLeadSeconds", r.PrewarmLeadSeconds)
+}
+
+// logIfInvalid checks config loaded from tenant store, it's kept even if it's invalid since tenant may be running with it
+func logIfInvalid(tenant string, conf *ConfigOfComputeCluster) {
+	if err := conf.Validate(); err != nil {
+		Logger.Errorf("[error][TenantStore][%v]invalid config is loaded, fix it by tenant-config API, err:%v", tenant, err.Error())
+		MetricOfInvalidTenantConfigCnt.Inc()
+	}
+}
+
+// Validate returns FieldErrors if config is invalid, it's checked before config is accepted from HTTP, gRPC or TiFlashTenant
+func (c *ConfigOfComputeCluster) Validate() error {
+	var errs FieldErrors
+	errs.validateNonNegative("AutoPauseIntervalSeconds", c.AutoPauseIntervalSeconds)
+	coresOfPod := DefaultCoreOfPod
+	if podClass := GetPodClass(c.PodClass); podClass != nil {
+		coresOfPod = podClass.CoresOfPod
+	} else {
+		errs.add("PodClass", c.PodClass, "unknown pod class")
+	}
+	errs.validateCores("MinCores", c.MinCores, coresOfPod)
+	errs.validateCores("MaxCores", c.MaxCores, coresOfPod)
+	errs.validateCores("InitCores", c.InitCores, coresOfPod)
+	if c.MinCores > c.MaxCores {
+		errs.add("MinCores", c.MinCores, fmt.Sprintf("must not be greater than MaxCores(%v)", c.MaxCores))
+	} else if c.InitCores < c.MinCores || c.InitCores > c.MaxCores {
+		errs.add("InitCores", c.InitCores, fmt.Sprintf("must be within [MinCores(%v), MaxCores(%v)]", c.MinCores, c.MaxCores))
+	}
+	// default window of auto-registered tenants is set by operator, it's accepted as is
+	if c.WindowSeconds != DefaultScaleIntervalSeconds && (c.WindowSeconds < MinWindowSecondsOfCfg || c.WindowSeconds > MaxWindowSecondsOfCfg) {
+		errs.add("WindowSeconds", c.WindowSeconds, fmt.Sprintf("must be within [%v, %v] or be DefaultScaleIntervalSeconds(%v)", MinWindowSecondsOfCfg, MaxWindowSecondsOfCfg, DefaultScaleIntervalSeconds))
+	}
+	if c.CpuScaleRules != nil {
+		c.CpuScaleRules.validate("CpuScaleRules", &errs)
+	}
+	if c.MemScaleRules != nil {
+		c.MemScaleRules.validate("MemScaleRules", &errs)
+	}
+	if c.TaskCntScaleRules != nil {
+		c.TaskCntScaleRules.validate("TaskCntScaleRules", &errs)
+	}
+	errs.validateNonNegative("ScaleUpCooldownSeconds", c.ScaleUpCooldownSeconds)
+	errs.validateNonNegative("ScaleDownStabilizationSeconds", c.ScaleDownStabilizationSeconds)
+	errs.validateNonNegative("MaxScaleUpStep", c.MaxScaleUpStep)
+	errs.validateNonNegative("MaxScaleDownStep", c.MaxScaleDownStep)
+	errs.validateNonNegative("MaxScaleUpStepPercent", c.MaxScaleUpStepPercent)
+	errs.validateNonNegative("MaxScaleDownStepPercent", c.MaxScaleDownStepPercent)
+	if err := validatePriorityClass(c.PriorityClass); err != nil {
+		errs.add("PriorityClass", c.PriorityClass, err.Error())
+	}
+	errs.validateNonNegative("ReservedWarmPods", c.ReservedWarmPods)
+	if c.ReservedWarmPods > c.InitCores/coresOfPod {
+		errs.add("ReservedWarmPods", c.ReservedWarmPods, fmt.Sprintf("must not exceed pods of InitCores(%v)", c.InitCores/coresOfPod))
+	}
+	for i, rule := range c.Schedules {
+		if rule == nil {
+			continue
+		}
+		rule.validate(fmt.Sprintf("Schedules[%v]", i), coresOfPod, &errs)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
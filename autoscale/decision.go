@@ -0,0 +1,275 @@
+package autoscale
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	DryRunMode              = false // global dry-run, decisions are recorded but no pods are moved
+	DecisionRingCapOfTenant = 64    // recent decisions kept for each tenant
+	DecisionAuditLogPath    = ""    // decisions are also appended into this file in JSON lines if it's not empty
+)
+
+const (
+	DecisionActionResize = "resize"
+	DecisionActionPause  = "pause"
+	DecisionActionResume = "resume"
+
+	DecisionRuleApi               = "api"                // pause or resume requested by http or grpc api
+	DecisionRuleOutstandingDemand = "outstanding-demand" // new pod of pool is handed to tenant whose scale out was not met
+)
+
+// DecisionContext is why a decision is made
+type DecisionContext struct {
+	Rule       string             `json:"rule"`
+	Reason     string             `json:"reason"`
+	Metrics    map[string]float64 `json:"metrics,omitempty"`
+	Thresholds map[string]float64 `json:"thresholds,omitempty"`
+}
+
+// ScaleDecision is what analyzeTaskLoop or api decided to do with a tenant, and the outcome of it
+type ScaleDecision struct {
+	Ts     int64  `json:"ts"`
+	Tenant string `json:"tenant"`
+	Action string `json:"action"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+	DryRun bool   `json:"dryRun"`
+	DecisionContext
+	Success bool `json:"success"`
+	FailCnt int  `json:"failCnt"`
+	UndoCnt int  `json:"undoCnt"`
+}
+
+func (d *ScaleDecision) setOutcome(success bool, result PodsChangeResult) {
+	d.Success = success && result.FailCnt == 0
+	d.FailCnt = result.FailCnt
+	d.UndoCnt = result.UndoCnt
+}
+
+// NewDecisionContextOfSnapshot collects metric values and thresholds considered by ScalePolicy
+func NewDecisionContextOfSnapshot(snapshot *TenantScaleSnapshot, rule string, reason string) DecisionContext {
+	ret := DecisionContext{Rule: rule, Reason: reason, Metrics: make(map[string]float64), Thresholds: make(map[string]float64)}
+	stats := snapshot.Stats[MetricsTopicCpu]
+	addMetric := func(name string, idx int) {
+		if idx < len(stats) && stats[idx].Cnt() > 0 {
+			ret.Metrics[name] = stats[idx].Avg()
+		}
+	}
+	addMetric(ScaleRuleNameCpu, MetricsIdxOfCpu)
+	addMetric(ScaleRuleNameMem, MetricsIdxOfMem)
+	addMetric(ScaleRuleNameTaskCnt, MetricsIdxOfTaskCnt)
+
+	lower, upper := snapshot.Conf.GetLowerAndUpperCpuScaleThreshold()
+	ret.Thresholds[ScaleRuleNameCpu+"_lower"] = lower
+	ret.Thresholds[ScaleRuleNameCpu+"_upper"] = upper
+	if snapshot.Conf.MemScaleRules != nil {
+		lower, upper = snapshot.Conf.MemScaleRules.GetLowerAndUpperLimit()
+		ret.Thresholds[ScaleRuleNameMem+"_lower"] = lower
+		ret.Thresholds[ScaleRuleNameMem+"_upper"] = upper
+	}
+	if snapshot.Conf.TaskCntScaleRules != nil {
+		lower, upper = snapshot.Conf.TaskCntScaleRules.GetLowerAndUpperLimit()
+		ret.Thresholds[ScaleRuleNameTaskCnt+"_lower"] = lower
+		ret.Thresholds[ScaleRuleNameTaskCnt+"_upper"] = upper
+	}
+	return ret
+}
+
+// DecisionRecorder keeps recent decisions of each tenant in a bounded ring, and appends them into audit log file if configured
+type DecisionRecorder struct {
+	mu        sync.Mutex
+	capacity  int
+	decisions map[string][]ScaleDecision
+	logPath   string
+	logFile   *os.File
+}
+
+func NewDecisionRecorder(capacity int, logPath string) *DecisionRecorder {
+	return &DecisionRecorder{
+		capacity:  MaxInt(capacity, 1),
+		decisions: make(map[string][]ScaleDecision),
+		logPath:   logPath,
+	}
+}
+
+func (r *DecisionRecorder) Record(d ScaleDecision) {
+	MetricOfScaleDecisionCnt.WithLabelValues(d.Action, strconv.FormatBool(d.DryRun)).Inc()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := append(r.decisions[d.Tenant], d)
+	if len(list) > r.capacity {
+		list = list[len(list)-r.capacity:]
+	}
+	r.decisions[d.Tenant] = list
+	r.appendToLogFile(d)
+}
+
+// should be called under r.mu
+func (r *DecisionRecorder) appendToLogFile(d ScaleDecision) {
+	if r.logPath == "" {
+		return
+	}
+	if r.logFile == nil {
+		f, err := os.OpenFile(r.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			Logger.Errorf("[error][DecisionRecorder]open audit log fail, path:%v err:%v", r.logPath, err.Error())
+			return
+		}
+		r.logFile = f
+	}
+	line, err := json.Marshal(d)
+	if err != nil {
+		Logger.Errorf("[error][DecisionRecorder]marshal decision fail, err:%v", err.Error())
+		return
+	}
+	line = append(line, '\n')
+	if _, err = r.logFile.Write(line); err != nil {
+		Logger.Errorf("[error][DecisionRecorder]write audit log fail, path:%v err:%v", r.logPath, err.Error())
+		r.logFile.Close()
+		r.logFile = nil // reopen next time
+	}
+}
+
+// GetDecisions returns a copy of recent decisions of tenant, or of all tenants if tenant is empty, sorted by time
+func (r *DecisionRecorder) GetDecisions(tenant string) []ScaleDecision {
+	return r.QueryDecisions(tenant, "", 0)
+}
+
+// QueryDecisions is GetDecisions filtered by action if it's not empty, and limited to the latest limit records if limit > 0
+func (r *DecisionRecorder) QueryDecisions(tenant string, action string, limit int) []ScaleDecision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ret := make([]ScaleDecision, 0, r.capacity)
+	for name, list := range r.decisions {
+		if tenant != "" && name != tenant {
+			continue
+		}
+		for _, d := range list {
+			if action == "" || d.Action == action {
+				ret = append(ret, d)
+			}
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Ts < ret[j].Ts
+	})
+	if limit > 0 && len(ret) > limit {
+		ret = ret[len(ret)-limit:]
+	}
+	return ret
+}
+
+func (c *TenantDesc) IsDryRun() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return DryRunMode || c.conf.DryRun
+}
+
+// resizeTenant resizes pods of tenant, or only records the decision if tenant is in dry-run mode.
+// scale-out is async like resume, since it may wait for warm pods, the decision is recorded after pods are added.
+func (c *ClusterManager) resizeTenant(tenant *TenantDesc, from int, target int, ctx DecisionContext) {
+	decision := ScaleDecision{Ts: time.Now().Unix(), Tenant: tenant.Name, Action: DecisionActionResize, From: from, To: target, DecisionContext: ctx}
+	if tenant.IsDryRun() {
+		conf := tenant.GetConf()
+		decision.To = conf.LimitScaleStep(from, target)
+		decision.DryRun = true
+		decision.Success = true
+		c.decisions.Record(decision)
+		Logger.Infof("[DryRun][%v]resize pods from %v to %v, reason:%v", tenant.Name, from, decision.To, ctx.Reason)
+		return
+	}
+	if target <= from {
+		c.doResizeTenant(tenant, decision)
+		return
+	}
+	if !tenant.isScalingOut.CompareAndSwap(false, true) {
+		Logger.Infof("[analyzeTaskLoop][%v]skip resize from %v to %v, last scale-out is in progress", tenant.Name, from, target)
+		return
+	}
+	go func() {
+		defer tenant.isScalingOut.Store(false)
+		c.doResizeTenant(tenant, decision)
+	}()
+}
+
+func (c *ClusterManager) doResizeTenant(tenant *TenantDesc, decision ScaleDecision) {
+	target, result := c.AutoScaleMeta.ResizePodsOfTenant(decision.From, decision.To, tenant.Name, c.tsContainer)
+	if target != decision.To {
+		decision.Reason = fmt.Sprintf("%v; limited by step from %v", decision.Reason, decision.To)
+		decision.To = target
+	}
+	decision.setOutcome(true, result)
+	c.decisions.Record(decision)
+	if c.SnsManager != nil {
+		c.SnsManager.TryToPublishTopology(tenant.Name, time.Now().UnixNano(), tenant.GetPodNames()) // public latest topology into SNS
+	}
+}
+
+// pauseTenant pauses tenant, or only records the decision if tenant is in dry-run mode.
+// pause is async, the decision is recorded after all pods are removed.
+func (c *ClusterManager) pauseTenant(tenant *TenantDesc, ctx DecisionContext) bool {
+	decision := ScaleDecision{Ts: time.Now().Unix(), Tenant: tenant.Name, Action: DecisionActionPause, From: tenant.GetCntOfPods(), To: 0, DecisionContext: ctx}
+	if tenant.IsDryRun() {
+		decision.DryRun = true
+		decision.Success = true
+		c.decisions.Record(decision)
+		Logger.Infof("[DryRun][%v]pause, reason:%v", tenant.Name, ctx.Reason)
+		return true
+	}
+	ret := c.AutoScaleMeta.AsyncPauseWithCallback(tenant.Name, c.tsContainer, func(result PodsChangeResult) {
+		decision.setOutcome(true, result)
+		c.decisions.Record(decision)
+	})
+	if !ret {
+		decision.setOutcome(false, PodsChangeResult{FailCnt: -1})
+		c.decisions.Record(decision)
+	}
+	return ret
+}
+
+// resumeTenant resumes tenant, or only records the decision if tenant is in dry-run mode
+func (c *ClusterManager) resumeTenant(tenant *TenantDesc, ctx DecisionContext) bool {
+	ret, _ := c.resumeTenantWithResult(tenant, ctx)
+	return ret
+}
+
+func (c *ClusterManager) resumeTenantWithResult(tenant *TenantDesc, ctx DecisionContext) (bool, PodsChangeResult) {
+	decision := ScaleDecision{Ts: time.Now().Unix(), Tenant: tenant.Name, Action: DecisionActionResume, From: tenant.GetCntOfPods(), To: tenant.GetInitCntOfPod(), DecisionContext: ctx}
+	if tenant.IsDryRun() {
+		decision.DryRun = true
+		decision.Success = true
+		c.decisions.Record(decision)
+		Logger.Infof("[DryRun][%v]resume, reason:%v", tenant.Name, ctx.Reason)
+		return true, PodsChangeResult{Reason: "tenant is in dry-run mode, no pods are assigned"}
+	}
+	ret, result := c.ResumeWithResult(tenant.Name)
+	decision.setOutcome(ret, result)
+	c.decisions.Record(decision)
+	return ret, result
+}
+
+// ResumeTenantByApi resumes tenant for request of api, only resume of paused tenant is recorded as a decision,
+// since clients call it before each query even if tenant is resumed
+func (c *ClusterManager) ResumeTenantByApi(tenantName string, api string) (bool, PodsChangeResult) {
+	tenant := c.AutoScaleMeta.GetTenantDesc(tenantName)
+	if tenant == nil || tenant.GetState() != TenantStatePaused {
+		return c.ResumeWithResult(tenantName)
+	}
+	return c.resumeTenantWithResult(tenant, DecisionContext{Rule: DecisionRuleApi, Reason: "resume by " + api})
+}
+
+// PauseTenantByApi pauses tenant for request of api
+func (c *ClusterManager) PauseTenantByApi(tenantName string, api string) bool {
+	tenant := c.AutoScaleMeta.GetTenantDesc(tenantName)
+	if tenant == nil {
+		return false
+	}
+	return c.pauseTenant(tenant, DecisionContext{Rule: DecisionRuleApi, Reason: "pause by " + api})
+}
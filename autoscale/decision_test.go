@@ -0,0 +1,139 @@
+package autoscale
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDecisionRecorder(t *testing.T) {
+	InitTestEnv()
+	r := NewDecisionRecorder(2, "")
+	r.Record(ScaleDecision{Tenant: "t1", Action: DecisionActionResize, From: 1, To: 2})
+	r.Record(ScaleDecision{Tenant: "t1", Action: DecisionActionResize, From: 2, To: 3})
+	r.Record(ScaleDecision{Tenant: "t1", Action: DecisionActionPause, From: 3, To: 0})
+	r.Record(ScaleDecision{Tenant: "t2", Action: DecisionActionResume, From: 0, To: 1})
+
+	decisions := r.GetDecisions("t1")
+	assertEqual(t, len(decisions), 2)
+	assertEqual(t, decisions[0].To, 3)
+	assertEqual(t, decisions[1].Action, DecisionActionPause)
+	assertEqual(t, len(r.GetDecisions("t2")), 1)
+	assertEqual(t, len(r.GetDecisions("")), 3)
+	assertEqual(t, len(r.GetDecisions("no-such-tenant")), 0)
+	// /audit and /decisions are served by the same query
+	assertEqual(t, len(r.QueryDecisions("", DecisionActionResize, 0)), 1)
+	assertEqual(t, r.QueryDecisions("t1", "", 1)[0].Action, DecisionActionPause)
+}
+
+func TestDryRunDecisions(t *testing.T) {
+	InitTestEnv()
+	c := &ClusterManager{decisions: NewDecisionRecorder(8, "")}
+	tenant := NewAutoPauseTenantDescWithState("t1", 1, 4, TenantStateResumed)
+	tenant.conf.DryRun = true
+	tenant.conf.MaxScaleUpStep = 1
+
+	// no pods are moved, the step limit is still reflected in decision
+	c.resizeTenant(tenant, 1, 3, DecisionContext{Rule: "test"})
+	assertEqual(t, c.pauseTenant(tenant, DecisionContext{Rule: "test"}), true)
+	assertEqual(t, c.resumeTenant(tenant, DecisionContext{Rule: "test"}), true)
+	assertEqual(t, tenant.GetCntOfPods(), 0)
+
+	decisions := c.decisions.GetDecisions("t1")
+	assertEqual(t, len(decisions), 3)
+	assertEqual(t, decisions[0].DryRun, true)
+	assertEqual(t, decisions[0].To, 2)
+	assertEqual(t, decisions[1].Action, DecisionActionPause)
+	assertEqual(t, decisions[2].Action, DecisionActionResume)
+
+	tenant.conf.DryRun = false
+	assertEqual(t, tenant.IsDryRun(), false)
+	DryRunMode = true
+	assertEqual(t, tenant.IsDryRun(), true)
+	DryRunMode = false
+}
+
+func TestAsyncScaleOut(t *testing.T) {
+	InitTestEnv()
+	meta := newMeta4TenantStoreTest(nil)
+	meta.SetupAutoPauseTenantWithState("t1", 1, 4, TenantStateResumed)
+	tenant := meta.GetTenantDesc("t1")
+	c := &ClusterManager{AutoScaleMeta: meta, decisions: NewDecisionRecorder(8, "")}
+
+	// scale-out is skipped while the last one is in progress
+	tenant.isScalingOut.Store(true)
+	c.resizeTenant(tenant, 0, 2, DecisionContext{Rule: "test"})
+	assertEqual(t, len(c.decisions.GetDecisions("t1")), 0)
+	tenant.isScalingOut.Store(false)
+
+	// decision is recorded once scale-out is done in background
+	c.resizeTenant(tenant, 0, 2, DecisionContext{Rule: "test"})
+	for i := 0; i < 1000 && tenant.isScalingOut.Load(); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assertEqual(t, tenant.isScalingOut.Load(), false)
+	decisions := c.decisions.GetDecisions("t1")
+	assertEqual(t, len(decisions), 1)
+	assertEqual(t, decisions[0].To, 2)
+
+	// step limits apply to every resize, including floors of min pods and schedules
+	tenant.conf.MaxScaleUpStep = 1
+	target, _ := meta.ResizePodsOfTenant(0, 3, "t1", meta.tsContainer)
+	assertEqual(t, target, 1)
+}
+
+func TestApiDecisions(t *testing.T) {
+	InitTestEnv()
+	meta := newMeta4TenantStoreTest(nil)
+	meta.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	tenant := meta.GetTenantDesc("t1")
+	tenant.conf.DryRun = true
+	c := &ClusterManager{AutoScaleMeta: meta, decisions: NewDecisionRecorder(8, "")}
+
+	ok, result := c.ResumeTenantByApi("t1", "http")
+	assertEqual(t, ok, true)
+	assertEqual(t, result.Reason != "", true)
+	// resume of resumed tenant is not recorded
+	tenant.SetState(TenantStateResumed)
+	c.ResumeTenantByApi("t1", "grpc")
+	assertEqual(t, c.PauseTenantByApi("t1", "pause4test"), true)
+	assertEqual(t, c.PauseTenantByApi("no-such-tenant", "pause4test"), false)
+
+	decisions := c.decisions.GetDecisions("t1")
+	assertEqual(t, len(decisions), 2)
+	assertEqual(t, decisions[0].Action, DecisionActionResume)
+	assertEqual(t, decisions[0].Rule, DecisionRuleApi)
+	assertEqual(t, decisions[1].Action, DecisionActionPause)
+	assertEqual(t, decisions[1].Reason, "pause by pause4test")
+}
+
+func TestDecisionAuditLog(t *testing.T) {
+	InitTestEnv()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	r := NewDecisionRecorder(4, path)
+	snapshot := newMultiMetricSnapshot4Test(2, 0.9, 0.6, 5)
+	r.Record(ScaleDecision{Ts: 1, Tenant: "t1", Action: DecisionActionResize, From: 2, To: 3,
+		DecisionContext: NewDecisionContextOfSnapshot(snapshot, ScalePolicyNameMultiMetric, "test"), Success: true})
+	r.Record(ScaleDecision{Ts: 2, Tenant: "t1", Action: DecisionActionPause, From: 3, To: 0, FailCnt: 1, UndoCnt: 1})
+
+	f, err := os.Open(path)
+	assertEqual(t, err, nil)
+	defer f.Close()
+	records := make([]ScaleDecision, 0, 2)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var d ScaleDecision
+		assertEqual(t, json.Unmarshal(scanner.Bytes(), &d), nil)
+		records = append(records, d)
+	}
+	assertEqual(t, len(records), 2)
+	assertEqual(t, records[0].Rule, ScalePolicyNameMultiMetric)
+	assertEqual(t, records[0].Metrics[ScaleRuleNameCpu], ComputeCpuUsageCoresPerPod(0.9))
+	assertEqual(t, records[0].Thresholds[ScaleRuleNameCpu+"_upper"], 0.8)
+	assertEqual(t, records[0].Thresholds[ScaleRuleNameTaskCnt+"_upper"], 10.0)
+	assertEqual(t, records[1].UndoCnt, 1)
+	assertEqual(t, records[1].Success, false)
+}
@@ -0,0 +1,76 @@
+package autoscale
+
+import (
+	"time"
+)
+
+var (
+	DefaultDrainTimeoutSeconds = 60
+	drainCheckInterval         = 2 * time.Second
+)
+
+// PodDrainer is implemented by ClusterManager, pods are unassigned without drain if AutoScaleMeta has no drainer
+type PodDrainer interface {
+	PublishTopology(tenant *TenantDesc)
+	QueryHandlingRequestCnt() (map[string]*TimeValPair, error)
+}
+
+// PublishTopology publishes current pods of tenant into SNS
+func (c *ClusterManager) PublishTopology(tenant *TenantDesc) {
+	if c.SnsManager != nil {
+		c.SnsManager.TryToPublishTopology(tenant.Name, time.Now().UnixNano(), tenant.GetPodNames())
+	}
+}
+
+func (c *ClusterManager) QueryHandlingRequestCnt() (map[string]*TimeValPair, error) {
+	return c.PromClient.QueryHandlingRequestCnt()
+}
+
+// GetDrainTimeoutSec applies DefaultDrainTimeoutSeconds to tenants of all sources if it's not set
+func (c *TenantDesc) GetDrainTimeoutSec() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.conf.DrainTimeoutSeconds == 0 {
+		return DefaultDrainTimeoutSeconds
+	}
+	return c.conf.DrainTimeoutSeconds
+}
+
+// drainPods is called after pods are removed from tenant. It publishes the reduced topology first,
+// then waits until no request is handled by the pods or drain timeout of tenant expires. Pods without metrics are regarded as drained.
+func (c *AutoScaleMeta) drainPods(tenantDesc *TenantDesc, pods []*PodDesc) {
+	if c.drainer == nil || len(pods) == 0 {
+		return
+	}
+	c.drainer.PublishTopology(tenantDesc)
+	timeoutSec := tenantDesc.GetDrainTimeoutSec()
+	if timeoutSec <= 0 {
+		return
+	}
+	start := time.Now()
+	deadline := start.Add(time.Duration(timeoutSec) * time.Second)
+	for {
+		cnts, err := c.drainer.QueryHandlingRequestCnt()
+		busyPods := make([]string, 0, len(pods))
+		if err != nil {
+			Logger.Warnf("[AutoScaleMeta][drain][%v]failed to query handling requests, err: %v", tenantDesc.Name, err.Error())
+		} else {
+			for _, pod := range pods {
+				if cnt, ok := cnts[pod.Name]; ok && cnt.value > 0 {
+					busyPods = append(busyPods, pod.Name)
+				}
+			}
+			if len(busyPods) == 0 {
+				Logger.Infof("[AutoScaleMeta][drain][%v]pods are drained in %v", tenantDesc.Name, time.Since(start))
+				MetricOfDrainSeconds.WithLabelValues("drained").Observe(time.Since(start).Seconds())
+				return
+			}
+		}
+		if !time.Now().Before(deadline) {
+			Logger.Warnf("[AutoScaleMeta][drain][%v]drain timeout after %vs, busy pods: %v", tenantDesc.Name, timeoutSec, busyPods)
+			MetricOfDrainSeconds.WithLabelValues("timeout").Observe(time.Since(start).Seconds())
+			return
+		}
+		time.Sleep(drainCheckInterval)
+	}
+}
@@ -0,0 +1,83 @@
+package autoscale
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type mockPodDrainer struct {
+	publishedPods []string
+	queryCnt      int
+	busyQueries   int // pods are busy in first busyQueries queries
+	err           error
+}
+
+func (d *mockPodDrainer) PublishTopology(tenant *TenantDesc) {
+	d.publishedPods = tenant.GetPodNames()
+}
+
+func (d *mockPodDrainer) QueryHandlingRequestCnt() (map[string]*TimeValPair, error) {
+	d.queryCnt++
+	if d.err != nil {
+		return nil, d.err
+	}
+	value := float64(0)
+	if d.queryCnt <= d.busyQueries {
+		value = 3
+	}
+	return map[string]*TimeValPair{
+		"p0":    {value: value},
+		"p1":    {value: value},
+		"other": {value: 5},
+	}, nil
+}
+
+func TestDrainPods(t *testing.T) {
+	InitTestEnv()
+	oldInterval := drainCheckInterval
+	defer func() {
+		drainCheckInterval = oldInterval
+	}()
+	drainCheckInterval = time.Millisecond
+
+	meta := newMeta4TenantStoreTest(nil)
+	meta.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	tenant := meta.GetTenantDesc("t1")
+	remainPod := &PodDesc{Name: "p2", IP: "10.0.0.3"}
+	tenant.SetPodWithTenantInfo(remainPod.Name, remainPod, 1)
+	pods := []*PodDesc{{Name: "p0"}, {Name: "p1"}, {Name: "p3"}}            // p3 has no metrics
+	assertEqual(t, tenant.GetDrainTimeoutSec(), DefaultDrainTimeoutSeconds) // it's not set
+
+	// no drainer
+	meta.drainPods(tenant, pods)
+
+	drainer := &mockPodDrainer{busyQueries: 3}
+	meta.drainer = drainer
+	meta.drainPods(tenant, pods)
+	assertEqual(t, len(drainer.publishedPods), 1)
+	assertEqual(t, drainer.publishedPods[0], "p2")
+	assertEqual(t, drainer.queryCnt, 4)
+
+	// drain timeout of tenant expires
+	tenant.conf.DrainTimeoutSeconds = 1
+	drainer = &mockPodDrainer{busyQueries: 1 << 30}
+	meta.drainer = drainer
+	start := time.Now()
+	meta.drainPods(tenant, pods)
+	assertEqual(t, time.Since(start) >= time.Second, true)
+	assertEqual(t, drainer.queryCnt > 1, true)
+
+	drainer = &mockPodDrainer{err: fmt.Errorf("prometheus is down")}
+	meta.drainer = drainer
+	meta.drainPods(tenant, pods)
+	assertEqual(t, drainer.queryCnt > 1, true)
+
+	// negative means no drain, but topology is still published
+	tenant.conf.DrainTimeoutSeconds = -1
+	drainer = &mockPodDrainer{}
+	meta.drainer = drainer
+	meta.drainPods(tenant, pods)
+	assertEqual(t, drainer.queryCnt, 0)
+	assertEqual(t, len(drainer.publishedPods), 1)
+}
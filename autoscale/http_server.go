@@ -141,8 +141,12 @@ func ResumeAndGetTopology(w http.ResponseWriter, tenantName string) {
 	// state := req.FormValue("state")
 
 	// if currentState == TenantStatePaused {
-	flag = Cm4Http.Resume(tenantName)
+	flag, result := Cm4Http.ResumeTenantByApi(tenantName, "http")
 	_, currentState, _ = Cm4Http.AutoScaleMeta.GetTenantState(tenantName)
+	if result.Reason != "" { // rejected, waiting doesn't help
+		io.WriteString(w, string(ret.WriteResp(1, TenantState2String(currentState), "resume failed: "+result.Reason, nil)))
+		return
+	}
 
 	// wait util topology is not empty or timeout
 	if len(Cm4Http.AutoScaleMeta.GetTopology(tenantName)) <= 0 {
@@ -151,7 +155,7 @@ func ResumeAndGetTopology(w http.ResponseWriter, tenantName string) {
 		for len(Cm4Http.AutoScaleMeta.GetTopology(tenantName)) <= 0 && time.Since(waitSt).Seconds() < float64(HttpResumeWaitTimoueSec) {
 			// for time.Now().UnixMilli()-waitSt.UnixMilli() < 15*1000 {
 			time.Sleep(time.Duration(HttpResumeCheckIntervalMs) * time.Millisecond)
-			flag = Cm4Http.Resume(tenantName)
+			flag, _ = Cm4Http.ResumeTenantByApi(tenantName, "http")
 		}
 		Logger.Warnf("[HTTP]ResumeAndGetTopology, resumed and topology is ready, wait cost %vms", time.Since(waitSt).Milliseconds())
 	}
@@ -178,6 +182,9 @@ func HttpHandleResumeAndGetTopology(w http.ResponseWriter, req *http.Request) {
 		MetricOfHttpRequestHttpHandleResumeAndGetTopologyMetricSeconds.Observe(time.Since(start).Seconds())
 	}()
 	MetricOfHttpRequestHttpHandleResumeAndGetTopologyCnt.Inc()
+	if forwardToLeader(w, req, "resume-and-get-topology") {
+		return
+	}
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	ip, _ := getIP(req)
 	tenantName := req.FormValue("tidbclusterid")
@@ -191,6 +198,9 @@ func HttpHandlePauseForTest(w http.ResponseWriter, req *http.Request) {
 		MetricOfHttpRequestHttpHandlePauseForTestMetricSeconds.Observe(time.Since(start).Seconds())
 	}()
 	MetricOfHttpRequestHttpHandlePauseForTestCnt.Inc()
+	if forwardToLeader(w, req, "pause4test") {
+		return
+	}
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	tenantName := req.FormValue("tidbclusterid")
 
@@ -204,7 +214,7 @@ func HttpHandlePauseForTest(w http.ResponseWriter, req *http.Request) {
 	ip, _ := getIP(req)
 	Logger.Infof("[HTTP]ResumeAndGetTopology, tenantName: %v, client: %v", tenantName, ip)
 	// if currentState == TenantStatePaused {
-	flag := Cm4Http.AsyncPause(tenantName)
+	flag := Cm4Http.PauseTenantByApi(tenantName, "pause4test")
 	_, currentState, _ := Cm4Http.AutoScaleMeta.GetTenantState(tenantName)
 	if !flag {
 		io.WriteString(w, string(ret.WriteResp(1, TenantState2String(currentState), "pause failed", nil)))
@@ -226,6 +236,169 @@ func DumpMeta(w http.ResponseWriter, req *http.Request) {
 	io.WriteString(w, Cm4Http.AutoScaleMeta.Dump())
 }
 
+// GetDecisions lists recent scale decisions, it's the same as /audit and kept for compatibility
+func GetDecisions(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	defer func() {
+		MetricOfHttpRequestGetDecisionsSeconds.Observe(time.Since(start).Seconds())
+	}()
+	MetricOfHttpRequestGetDecisionsCnt.Inc()
+	if forwardToLeader(w, req, "decisions") {
+		return
+	}
+	writeDecisions(w, req)
+}
+
+// GetAudit queries decision records, params: tenant(optional), action(optional), limit(optional, latest N records)
+func GetAudit(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	defer func() {
+		MetricOfHttpRequestGetAuditSeconds.Observe(time.Since(start).Seconds())
+	}()
+	MetricOfHttpRequestGetAuditCnt.Inc()
+	if forwardToLeader(w, req, "audit") {
+		return
+	}
+	writeDecisions(w, req)
+}
+
+// writeDecisions serves both /decisions and /audit from recorder of decisions
+func writeDecisions(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	limit := 0
+	if limitStr := req.FormValue("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			http.Error(w, "invalid limit: "+limitStr, http.StatusBadRequest)
+			return
+		}
+	}
+	retJson, err := json.Marshal(Cm4Http.decisions.QueryDecisions(req.FormValue("tenant"), req.FormValue("action"), limit))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(retJson)
+}
+
+// GetUpgradeStatus shows progress of pod upgrade of each pod class
+func GetUpgradeStatus(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	defer func() {
+		MetricOfHttpRequestGetUpgradeStatusSeconds.Observe(time.Since(start).Seconds())
+	}()
+	MetricOfHttpRequestGetUpgradeStatusCnt.Inc()
+	if forwardToLeader(w, req, "upgrade-status") {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	retJson, err := json.Marshal(Cm4Http.upgrades.GetStatuses())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(retJson)
+}
+
+// GetAnomalousPods shows pods quarantined in prewarm pools
+func GetAnomalousPods(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	defer func() {
+		MetricOfHttpRequestGetAnomalousPodsSeconds.Observe(time.Since(start).Seconds())
+	}()
+	MetricOfHttpRequestGetAnomalousPodsCnt.Inc()
+	if forwardToLeader(w, req, "anomalous-pods") {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	retJson, err := json.Marshal(Cm4Http.AutoScaleMeta.GetAnomalousPods())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(retJson)
+}
+
+// TenantConfig is CRUD of tenant's config, param: tenant.
+// GET returns config of tenant, or of all tenants if tenant is not specified.
+// POST creates tenant with config in body, unspecified fields are defaults of auto-registered tenant.
+// PUT updates fields specified in body, DELETE removes paused tenant.
+// Invalid config is rejected with 422 and field errors in body.
+func TenantConfig(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	defer func() {
+		MetricOfHttpRequestTenantConfigSeconds.Observe(time.Since(start).Seconds())
+	}()
+	MetricOfHttpRequestTenantConfigCnt.Inc()
+	if forwardToLeader(w, req, "tenant-config") {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	tenantName := req.FormValue("tenant")
+	ip, _ := getIP(req)
+	Logger.Infof("[HTTP]TenantConfig, method: %v tenantName: %v, client: %v", req.Method, tenantName, ip)
+	meta := Cm4Http.AutoScaleMeta
+	var err error
+	switch req.Method {
+	case http.MethodGet:
+		var ret interface{}
+		if tenantName == "" {
+			ret = meta.GetTenantConfigs()
+		} else {
+			conf, ok := meta.GetTenantConfig(tenantName)
+			if !ok {
+				http.Error(w, "no such tenant: "+tenantName, http.StatusNotFound)
+				return
+			}
+			ret = conf
+		}
+		retJson, err := json.Marshal(ret)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(retJson)
+		return
+	case http.MethodPost, http.MethodPut:
+		var body []byte
+		if body, err = io.ReadAll(req.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Method == http.MethodPost {
+			err = meta.CreateTenantConfigFromJson(tenantName, body)
+		} else if _, ok := meta.GetTenantConfig(tenantName); !ok {
+			http.Error(w, "no such tenant: "+tenantName, http.StatusNotFound)
+			return
+		} else {
+			err = meta.PatchTenantConfig(tenantName, body)
+		}
+	case http.MethodDelete:
+		err = meta.DeleteTenantConfig(tenantName)
+	default:
+		http.Error(w, "unsupported method: "+req.Method, http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		Logger.Errorf("[error][HTTP]TenantConfig fail, method: %v tenantName: %v, err: %v", req.Method, tenantName, err.Error())
+		if fieldErrs, ok := err.(FieldErrors); ok {
+			retJson, _ := json.Marshal(map[string]interface{}{"errors": fieldErrs})
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write(retJson)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	io.WriteString(w, "ok")
+}
+
 func GetStateServer(w http.ResponseWriter, req *http.Request) {
 	start := time.Now()
 	defer func() {
@@ -350,9 +523,14 @@ func RunAutoscaleHttpServer() {
 	http.HandleFunc("/pause4test", HttpHandlePauseForTest)
 	http.HandleFunc("/sharedfixedpool", SharedFixedPool)
 	http.HandleFunc("/dumpmeta", DumpMeta)
-
-	Logger.Infof("[HTTP]ListenAndServe 8081")
-	err := http.ListenAndServe(":8081", nil)
+	http.HandleFunc("/decisions", GetDecisions)
+	http.HandleFunc("/audit", GetAudit)
+	http.HandleFunc("/tenant-config", TenantConfig)
+	http.HandleFunc("/upgrade-status", GetUpgradeStatus)
+	http.HandleFunc("/anomalous-pods", GetAnomalousPods)
+
+	Logger.Infof("[HTTP]ListenAndServe %v", HttpServerPort)
+	err := http.ListenAndServe(":"+HttpServerPort, nil)
 	if err != nil {
 		log.Fatal("ListenAndServe: ", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/openkruise/kruise-api/apps/v1alpha1"
 	kruiseclientset "github.com/openkruise/kruise-api/client/clientset/versioned"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -31,6 +32,11 @@ func CloneSetPatchImage(cli *kruiseclientset.Clientset, ns string, clonesetName
 	return err
 }
 
+// CloneSetMergePatch applies json merge patch, which is also supported by custom resources unlike strategic merge patch
+func CloneSetMergePatch(cli *kruiseclientset.Clientset, ns string, clonesetName string, patch []byte) (*v1alpha1.CloneSet, error) {
+	return cli.AppsV1alpha1().CloneSets(ns).Patch(context.TODO(), clonesetName, types.MergePatchType, patch, metav1.PatchOptions{})
+}
+
 func GetReplicaOfStatefulSet(k8sCli *kubernetes.Clientset, ns string, name string) (int, int, error) {
 	ret, err := k8sCli.AppsV1().StatefulSets(ns).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
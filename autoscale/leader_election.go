@@ -0,0 +1,213 @@
+package autoscale
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	pb "github.com/tikv/pd/auto_scale_proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+var (
+	LeaderElectionEnabled          = false // if disabled, this replica is always the leader
+	LeaderElectionLeaseName        = "tiflash-autoscale-leader"
+	LeaderElectionLeaseDurationSec = 15
+	LeaderElectionRenewDeadlineSec = 10
+	LeaderElectionRetryPeriodSec   = 2
+	FollowerSyncIntervalSec        = 10 // how often followers rescan pods to refresh their read-only view
+)
+
+const (
+	EnvKeyOfPodName = "MY_POD_NAME"
+	EnvKeyOfPodIP   = "MY_POD_IP"
+
+	HttpServerPort = "8081"
+	GrpcServerPort = "8091"
+
+	// set on requests forwarded by followers, the receiver always handles them locally to avoid forwarding loops
+	HttpHeaderOfForwardedBy   = "X-Autoscale-Forwarded-By"
+	GrpcMetadataOfForwardedBy = "x-autoscale-forwarded-by"
+)
+
+// NewLeaderIdentity builds identity of replica as "podName_podIP", so that followers can reach leader by its identity
+func NewLeaderIdentity(podName string, podIP string) string {
+	return podName + "_" + podIP
+}
+
+// GetIPOfLeaderIdentity returns ip part of identity, empty if identity is not built by NewLeaderIdentity
+func GetIPOfLeaderIdentity(identity string) string {
+	idx := strings.LastIndex(identity, "_")
+	if idx < 0 {
+		return ""
+	}
+	ip := identity[idx+1:]
+	if net.ParseIP(ip) == nil {
+		return ""
+	}
+	return ip
+}
+
+func getIdentityOfSelf() string {
+	podName := os.Getenv(EnvKeyOfPodName)
+	if podName == "" {
+		podName, _ = os.Hostname()
+	}
+	return NewLeaderIdentity(podName, os.Getenv(EnvKeyOfPodIP))
+}
+
+func (c *ClusterManager) IsLeader() bool {
+	return c.isLeader.Load()
+}
+
+func (c *ClusterManager) setLeaderIdentity(identity string) {
+	c.muOfLeader.Lock()
+	defer c.muOfLeader.Unlock()
+	c.leaderIdentity = identity
+}
+
+func (c *ClusterManager) GetLeaderIdentity() string {
+	c.muOfLeader.Lock()
+	defer c.muOfLeader.Unlock()
+	return c.leaderIdentity
+}
+
+// runLeaderElection blocks until leadership is lost, the process exits then since leader loops can't be stopped safely
+func (c *ClusterManager) runLeaderElection() {
+	Logger.Infof("[LeaderElection]begin, identity:%v lease:%v/%v", c.identity, c.Namespace, LeaderElectionLeaseName)
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      LeaderElectionLeaseName,
+			Namespace: c.Namespace,
+		},
+		Client: c.K8sCli.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: c.identity,
+		},
+	}
+	leaderelection.RunOrDie(context.TODO(), leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: time.Duration(LeaderElectionLeaseDurationSec) * time.Second,
+		RenewDeadline: time.Duration(LeaderElectionRenewDeadlineSec) * time.Second,
+		RetryPeriod:   time.Duration(LeaderElectionRetryPeriodSec) * time.Second,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				c.takeLeadership()
+			},
+			OnStoppedLeading: func() {
+				if !c.IsLeader() {
+					return
+				}
+				Logger.Errorf("[error][LeaderElection]leadership is lost, exit to restart as follower, identity:%v", c.identity)
+				Logger.Sync()
+				os.Exit(1)
+			},
+			OnNewLeader: func(identity string) {
+				Logger.Infof("[LeaderElection]new leader:%v self:%v", identity, c.identity)
+				c.setLeaderIdentity(identity)
+			},
+		},
+	})
+}
+
+// takeLeadership runs the leader-only parts of startup, pods in the view built as follower may be stale, so rescan them
+func (c *ClusterManager) takeLeadership() {
+	Logger.Infof("[LeaderElection]became leader, identity:%v", c.identity)
+	c.isLeader.Store(true)
+	MetricOfIsLeader.Set(1)
+	c.setLeaderIdentity(c.identity)
+	c.initCloneSet()
+	// tenants are loaded at startup and refreshed from store while following, they are not reloaded here
+	c.AutoScaleMeta.ScanStateOfPods(true)
+	c.AutoScaleMeta.correctStatesOfTenants()
+	c.AutoScaleMeta.resetPodDemandHistories(time.Now())
+	c.startLeaderLoops()
+}
+
+// startLeaderLoops starts the loops which change pods, cloneset or tenant store
+func (c *ClusterManager) startLeaderLoops() {
+	// pod prepare & GC
+	c.wg.Add(1)
+	go c.podPrepareLoop()
+
+	go c.manageAnalyzeTasks()
+	go c.checkFixPoolReplicaLoop()
+	go c.predictiveScaleLoop()
+	go c.flushTenantStoreLoop()
+	go c.upgradeLoop()
+	go c.quarantineProbeLoop()
+	if TenantCRDEnabled {
+		go c.tenantCRDLoop()
+	}
+}
+
+// checked
+// syncViewOfFollower refreshes assignment of all pods from supervisors, since tenants are resized or paused by leader.
+// The scan is read-only for follower, it doesn't register tenants or change their states.
+func (c *ClusterManager) syncViewOfFollower() {
+	c.AutoScaleMeta.refreshTenantsFromStore()
+	c.AutoScaleMeta.ScanStateOfPods(true)
+}
+
+// forwardToLeader proxies req to leader if this replica is a follower, returns true if req is handled by it
+func forwardToLeader(w http.ResponseWriter, req *http.Request, api string) bool {
+	if Cm4Http.IsLeader() || req.Header.Get(HttpHeaderOfForwardedBy) != "" {
+		return false
+	}
+	leaderIdentity := Cm4Http.GetLeaderIdentity()
+	leaderIP := GetIPOfLeaderIdentity(leaderIdentity)
+	if leaderIP == "" {
+		Logger.Errorf("[error][HTTP]leader is unknown, api:%v leader:%v", api, leaderIdentity)
+		http.Error(w, "leader is unknown", http.StatusServiceUnavailable)
+		return true
+	}
+	MetricOfForwardToLeaderCnt.WithLabelValues(api).Inc()
+	Logger.Infof("[HTTP]forward to leader, api:%v leader:%v", api, leaderIdentity)
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: net.JoinHostPort(leaderIP, HttpServerPort)})
+	req.Header.Set(HttpHeaderOfForwardedBy, Cm4Http.identity)
+	proxy.ServeHTTP(w, req)
+	return true
+}
+
+func isForwardedGrpcCall(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	return ok && len(md.Get(GrpcMetadataOfForwardedBy)) > 0
+}
+
+// dialLeader connects to leader for grpc call api from follower, conn is nil if it fails and errInfo tells why.
+// outCtx marks the call as forwarded, so that it's not forwarded again.
+func dialLeader(ctx context.Context, api string) (conn *grpc.ClientConn, outCtx context.Context, errInfo string) {
+	leaderIdentity := Cm4Http.GetLeaderIdentity()
+	leaderIP := GetIPOfLeaderIdentity(leaderIdentity)
+	if leaderIP == "" {
+		Logger.Errorf("[error][grpc]leader is unknown, api:%v leader:%v", api, leaderIdentity)
+		return nil, nil, "leader is unknown"
+	}
+	MetricOfForwardToLeaderCnt.WithLabelValues(api).Inc()
+	dialCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(dialCtx, net.JoinHostPort(leaderIP, GrpcServerPort), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		Logger.Errorf("[error][grpc]dial leader failed, api:%v leader:%v err:%v", api, leaderIdentity, err.Error())
+		return nil, nil, "forward to leader failed"
+	}
+	return conn, metadata.AppendToOutgoingContext(ctx, GrpcMetadataOfForwardedBy, Cm4Http.identity), ""
+}
+
+func forwardResumeAndGetTopologyToLeader(ctx context.Context, req *pb.ResumeAndGetTopologyRequest) (*pb.ResumeAndGetTopologyResponse, error) {
+	conn, outCtx, errInfo := dialLeader(ctx, "ResumeAndGetTopology")
+	if conn == nil {
+		return &pb.ResumeAndGetTopologyResponse{HasErr: true, ErrInfo: errInfo}, nil
+	}
+	defer conn.Close()
+	return pb.NewAutoScaleClient(conn).ResumeAndGetTopology(outCtx, req)
+}
@@ -0,0 +1,41 @@
+package autoscale
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLeaderIdentity(t *testing.T) {
+	identity := NewLeaderIdentity("autoscale-0", "10.0.1.2")
+	assertEqual(t, identity, "autoscale-0_10.0.1.2")
+	assertEqual(t, GetIPOfLeaderIdentity(identity), "10.0.1.2")
+	assertEqual(t, GetIPOfLeaderIdentity("my_autoscale_0_10.0.1.3"), "10.0.1.3")
+	assertEqual(t, GetIPOfLeaderIdentity("autoscale-0_"), "")
+	assertEqual(t, GetIPOfLeaderIdentity("autoscale-0"), "")
+	assertEqual(t, GetIPOfLeaderIdentity(""), "")
+}
+
+func TestForwardToLeader(t *testing.T) {
+	InitTestEnv()
+	oldCm := Cm4Http
+	defer func() { Cm4Http = oldCm }()
+	Cm4Http = &ClusterManager{identity: NewLeaderIdentity("autoscale-1", "10.0.1.3")}
+
+	// follower without known leader
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/pause4test?tidbclusterid=t1", nil)
+	assertEqual(t, forwardToLeader(w, req, "pause4test"), true)
+	assertEqual(t, w.Code, http.StatusServiceUnavailable)
+
+	// request forwarded by another follower is handled locally
+	w = httptest.NewRecorder()
+	req.Header.Set(HttpHeaderOfForwardedBy, "autoscale-2_10.0.1.4")
+	assertEqual(t, forwardToLeader(w, req, "pause4test"), false)
+
+	// leader handles requests locally
+	Cm4Http.isLeader.Store(true)
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/pause4test?tidbclusterid=t1", nil)
+	assertEqual(t, forwardToLeader(w, req, "pause4test"), false)
+}
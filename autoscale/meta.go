@@ -32,6 +32,7 @@ var (
 	DefaultMinCntOfPod        = 1
 	DefaultMaxCntOfPod        = 4
 	DefaultCoreOfPod          = 8
+	DefaultMemGiBPerCore      = 4 // memory of pod is DefaultCoreOfPod * DefaultMemGiBPerCore GiB
 	DefaultLowerLimit         = 0.2
 	DefaultUpperLimit         = 0.8
 	DefaultPrewarmPoolCap     = 4
@@ -39,11 +40,13 @@ var (
 	// DefaultCapOfSeries        = 6  ///default scale interval: 1min. 6 * MetricResolutionSeconds(10s) = 60s (1min)
 	MetricResolutionSeconds = 10 // metric step: 10s
 
-	DefaultAutoPauseIntervalSeconds  = 60
-	DefaultScaleIntervalSeconds      = 60
-	HardCodeMaxScaleIntervalSecOfCfg = 3600
-	MaxUnassignWaitTimeSec           = 60
-	ReadNodeLogUploadS3Bucket        = ""
+	DefaultAutoPauseIntervalSeconds      = 60
+	DefaultScaleIntervalSeconds          = 60
+	DefaultScaleUpCooldownSeconds        = 60
+	DefaultScaleDownStabilizationSeconds = 300
+	HardCodeMaxScaleIntervalSecOfCfg     = 3600
+	MaxUnassignWaitTimeSec               = 60
+	ReadNodeLogUploadS3Bucket            = ""
 )
 
 var (
@@ -73,12 +76,14 @@ func TenantState2String(state int32) string {
 }
 
 type PodDesc struct {
-	Name string
-	IP   string
+	Name  string
+	IP    string
+	Class string // pod class of the cloneset which the pod belongs to
 	// State int32 // 0: unassigned 1:assigned
 
 	tenantName        string
 	startTimeOfAssign int64        //startTime of tenant's assignment
+	revision          string       // revision of cloneset which the pod is created from
 	mu                sync.RWMutex /// TODO use it //TODO add pod level lock!!!
 
 	muOfGrpc        sync.Mutex
@@ -108,6 +113,18 @@ func (p *PodDesc) GetTenantName() string {
 	return p.tenantName
 }
 
+func (p *PodDesc) SetRevision(revision string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.revision = revision
+}
+
+func (p *PodDesc) GetRevision() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.revision
+}
+
 func (p *PodDesc) GetStartTimeOfAssign() int64 {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -130,10 +147,10 @@ func (p *PodDesc) GetTenantInfo() (string, int64) {
 }
 
 // checked
-func (c *PodDesc) AssignTenantWithMockConf(tenant string) (resp *supervisor.Result, err error) {
+func (c *PodDesc) AssignTenantWithAddrs(tenant string, tidbStatusAddr string, pdAddr string) (resp *supervisor.Result, err error) {
 	c.muOfGrpc.Lock()
 	defer c.muOfGrpc.Unlock()
-	return AssignTenantHardCodeArgs(c.IP, tenant)
+	return AssignTenant(c.IP, tenant, tidbStatusAddr, pdAddr)
 }
 
 // checked
@@ -186,6 +203,17 @@ type TenantDesc struct {
 	conf            ConfigOfComputeCluster        /// TODO copy from configManager, reload for each analyze loop
 	refOfLatestConf *ConfigOfComputeClusterHolder /// TODO assign it // DO NOT directly read it ,since it is cocurrently being writed by other thread
 	// conf        TenantConf // TODO use it
+
+	lastResizeTs int64       // unix seconds of last resize by autoscale, guarded by mu
+	isScalingOut atomic.Bool // scale-out by autoscale is waiting for warm pods in background
+	stabilizer   ScaleStabilizer
+	onChanged    func() // called when state or config is changed, set before tenant is added into meta
+}
+
+func (c *TenantDesc) notifyChanged() {
+	if c.onChanged != nil {
+		c.onChanged()
+	}
 }
 
 func (c *TenantDesc) Dump() string {
@@ -207,6 +235,16 @@ func (c *TenantDesc) SortPodsAtStartUp() {
 	}
 }
 
+// getOrAttachConfHolder returns holder of tenant, auto-registered tenants have no holder until their config is updated by API
+func (c *TenantDesc) getOrAttachConfHolder(cm *ConfigManager) *ConfigOfComputeClusterHolder {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refOfLatestConf == nil {
+		c.refOfLatestConf = cm.SetHolder(c.Name, &ConfigOfComputeClusterHolder{Config: c.conf})
+	}
+	return c.refOfLatestConf
+}
+
 // checked
 func (c *TenantDesc) TryToReloadConf(forceUpdate bool) bool {
 	c.mu.Lock()
@@ -216,12 +254,14 @@ func (c *TenantDesc) TryToReloadConf(forceUpdate bool) bool {
 	}
 	if forceUpdate || c.refOfLatestConf.HasChanged(c.conf.LastModifiedTs) {
 		c.conf = c.refOfLatestConf.DeepCopy()
-		if (c.conf.MinCores%DefaultCoreOfPod != 0) || (c.conf.MaxCores%DefaultCoreOfPod != 0) {
-			Logger.Errorf("min/max cores not completedly divided by DefaultCoreOfPod, TidbCluster: %v , minCores: %v , maxCore: %v , coresOfPod:%v \n",
-				c.conf.ConfigOfTiDBCluster.Name, c.conf.MinCores, c.conf.MaxCores, DefaultCoreOfPod)
+		coresOfPod := c.conf.GetCoresOfPod()
+		if (c.conf.MinCores%coresOfPod != 0) || (c.conf.MaxCores%coresOfPod != 0) {
+			Logger.Errorf("min/max cores not completedly divided by cores of pod, TidbCluster: %v , minCores: %v , maxCore: %v , coresOfPod:%v \n",
+				c.conf.ConfigOfTiDBCluster.Name, c.conf.MinCores, c.conf.MaxCores, coresOfPod)
 		}
 		// c.MinCntOfPod = c.conf.MinCores / DefaultCoreOfPod
 		// c.MaxCntOfPod = c.conf.MaxCores / DefaultCoreOfPod
+		c.notifyChanged()
 		return true
 	}
 	return false
@@ -241,12 +281,83 @@ func (c *TenantDesc) GetLowerAndUpperCpuScaleThreshold() (float64, float64) {
 	return c.conf.GetLowerAndUpperCpuScaleThreshold()
 }
 
+// GetAddrsOfTiDBCluster returns tidb status addr and pd addr of tenant, error if they are missing
+func (c *TenantDesc) GetAddrsOfTiDBCluster() (string, string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conf.ConfigOfTiDBCluster.GetAddrs()
+}
+
+// return a copy of current config
+func (c *TenantDesc) GetConf() ConfigOfComputeCluster {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conf
+}
+
+func (c *TenantDesc) SetLastResizeTs(ts int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastResizeTs = ts
+}
+
+func (c *TenantDesc) GetLastResizeTs() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastResizeTs
+}
+
+// StabilizeTarget applies scale-up cooldown and scale-down stabilization window of tenant's config on the target of ScalePolicy
+// return -1 as target if the tenant should keep its current pods
+func (c *TenantDesc) StabilizeTarget(now int64, cntOfPods int, target int) (int, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stabilizer.Stabilize(now, cntOfPods, target, c.lastResizeTs, c.conf.ScaleUpCooldownSeconds, c.conf.ScaleDownStabilizationSeconds)
+}
+
+// GetScheduledScaleResult merges active schedules of tenant with its configured min/max pods
+func (c *TenantDesc) GetScheduledScaleResult(t time.Time) ScheduledScaleResult {
+	minCntOfPod := c.GetMinCntOfPod()
+	maxCntOfPod := c.GetMaxCntOfPod()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conf.GetScheduledScaleResult(t, minCntOfPod, maxCntOfPod)
+}
+
+// GetScheduledPrewarmCntOfPod returns how many more pods the tenant needs for its upcoming schedules
+func (c *TenantDesc) GetScheduledPrewarmCntOfPod(t time.Time) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return MaxInt(c.conf.GetUpcomingScheduledCntOfPod(t)-len(c.podMap), 0)
+}
+
+// GetPriorityClass returns name of priority class of tenant, never empty
+func (c *TenantDesc) GetPriorityClass() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return NormalizePriorityClassName(c.conf.PriorityClass)
+}
+
+// GetPodClass returns name of pod class of tenant, never empty
+func (c *TenantDesc) GetPodClass() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return NormalizePodClassName(c.conf.PodClass)
+}
+
+func (c *TenantDesc) GetCoresOfPod() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conf.GetCoresOfPod()
+}
+
 // checked
 func (c *TenantDesc) GetMinCntOfPod() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	ret := MaxInt(c.conf.MinCores/DefaultCoreOfPod, 1)
-	if c.conf.MinCores%DefaultCoreOfPod != 0 || c.conf.MinCores <= 0 {
+	coresOfPod := c.conf.GetCoresOfPod()
+	ret := MaxInt(c.conf.MinCores/coresOfPod, 1)
+	if c.conf.MinCores%coresOfPod != 0 || c.conf.MinCores <= 0 {
 		Logger.Errorf("[Conf][%v]invalid min_cores:%v, min_pods:%v", c.Name, c.conf.MinCores, ret)
 	}
 	return ret
@@ -256,8 +367,9 @@ func (c *TenantDesc) GetMinCntOfPod() int {
 func (c *TenantDesc) GetMaxCntOfPod() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	ret := MaxInt(c.conf.MaxCores/DefaultCoreOfPod, MaxInt(c.conf.MinCores/DefaultCoreOfPod, 1))
-	if c.conf.MaxCores%DefaultCoreOfPod != 0 || c.conf.MaxCores <= 0 {
+	coresOfPod := c.conf.GetCoresOfPod()
+	ret := MaxInt(c.conf.MaxCores/coresOfPod, MaxInt(c.conf.MinCores/coresOfPod, 1))
+	if c.conf.MaxCores%coresOfPod != 0 || c.conf.MaxCores <= 0 {
 		Logger.Errorf("[Conf][%v]invalid max_cores:%v, max_pods:%v", c.Name, c.conf.MaxCores, ret)
 	}
 	return ret
@@ -407,7 +519,11 @@ func (c *TenantDesc) GetPodAddrs() []string {
 
 // checked
 func (c *TenantDesc) switchState(from int32, to int32) bool {
-	return atomic.CompareAndSwapInt32(&c.State, from, to)
+	if atomic.CompareAndSwapInt32(&c.State, from, to) {
+		c.notifyChanged()
+		return true
+	}
+	return false
 }
 
 // checked
@@ -442,7 +558,9 @@ func (c *TenantDesc) SyncStateResumed() bool {
 func (c *TenantDesc) SetState(state int32) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	atomic.StoreInt32(&c.State, state)
+	if atomic.SwapInt32(&c.State, state) != state {
+		c.notifyChanged()
+	}
 }
 
 // checked
@@ -485,20 +603,29 @@ func NewAutoPauseTenantDescWithState(name string, minPods int, maxPods int, stat
 		// MaxCntOfPod: maxPods,
 		podMap:  make(map[string]*PodDesc),
 		podList: make([]*PodDesc, 0, 64),
-		conf: ConfigOfComputeCluster{
-			Disabled:                 false,                           ///TODO  disable or not defualt?
-			AutoPauseIntervalSeconds: DefaultAutoPauseIntervalSeconds, // 5min defualt
-			MinCores:                 minPods * DefaultCoreOfPod,
-			MaxCores:                 maxPods * DefaultCoreOfPod,
-			InitCores:                minPods * DefaultCoreOfPod,
-			WindowSeconds:            DefaultScaleIntervalSeconds,
-			CpuScaleRules:            nil,
-			ConfigOfTiDBCluster: &ConfigOfTiDBCluster{ // triger when modified: instantly reload compute pod's config  TODO handle version change case
-				Name: name,
-			},
-			LastModifiedTs: 0,
+		conf:    NewDefaultConfigOfComputeCluster(name, minPods, maxPods),
+	}
+}
+
+// NewDefaultConfigOfComputeCluster is the config of auto-registered tenants
+func NewDefaultConfigOfComputeCluster(name string, minPods int, maxPods int) ConfigOfComputeCluster {
+	ret := ConfigOfComputeCluster{
+		Disabled:                      false,                           ///TODO  disable or not defualt?
+		AutoPauseIntervalSeconds:      DefaultAutoPauseIntervalSeconds, // 5min defualt
+		MinCores:                      minPods * DefaultCoreOfPod,
+		MaxCores:                      maxPods * DefaultCoreOfPod,
+		InitCores:                     minPods * DefaultCoreOfPod,
+		WindowSeconds:                 DefaultScaleIntervalSeconds,
+		CpuScaleRules:                 nil,
+		ScaleUpCooldownSeconds:        DefaultScaleUpCooldownSeconds,
+		ScaleDownStabilizationSeconds: DefaultScaleDownStabilizationSeconds,
+		ConfigOfTiDBCluster: &ConfigOfTiDBCluster{ // triger when modified: instantly reload compute pod's config  TODO handle version change case
+			Name: name,
 		},
+		LastModifiedTs: 0,
 	}
+	ret.ConfigOfTiDBCluster.FillDefaultAddrs()
+	return ret
 }
 
 func NewTenantDescWithConfigAndState(name string, confHolder *ConfigOfComputeClusterHolder, state int32) *TenantDesc {
@@ -525,27 +652,59 @@ type PrewarmPoolOpResult struct {
 
 type PrewarmPool struct {
 	mu         sync.Mutex
+	PodClass   string
 	WarmedPods *TenantDesc
 
 	cntOfPending          atomic.Int32
 	tenantLastOpResultMap map[string]*PrewarmPoolOpResult
 	SoftLimit             int // expected size of pool
+	prewarmPoolCap        int // SoftLimit of pod class, it's also used by adaptive sizing until history covers one day
+
+	quarantine    map[string]*quarantinedPod // pods failed to be assigned or unassigned, they are not picked until probe succeeds
+	demandHistory *PodDemandHistory          // pods taken by tenants, used by adaptive sizing
+
+	waitingRequests []*podRequest // requests of tenants waiting for warm pods
+	requestSeq      uint64
+	outstanding     map[string]*outstandingDemand // tenant -> pods it fails to get, they are assigned when new pods are ready
+	reservations    map[string][]*reservedPod     // paused tenant -> warm pods kept for its resume, they are not in WarmedPods
 }
 
-func NewPrewarmPool(warmedPods *TenantDesc) *PrewarmPool {
+func NewPrewarmPool(podClass *PodClass) *PrewarmPool {
 	return &PrewarmPool{
-		WarmedPods:            warmedPods,
+		PodClass:              podClass.Name,
+		WarmedPods:            NewAutoPauseTenantDescWithState("", 0, podClass.PrewarmPoolCap, TenantStateResumed),
 		cntOfPending:          atomic.Int32{},
 		tenantLastOpResultMap: make(map[string]*PrewarmPoolOpResult),
-		SoftLimit:             warmedPods.GetMaxCntOfPod(),
+		SoftLimit:             podClass.PrewarmPoolCap,
+		prewarmPoolCap:        podClass.PrewarmPoolCap,
+		quarantine:            make(map[string]*quarantinedPod),
+		demandHistory:         NewPodDemandHistory(time.Now()),
+		outstanding:           make(map[string]*outstandingDemand),
+		reservations:          make(map[string][]*reservedPod),
 	}
 }
 
+// newPrewarmPools creates one pool for each pod class
+func newPrewarmPools() map[string]*PrewarmPool {
+	ret := make(map[string]*PrewarmPool)
+	for _, name := range GetPodClassNames() {
+		ret[name] = NewPrewarmPool(GetPodClass(name))
+	}
+	return ret
+}
+
 // checked
 func (p *PrewarmPool) DoPodsWarm(c *ClusterManager) {
 	failCntTotal := 0
+	// pods needed by upcoming scheduled scale-outs, computed before lock of pool to avoid deadlock
+	scheduledCnt := c.AutoScaleMeta.GetScheduledPrewarmCntOfPod(p.PodClass, time.Now())
+	// reserved pods leave WarmedPods, so pool is refilled for other tenants
+	c.AutoScaleMeta.maintainReservations(p)
 	p.mu.Lock()
 
+	if AdaptivePoolSizingEnabled {
+		p.SoftLimit = p.getAdaptiveSoftLimit(time.Now())
+	}
 	now := time.Now().Unix()
 	for k, v := range p.tenantLastOpResultMap {
 		if v.lastTs > now-FailCntCheckTimeWindow {
@@ -556,32 +715,41 @@ func (p *PrewarmPool) DoPodsWarm(c *ClusterManager) {
 	}
 
 	/// DO real pods resize!!!!
-	delta := failCntTotal + p.SoftLimit - (int(p.cntOfPending.Load()) + p.WarmedPods.GetCntOfPods())
-	MetricOfDoPodsWarmFailSnapshot.Set(float64(failCntTotal))
-	MetricOfDoPodsWarmDeltaSnapshot.Set(float64(delta))
-	MetricOfDoPodsWarmPendingSnapshot.Set(float64(p.cntOfPending.Load()))
-	MetricOfDoPodsWarmValidSnapshot.Set(float64(p.WarmedPods.GetCntOfPods()))
+	limit := p.SoftLimit + scheduledCnt
+	waitingCnt := p.getWaitingCntOfPodsWithoutLock() + p.getOutstandingCntOfPodsWithoutLock(now)
+	delta := failCntTotal + waitingCnt + limit - (int(p.cntOfPending.Load()) + p.WarmedPods.GetCntOfPods())
+	if p.PodClass == DefaultPodClassName { // series without pod class are kept for existing dashboards
+		MetricOfDoPodsWarmFailSnapshot.Set(float64(failCntTotal))
+		MetricOfDoPodsWarmDeltaSnapshot.Set(float64(delta))
+		MetricOfDoPodsWarmPendingSnapshot.Set(float64(p.cntOfPending.Load()))
+		MetricOfDoPodsWarmValidSnapshot.Set(float64(p.WarmedPods.GetCntOfPods()))
+		MetricOfDoPodsWarmScheduledSnapshot.Set(float64(scheduledCnt))
+	}
+	MetricOfDoPodsWarmSnapshotOfPodClass.WithLabelValues("fail", p.PodClass).Set(float64(failCntTotal))
+	MetricOfDoPodsWarmSnapshotOfPodClass.WithLabelValues("delta", p.PodClass).Set(float64(delta))
+	MetricOfDoPodsWarmSnapshotOfPodClass.WithLabelValues("pending", p.PodClass).Set(float64(p.cntOfPending.Load()))
+	MetricOfDoPodsWarmSnapshotOfPodClass.WithLabelValues("valid", p.PodClass).Set(float64(p.WarmedPods.GetCntOfPods()))
+	MetricOfDoPodsWarmSnapshotOfPodClass.WithLabelValues("scheduled", p.PodClass).Set(float64(scheduledCnt))
+	MetricOfDoPodsWarmSnapshotOfPodClass.WithLabelValues("soft_limit", p.PodClass).Set(float64(p.SoftLimit))
+	MetricOfDoPodsWarmSnapshotOfPodClass.WithLabelValues("waiting", p.PodClass).Set(float64(waitingCnt))
+	MetricOfDoPodsWarmSnapshotOfPodClass.WithLabelValues("reserved", p.PodClass).Set(float64(p.getCntOfAllReservedPodsWithoutLock()))
 	if delta != 0 {
-		Logger.Infof("[PrewarmPool]DoPodsWarm. failcnt:%v , delta:%v, pending: %v valid:%v ", failCntTotal, delta, p.cntOfPending.Load(), p.WarmedPods.GetCntOfPods())
+		Logger.Infof("[PrewarmPool][%v]DoPodsWarm. failcnt:%v , delta:%v, pending: %v valid:%v scheduled:%v waiting:%v", p.PodClass, failCntTotal, delta, p.cntOfPending.Load(), p.WarmedPods.GetCntOfPods(), scheduledCnt, waitingCnt)
 	}
 	p.mu.Unlock()
 
-	// Set these two metrics after unlock to avoid deadlock
-	MetricOfTenantCntSnapshot.Set(float64(c.AutoScaleMeta.GetTenantCnt()))
-	MetricOfPodCntSnapshot.Set(float64(c.AutoScaleMeta.GetPodCnt()))
-
 	// var ret *v1alpha1.CloneSet
 	var err error
 	if delta > 0 {
 		p.cntOfPending.Add(int32(delta))
 		Logger.Debugf("[CntOfPending]DoPodsWarm, add delta %v, result:%v", delta, p.cntOfPending.Load())
-		_, err = c.addNewPods(int32(delta), 2)
+		_, err = c.addNewPods(p.PodClass, int32(delta), 2)
 		if err != nil { // revert
 			p.cntOfPending.Add(int32(-delta))
 			Logger.Debugf("[CntOfPending]DoPodsWarm, revert delta %v, result:%v", delta, p.cntOfPending.Load())
 		}
 	} else if delta < 0 {
-		overCnt := p.WarmedPods.GetCntOfPods() - p.SoftLimit
+		overCnt := p.WarmedPods.GetCntOfPods() - limit
 		if overCnt > 0 {
 			removeCnt := MinInt(-delta, overCnt)
 
@@ -590,7 +758,7 @@ func (p *PrewarmPool) DoPodsWarm(c *ClusterManager) {
 			for _, v := range podsToDel {
 				podNames = append(podNames, v.Name)
 			}
-			_, err = c.removePods(podNames, 2)
+			_, err = c.removePods(p.PodClass, podNames, 2)
 			if err != nil { // revert
 				for _, pod := range podsToDel {
 					p.putWarmedPod("", pod, false)
@@ -599,7 +767,7 @@ func (p *PrewarmPool) DoPodsWarm(c *ClusterManager) {
 		}
 	}
 	if err != nil {
-		Logger.Errorf("[error][PrewarmPool.DoPodsWarm] error encountered! err:%v", err.Error())
+		Logger.Errorf("[error][PrewarmPool.DoPodsWarm][%v] error encountered! err:%v", p.PodClass, err.Error())
 	}
 }
 
@@ -624,6 +792,7 @@ func (p *PrewarmPool) getWarmedPods(tenantName string, cnt int) ([]*PodDesc, int
 		}
 	}
 	if tenantName != "" {
+		p.demandHistory.record(time.Now(), len(podsToAssign)+cnt)
 		p.tenantLastOpResultMap[tenantName] = &PrewarmPoolOpResult{
 			failCnt: cnt,
 			lastTs:  time.Now().Unix(),
@@ -632,18 +801,42 @@ func (p *PrewarmPool) getWarmedPods(tenantName string, cnt int) ([]*PodDesc, int
 	return podsToAssign, cnt
 }
 
+// takeWarmedPod removes the pod from pool, nil if it's not in pool
+func (p *PrewarmPool) takeWarmedPod(podName string) *PodDesc {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.WarmedPods.RemovePod(podName)
+}
+
+// takeWarmedPodOfRevision removes one pod of revision from pool, nil if there is none
+func (p *PrewarmPool) takeWarmedPodOfRevision(revision string) *PodDesc {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, name := range p.WarmedPods.GetPodNames() {
+		pod, ok := p.WarmedPods.GetPod(name)
+		if ok && isPodOfRevision(pod.GetRevision(), revision) {
+			return p.WarmedPods.RemovePod(name)
+		}
+	}
+	return nil
+}
+
+func (p *PrewarmPool) decCntOfPendingWithoutLock() {
+	if p.cntOfPending.Load() > 0 { /// .it maybe <0 when startUp, it's used for that case but harmless since it will be correct after startUp. TODO use a more graceful way
+		p.cntOfPending.Add(-1)
+		Logger.Debugf("[CntOfPending]putWarmedPod result:%v", p.cntOfPending.Load())
+	} else {
+		Logger.Debugf("[CntOfPending]putWarmedPod, cntOfPending <= 0, cntOfPending:%v", p.cntOfPending.Load())
+	}
+}
+
 // checked
 func (p *PrewarmPool) putWarmedPod(fromTenantName string, pod *PodDesc, isNewPod bool) {
-	Logger.Infof("[PrewarmPool]put warmed pod fromTenant: %v pod: %v newPod:%v", fromTenantName, pod.Name, isNewPod)
+	Logger.Infof("[PrewarmPool][%v]put warmed pod fromTenant: %v pod: %v newPod:%v", p.PodClass, fromTenantName, pod.Name, isNewPod)
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	if isNewPod {
-		if p.cntOfPending.Load() > 0 { /// .it maybe <0 when startUp, it's used for that case but harmless since it will be correct after startUp. TODO use a more graceful way
-			p.cntOfPending.Add(-1)
-			Logger.Debugf("[CntOfPending]putWarmedPod result:%v", p.cntOfPending.Load())
-		} else {
-			Logger.Debugf("[CntOfPending]putWarmedPod, cntOfPending <= 0, cntOfPending:%v", p.cntOfPending.Load())
-		}
+		p.decCntOfPendingWithoutLock()
 	}
 	p.WarmedPods.SetPod(pod.Name, pod) // no need to set startTimeOfAssign, since there is no tenantInfo
 	if fromTenantName != "" {          // reset tenant's LastOpResult， since tenant returns pod back to pool, he has enough pods.
@@ -652,18 +845,36 @@ func (p *PrewarmPool) putWarmedPod(fromTenantName string, pod *PodDesc, isNewPod
 			lastTs:  time.Now().Unix(),
 		}
 	}
+	p.dispatchWithoutLock()
 }
 
 type AutoScaleMeta struct {
 	mu         sync.Mutex //TODO use RwMutex
 	tenantMap  map[string]*TenantDesc
 	PodDescMap map[string]*PodDesc
-	*PrewarmPool
+
+	prewarmPools map[string]*PrewarmPool // pod class -> pool, fixed after creation
 
 	k8sCli *kubernetes.Clientset
 	// configMap      *v1.ConfigMap //TODO expire entry of removed pod
 	// cmMutex        sync.Mutex
 	IsRuntimeReady atomic.Bool
+
+	tenantStore        TenantStore       // nil means tenants are not persisted
+	muOfTenantStore    sync.Mutex        // guards persistedTenants and calls of tenantStore
+	persistedTenants   map[string]string // tenant -> last persisted record
+	tenantStoreChanged chan struct{}     // signaled when tenants are changed, flush loop saves them then
+
+	ConfigManager *ConfigManager
+	drainer       PodDrainer           // nil means pods are unassigned without drain
+	tsContainer   *TimeSeriesContainer // set by ClusterManager, used by pods assigned in background
+	decisions     *DecisionRecorder    // set by ClusterManager, records pods assigned to outstanding demand
+	isLeader      *atomic.Bool         // set by ClusterManager, nil means this replica is always leader
+}
+
+// IsLeader returns false if this replica is a follower, which only keeps a read-only view of tenants and pods
+func (c *AutoScaleMeta) IsLeader() bool {
+	return c.isLeader == nil || c.isLeader.Load()
 }
 
 // checked
@@ -674,11 +885,20 @@ func NewAutoScaleMeta(config *restclient.Config) *AutoScaleMeta {
 	}
 	ret := &AutoScaleMeta{
 		// Pod2tenant: make(map[string]string),
-		tenantMap:   make(map[string]*TenantDesc),
-		PodDescMap:  make(map[string]*PodDesc),
-		PrewarmPool: NewPrewarmPool(NewAutoPauseTenantDescWithState("", 0, PrewarmPoolCap, TenantStateResumed)),
-		k8sCli:      client,
+		tenantMap:    make(map[string]*TenantDesc),
+		PodDescMap:   make(map[string]*PodDesc),
+		prewarmPools: newPrewarmPools(),
+		k8sCli:       client,
+
+		persistedTenants:   make(map[string]string),
+		tenantStoreChanged: make(chan struct{}, 1),
+		ConfigManager:      NewConfigManager(),
+	}
+	ret.tenantStore, err = NewTenantStore(client)
+	if err != nil {
+		panic(err.Error())
 	}
+	ret.loadTenantsFromStore()
 	if UseSpecialTenantAsFixPool {
 		ret.setupManualPauseMockTenant(SpecialTenantNameForFixPool, 1, 1, false, 300, nil)
 	}
@@ -702,7 +922,27 @@ func (c *AutoScaleMeta) Dump() string {
 	}
 	// pendingCnt := c.pendingCnt
 	c.mu.Unlock()
-	return fmt.Sprintf("tenantcnt:%v, podcnt:%v, warmpool:%v tenants:{%+v}, pods:{%+v} ", len(tenant2PodCntMap), len(pod2ip), c.WarmedPods.GetPodNames(), tenant2PodCntMap, pod2ip)
+	warmPods := make(map[string][]string)
+	for _, pool := range c.GetPrewarmPools() {
+		warmPods[pool.PodClass] = pool.WarmedPods.GetPodNames()
+	}
+	return fmt.Sprintf("tenantcnt:%v, podcnt:%v, warmpool:%v tenants:{%+v}, pods:{%+v} ", len(tenant2PodCntMap), len(pod2ip), warmPods, tenant2PodCntMap, pod2ip)
+}
+
+// GetPrewarmPool returns nil if pod class is unknown
+func (c *AutoScaleMeta) GetPrewarmPool(podClass string) *PrewarmPool {
+	return c.prewarmPools[NormalizePodClassName(podClass)]
+}
+
+// GetPrewarmPools returns pools in order of GetPodClassNames
+func (c *AutoScaleMeta) GetPrewarmPools() []*PrewarmPool {
+	ret := make([]*PrewarmPool, 0, len(c.prewarmPools))
+	for _, name := range GetPodClassNames() {
+		if pool, ok := c.prewarmPools[name]; ok {
+			ret = append(ret, pool)
+		}
+	}
+	return ret
 }
 
 func (c *AutoScaleMeta) GetTenantCnt() int {
@@ -841,7 +1081,7 @@ func (c *AutoScaleMeta) ScanStateOfPods(atStartUp bool) {
 	// statesDeltaMap := make(map[string]string)
 	// var muOfStatesDeltaMap sync.Mutex
 	for _, v := range pods {
-		if v.IP != "" {
+		if v.IP != "" && !c.isReservedPod(v) {
 			wg.Add(1)
 			go func(podDesc *PodDesc) {
 				defer wg.Done()
@@ -869,6 +1109,21 @@ func (c *AutoScaleMeta) IterateTenants(f func(k string, v *TenantDesc)) {
 	}
 }
 
+// correctStatesOfTenants is called when this replica becomes leader, since follower doesn't change states of tenants in its scan.
+// Tenants with pods are resumed, and resumed tenants without pods are paused.
+func (c *AutoScaleMeta) correctStatesOfTenants() {
+	for _, tenant := range c.GetTenants() {
+		state := tenant.GetState()
+		if tenant.GetCntOfPods() > 0 && state != TenantStateResumed {
+			Logger.Warnf("[AutoScaleMeta][correctStatesOfTenants]tenant %v has pods but it's %v, set resumed", tenant.Name, TenantState2String(state))
+			tenant.SetState(TenantStateResumed)
+		} else if tenant.GetCntOfPods() == 0 && state == TenantStateResumed {
+			Logger.Warnf("[AutoScaleMeta][correctStatesOfTenants]tenant %v has no pod but it's resumed, set paused", tenant.Name)
+			tenant.SetState(TenantStatePaused)
+		}
+	}
+}
+
 // checked
 func (c *AutoScaleMeta) GetTenants() []*TenantDesc {
 	// c.mu.Lock()
@@ -899,6 +1154,17 @@ func (c *AutoScaleMeta) CopyTenantsMap() map[string]*TenantDesc {
 	return ret
 }
 
+// GetScheduledPrewarmCntOfPod sums pods needed by upcoming schedules of tenants of the pod class
+func (c *AutoScaleMeta) GetScheduledPrewarmCntOfPod(podClass string, t time.Time) int {
+	ret := 0
+	for _, tenant := range c.GetTenants() {
+		if tenant.GetPodClass() == podClass {
+			ret += tenant.GetScheduledPrewarmCntOfPod(t)
+		}
+	}
+	return ret
+}
+
 // checked
 func (c *AutoScaleMeta) GetTenantDesc(tenant string) *TenantDesc {
 	c.mu.Lock()
@@ -925,8 +1191,20 @@ func (c *AutoScaleMeta) GetTenantNames() []string {
 	return ret
 }
 
+// PodsChangeResult is the outcome of adding pods into or removing pods from a tenant
+type PodsChangeResult struct {
+	FailCnt int    // pods failed to be added or removed, -1 means the change is rejected
+	UndoCnt int    // pods whose supervisor call failed and were put back, removed pods are quarantined instead
+	Reason  string // why the change is rejected, empty if it's not known
+}
+
 // checked
 func (c *AutoScaleMeta) AsyncPause(tenant string, tsContainer *TimeSeriesContainer) bool {
+	return c.AsyncPauseWithCallback(tenant, tsContainer, nil)
+}
+
+// onDone is called with the outcome after all pods are removed, if pause is accepted
+func (c *AutoScaleMeta) AsyncPauseWithCallback(tenant string, tsContainer *TimeSeriesContainer, onDone func(PodsChangeResult)) bool {
 	v := c.GetTenantDesc(tenant)
 	// c.mu.Lock()
 	// defer c.mu.Unlock()
@@ -936,14 +1214,19 @@ func (c *AutoScaleMeta) AsyncPause(tenant string, tsContainer *TimeSeriesContain
 	}
 	if v.SyncStatePausing() {
 		Logger.Infof("[AutoScaleMeta][%v] Pausing %v", tenant, tenant)
-		go c.removePodFromTenant(v.GetCntOfPods(), tenant, tsContainer, true)
+		go func() {
+			result := c.removePodFromTenant(v.GetCntOfPods(), tenant, tsContainer, true)
+			if onDone != nil {
+				onDone(result)
+			}
+		}()
 		return true
 	} else {
 		return false
 	}
 }
 
-func (c *AutoScaleMeta) AsyncResume(tenant string, tsContainer *TimeSeriesContainer) (chan int, bool) {
+func (c *AutoScaleMeta) AsyncResume(tenant string, tsContainer *TimeSeriesContainer) (chan PodsChangeResult, bool) {
 	// c.mu.Lock()
 	// defer c.mu.Unlock()
 	// v, ok := c.tenantMap[tenant]
@@ -954,7 +1237,7 @@ func (c *AutoScaleMeta) AsyncResume(tenant string, tsContainer *TimeSeriesContai
 	if v.SyncStateResuming() {
 		Logger.Infof("[AutoScaleMeta][%v] Resuming %v", tenant, tenant)
 		// TODO ensure there is no pods now
-		resultChan := make(chan int)
+		resultChan := make(chan PodsChangeResult)
 		go c.addPodIntoTenant(v.GetInitCntOfPod(), tenant, tsContainer, true, resultChan)
 		return resultChan, true
 	} else {
@@ -1011,8 +1294,19 @@ func (c *AutoScaleMeta) GetTenantState(tenant string) (bool, int32, int) {
 
 // Used by controller
 func (c *AutoScaleMeta) addPreWarmFromPending(podName string, desc *PodDesc) {
-	Logger.Infof("[AutoScaleMeta]addPreWarmFromPending %v", podName)
-	c.PrewarmPool.putWarmedPod("", desc, true)
+	Logger.Infof("[AutoScaleMeta]addPreWarmFromPending %v class:%v", podName, desc.Class)
+	pool := c.GetPrewarmPool(desc.Class)
+	if pool == nil {
+		Logger.Errorf("[error][AutoScaleMeta]addPreWarmFromPending, unknown pod class:%v pod:%v", desc.Class, podName)
+		return
+	}
+	if !c.IsLeader() { // pods are handed out by leader only
+		pool.putWarmedPod("", desc, true)
+		return
+	}
+	if tenant := pool.putNewPod(desc); tenant != "" {
+		go c.assignPodOfOutstandingDemand(pool, tenant, desc)
+	}
 }
 
 // func (c *AutoScaleMeta) handleChangeOfPodIP(pod *v1.Pod) {
@@ -1046,7 +1340,8 @@ func (c *AutoScaleMeta) UpdatePod(pod *v1.Pod) {
 	podDesc, ok := c.PodDescMap[name]
 	Logger.Infof("[updatePod] %v cur_ip:%v", name, pod.Status.PodIP)
 	if !ok { // new pod
-		podDesc = &PodDesc{Name: name, IP: pod.Status.PodIP}
+		podDesc = &PodDesc{Name: name, IP: pod.Status.PodIP, Class: GetPodClassOfCloneSet(pod.Labels["app"])}
+		podDesc.SetRevision(pod.Labels[LabelKeyOfPodRevision])
 		c.PodDescMap[name] = podDesc
 
 		if pod.Status.PodIP != "" {
@@ -1059,6 +1354,7 @@ func (c *AutoScaleMeta) UpdatePod(pod *v1.Pod) {
 			//TODO handle
 			Logger.Errorf("[UpdatePod]exception case of Pod %v", name)
 		} else {
+			podDesc.SetRevision(pod.Labels[LabelKeyOfPodRevision]) // changed by in-place update
 			if podDesc.IP == "" {
 				if pod.Status.PodIP != "" {
 					podDesc.IP = pod.Status.PodIP
@@ -1091,14 +1387,29 @@ func (c *AutoScaleMeta) UpdatePod(pod *v1.Pod) {
 // TODO refine lock logic to prevent race
 // TODO make it non-blocking between tenants
 // there should not be more than one threads calling this for a same tenant
-func (c *AutoScaleMeta) ResizePodsOfTenant(from int, target int, tenant string, tsContainer *TimeSeriesContainer) {
+// return the target which is actually applied, it may be limited by step limits of tenant
+func (c *AutoScaleMeta) ResizePodsOfTenant(from int, target int, tenant string, tsContainer *TimeSeriesContainer) (int, PodsChangeResult) {
+	tenantDesc := c.GetTenantDesc(tenant)
+	if tenantDesc != nil {
+		conf := tenantDesc.GetConf()
+		limitedTarget := conf.LimitScaleStep(from, target)
+		if limitedTarget != target {
+			Logger.Infof("[AutoScaleMeta]ResizePodsOfTenant target is limited by step from %v to %v, tenant:%v", target, limitedTarget, tenant)
+			target = limitedTarget
+		}
+	}
 	Logger.Infof("[AutoScaleMeta]ResizePodsOfTenant from %v to %v , tenant:%v", from, target, tenant)
 	// TODO assert and validate "from" equal to current cntOfPod
+	if target != from && tenantDesc != nil {
+		tenantDesc.SetLastResizeTs(time.Now().Unix())
+	}
+	var result PodsChangeResult
 	if target > from {
-		c.addPodIntoTenant(target-from, tenant, tsContainer, false, nil)
+		result = c.addPodIntoTenant(target-from, tenant, tsContainer, false, nil)
 	} else if target < from {
-		c.removePodFromTenant(from-target, tenant, tsContainer, false)
+		result = c.removePodFromTenant(from-target, tenant, tsContainer, false)
 	}
+	return target, result
 }
 
 // checked
@@ -1110,7 +1421,9 @@ func (c *AutoScaleMeta) removePodFromClusterWithoutLock(podDesc *PodDesc) {
 	var ok bool
 	var oldTenantDesc *TenantDesc
 	if oldTenant == "" {
-		oldTenantDesc = c.PrewarmPool.WarmedPods
+		if pool := c.GetPrewarmPool(podDesc.Class); pool != nil {
+			oldTenantDesc = pool.WarmedPods
+		}
 	} else {
 		oldTenantDesc, ok = c.tenantMap[oldTenant]
 		if !ok {
@@ -1123,14 +1436,22 @@ func (c *AutoScaleMeta) removePodFromClusterWithoutLock(podDesc *PodDesc) {
 	// remove podinfo from cluster
 	podDesc.ClearTenantInfo()
 
+	if pool := c.GetPrewarmPool(podDesc.Class); pool != nil {
+		pool.releaseQuarantinedPod(podName)
+		pool.mu.Lock()
+		pool.removePodOfReservationsWithoutLock(podName)
+		pool.mu.Unlock()
+	}
 	delete(c.PodDescMap, podDesc.Name)
 }
 
 // checked
-func (c *AutoScaleMeta) getTenantDescOrWarmedPool(tenant string) *TenantDesc {
+func (c *AutoScaleMeta) getTenantDescOrWarmedPool(tenant string, podClass string) *TenantDesc {
 	var tenantDesc *TenantDesc
 	if tenant == "" {
-		tenantDesc = c.PrewarmPool.WarmedPods
+		if pool := c.GetPrewarmPool(podClass); pool != nil {
+			tenantDesc = pool.WarmedPods
+		}
 	} else {
 		var ok bool
 		tenantDesc, ok = c.tenantMap[tenant]
@@ -1146,11 +1467,15 @@ func (c *AutoScaleMeta) UpdateLocalMetaPodOfTenant(podName string, podDesc *PodD
 	Logger.Infof("[AutoScaleMeta]updateLocalMetaPodOfTenant pod:%v tenant:%v", podName, tenant)
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if _, ok := c.tenantMap[tenant]; tenant != "" && !ok && !c.IsLeader() {
+		Logger.Warnf("[AutoScaleMeta][updateLocalMetaPodOfTenant]no such tenant:%v in view of follower, pod:%v is kept", tenant, podName)
+		return
+	}
 	// remove old pod of tenant info if possible
 	oldTenant, _ := podDesc.GetTenantInfo()
 
 	if oldTenant != tenant {
-		oldTenantDesc := c.getTenantDescOrWarmedPool(oldTenant)
+		oldTenantDesc := c.getTenantDescOrWarmedPool(oldTenant, podDesc.Class)
 		if oldTenantDesc != nil {
 			oldTenantDesc.RemovePod(podName)
 		}
@@ -1161,7 +1486,12 @@ func (c *AutoScaleMeta) UpdateLocalMetaPodOfTenant(podName string, podDesc *PodD
 	if tenant != "" {
 		newTenantDesc, ok = c.tenantMap[tenant]
 
-		if !ok {
+		if ok && c.adoptPreAssignedPodWithoutLock(newTenantDesc, podDesc, startTimeOfAssign) {
+			newTenantDesc.RemovePod(podName) // it may be attached by earlier scan, paused tenant is kept paused
+			return
+		} else if !c.IsLeader() {
+			// follower only moves pods between known tenants, tenants are registered and their states are changed by leader
+		} else if !ok {
 			if OptionRunMode == RunModeLocal || OptionRunMode == RunModeServeless {
 				Logger.Infof("[AutoScaleMeta][updateLocalMetaPodOfTenant]no such tenant:%v, do auto register", tenant)
 				c.setupAutoPauseTenantWithStateExtraArgs(tenant, DefaultMinCntOfPod, DefaultMaxCntOfPod, TenantStateResumed, false)
@@ -1188,8 +1518,8 @@ func (c *AutoScaleMeta) UpdateLocalMetaPodOfTenant(podName string, podDesc *PodD
 			}
 		}
 	} else {
-		newTenantDesc = c.PrewarmPool.WarmedPods
-		ok = true
+		newTenantDesc = c.getTenantDescOrWarmedPool("", podDesc.Class)
+		ok = newTenantDesc != nil
 	}
 	if ok {
 		// if startTimeOfAssign != 0 {
@@ -1205,7 +1535,7 @@ func (c *AutoScaleMeta) UpdateLocalMetaPodOfTenant(podName string, podDesc *PodD
 // checked
 // return cnt fail to add
 // -1 is error
-func (c *AutoScaleMeta) addPodIntoTenant(addCnt int, tenant string, tsContainer *TimeSeriesContainer, isResume bool, resultChan chan<- int) (retv int) {
+func (c *AutoScaleMeta) addPodIntoTenant(addCnt int, tenant string, tsContainer *TimeSeriesContainer, isResume bool, resultChan chan<- PodsChangeResult) (retv PodsChangeResult) {
 	start := time.Now()
 	MetricOfAddPodIntoTenantCnt.Inc()
 	Logger.Infof("[AutoScaleMeta][resize][addPodIntoTenant][%v] %v %v isResume:%v", tenant, addCnt, tenant, isResume)
@@ -1221,7 +1551,24 @@ func (c *AutoScaleMeta) addPodIntoTenant(addCnt int, tenant string, tsContainer
 	// c.mu.Unlock()
 	tenantDesc := c.GetTenantDesc(tenant)
 	if tenantDesc == nil {
-		return -1
+		return PodsChangeResult{FailCnt: -1}
+	}
+	// reject before taking warmed pods, since supervisor can't serve tenant without addresses
+	tidbStatusAddr, pdAddr, err := tenantDesc.GetAddrsOfTiDBCluster()
+	if err != nil {
+		Logger.Errorf("[error][AutoScaleMeta][resize][addPodIntoTenant][%v] assignment rejected, err: %v", tenant, err.Error())
+		if isResume {
+			tenantDesc.SetState(TenantStatePaused)
+		}
+		return PodsChangeResult{FailCnt: -1, Reason: err.Error()}
+	}
+	pool := c.GetPrewarmPool(tenantDesc.GetPodClass())
+	if pool == nil {
+		Logger.Errorf("[error][AutoScaleMeta][resize][addPodIntoTenant][%v] unknown pod class:%v", tenant, tenantDesc.GetPodClass())
+		if isResume {
+			tenantDesc.SetState(TenantStatePaused)
+		}
+		return PodsChangeResult{FailCnt: -1}
 	}
 	// tenantDesc.ResizeMu.Lock()
 	// defer tenantDesc.ResizeMu.Unlock()
@@ -1234,7 +1581,7 @@ func (c *AutoScaleMeta) addPodIntoTenant(addCnt int, tenant string, tsContainer
 			// ERROR!!!
 			Logger.Errorf("[error][AutoScaleMeta][resize][addPodIntoTenant][%v] failed to resume: 'tenantDesc.GetState() != TenantStateResuming', state:%v \n ", tenant, state)
 			c.mu.Unlock()
-			return -1
+			return PodsChangeResult{FailCnt: -1}
 		}
 	} else {
 		state := tenantDesc.GetState()
@@ -1242,12 +1589,42 @@ func (c *AutoScaleMeta) addPodIntoTenant(addCnt int, tenant string, tsContainer
 			// ERROR!!!
 			Logger.Errorf("[error][AutoScaleMeta][resize][addPodIntoTenant][%v] failed: 'tenantDesc.GetState() != TenantStateResumed', state:%v \n ", tenant, state)
 			c.mu.Unlock()
-			return -1
+			return PodsChangeResult{FailCnt: -1}
 		}
 	}
 
-	podsToAssign, failCnt := c.PrewarmPool.getWarmedPods(tenant, addCnt)
+	var reservedPods []*reservedPod
+	if isResume { // pods reserved for paused tenant are used before shared ones
+		reservedPods = pool.takeReservedPods(tenant, addCnt)
+	}
+	c.mu.Unlock()
+
+	podsToAssign, failCnt := pool.acquireWarmedPods(tenant, addCnt-len(reservedPods), isResume, tenantDesc.GetPriorityClass(), time.Duration(PodRequestTimeoutSec)*time.Second)
+	// tenant may be paused while waiting for pods
+	c.mu.Lock()
+	if state := tenantDesc.GetState(); (isResume && state != TenantStateResuming) || (!isResume && state != TenantStateResumed) {
+		Logger.Errorf("[error][AutoScaleMeta][resize][addPodIntoTenant][%v] state changes while waiting for pods, state:%v", tenant, TenantState2String(state))
+		for _, v := range podsToAssign {
+			pool.putWarmedPod("", v, false)
+		}
+		pool.putReservedPods(tenant, reservedPods)
+		c.mu.Unlock()
+		return PodsChangeResult{FailCnt: -1}
+	}
+	preAssignedCnt := 0
+	for _, r := range reservedPods {
+		if r.isPreAssigned() { // supervisor has served tenant already
+			tsContainer.ResetMetricsOfPod(r.pod.Name)
+			tenantDesc.SetPodWithTenantInfo(r.pod.Name, r.pod, r.startTimeOfAssign)
+			preAssignedCnt++
+		} else {
+			podsToAssign = append(podsToAssign, r.pod)
+		}
+	}
 	c.mu.Unlock()
+	if len(reservedPods) > 0 {
+		Logger.Infof("[AutoScaleMeta][resize][addPodIntoTenant][%v] use %v reserved pods, pre-assigned:%v", tenant, len(reservedPods), preAssignedCnt)
+	}
 
 	exceptionCnt := 0
 	for _, pod2assign := range podsToAssign {
@@ -1266,7 +1643,7 @@ func (c *AutoScaleMeta) addPodIntoTenant(addCnt int, tenant string, tsContainer
 		defer v.isStateChanging.Store(false)
 		go func(v *PodDesc) {
 			defer apiWg.Done()
-			resp, err := v.AssignTenantWithMockConf(tenant)
+			resp, err := v.AssignTenantWithAddrs(tenant, tidbStatusAddr, pdAddr)
 			localMu.Lock()
 			defer localMu.Unlock()
 			if err != nil || resp.HasErr {
@@ -1299,7 +1676,7 @@ func (c *AutoScaleMeta) addPodIntoTenant(addCnt int, tenant string, tsContainer
 	for _, v := range undoList {
 		_, ok := c.PodDescMap[v.Name]
 		if ok {
-			c.PrewarmPool.putWarmedPod(tenant, v, false) // TODO do we need treat failed pods as anomaly group, so that we handle them differently from normal ones.
+			pool.quarantinePod(v, tenant, "grpc error of AssignTenant")
 		} else {
 			Logger.Warnf("[AutoScaleMeta][resize][addPodIntoTenant][%v] exception case: pod %v has beed deleted by k8s", tenant, v.Name)
 			exceptionCnt++
@@ -1318,24 +1695,93 @@ func (c *AutoScaleMeta) addPodIntoTenant(addCnt int, tenant string, tsContainer
 	MetricOfAddPodSuccessCnt.Add(float64(addCnt - failCnt))
 	MetricOfAddPodFailedCnt.Add(float64(failCnt))
 	// c.setConfigMapStateBatch(statesDeltaMap)
-	return failCnt
+	return PodsChangeResult{FailCnt: failCnt, UndoCnt: len(undoList)}
+}
+
+// putWarmedPodIntoPoolOfClass returns pod back to the pool of its class
+func (c *AutoScaleMeta) putWarmedPodIntoPoolOfClass(fromTenant string, pod *PodDesc) {
+	pool := c.GetPrewarmPool(pod.Class)
+	if pool == nil {
+		Logger.Errorf("[error][AutoScaleMeta]unknown pod class:%v pod:%v", pod.Class, pod.Name)
+		return
+	}
+	pool.putWarmedPod(fromTenant, pod, false)
+}
+
+// replacePodOfTenant assigns newPod to tenant before oldPod is unassigned, so that tenant never has fewer pods.
+// It returns oldPod which has left tenant, newPod is returned to pool if it fails to be assigned
+func (c *AutoScaleMeta) replacePodOfTenant(tenant string, oldPodName string, newPod *PodDesc, tsContainer *TimeSeriesContainer) (*PodDesc, error) {
+	tenantDesc := c.GetTenantDesc(tenant)
+	var err error
+	if tenantDesc == nil {
+		err = fmt.Errorf("no such tenant")
+	} else if state := tenantDesc.GetState(); state != TenantStateResumed {
+		err = fmt.Errorf("tenant is %v", TenantState2String(state))
+	} else if _, ok := tenantDesc.GetPod(oldPodName); !ok {
+		err = fmt.Errorf("pod %v is not assigned to tenant", oldPodName)
+	}
+	var tidbStatusAddr, pdAddr string
+	if err == nil {
+		tidbStatusAddr, pdAddr, err = tenantDesc.GetAddrsOfTiDBCluster()
+	}
+	if err != nil {
+		c.mu.Lock()
+		c.putWarmedPodIntoPoolOfClass("", newPod)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	newPod.isStateChanging.Store(true)
+	resp, err := newPod.AssignTenantWithAddrs(tenant, tidbStatusAddr, pdAddr)
+	newPod.isStateChanging.Store(false)
+	if err != nil {
+		Logger.Errorf("[error][AutoScaleMeta][replacePodOfTenant][%v] grpc error, undo, pod:%v err: %v", tenant, newPod.Name, err.Error())
+		c.quarantinePod(newPod, tenant, "grpc error of AssignTenant: "+err.Error())
+		return nil, err
+	} else if resp.HasErr {
+		Logger.Errorf("[error][AutoScaleMeta][replacePodOfTenant][%v] app api error, pod:%v err: %v", tenant, newPod.Name, resp.ErrInfo)
+		if !resp.IsUnassigning {
+			c.UpdateLocalMetaPodOfTenant(newPod.Name, newPod, resp.TenantID, resp.StartTime)
+		} else {
+			HandleUnassingCase(c, resp.TenantID, newPod, tsContainer)
+		}
+		return nil, fmt.Errorf(resp.ErrInfo)
+	}
+	c.mu.Lock()
+	tsContainer.ResetMetricsOfPod(newPod.Name)
+	tenantDesc.SetPodWithTenantInfo(newPod.Name, newPod, resp.StartTime)
+	oldPod := tenantDesc.RemovePod(oldPodName)
+	c.mu.Unlock()
+	if oldPod == nil { // removed by scale-in meanwhile
+		Logger.Warnf("[AutoScaleMeta][replacePodOfTenant][%v] pod %v has left tenant", tenant, oldPodName)
+		return nil, nil
+	}
+
+	// new pod is in topology of tenant and old pod has left it, running tasks of old pod are finished before unassign
+	oldPod.isStateChanging.Store(true)
+	c.drainPods(tenantDesc, []*PodDesc{oldPod})
+	// old pod is going to be deleted, so failure of unassign is only logged
+	resp, err = oldPod.UnassignTenantWithMockConf(tenant, false)
+	oldPod.isStateChanging.Store(false)
+	if err != nil {
+		Logger.Warnf("[AutoScaleMeta][replacePodOfTenant][%v] failed to unassign pod %v, err: %v", tenant, oldPodName, err.Error())
+	} else if resp.HasErr {
+		Logger.Warnf("[AutoScaleMeta][replacePodOfTenant][%v] failed to unassign pod %v, err: %v", tenant, oldPodName, resp.ErrInfo)
+	}
+	tsContainer.ResetMetricsOfPod(oldPodName)
+	Logger.Infof("[AutoScaleMeta][replacePodOfTenant][%v] pod %v is replaced by %v", tenant, oldPodName, newPod.Name)
+	return oldPod, nil
 }
 
 // checked
+// HandleUnassingCase waits in background until supervisor has finished unassigning, see waitUnassignDone
 func HandleUnassingCase(c *AutoScaleMeta, curtenant string, v *PodDesc, tsContainer *TimeSeriesContainer) {
 	Logger.Infof("[HandleUnassingCase]begin. tenant:%v pod:%v", curtenant, v.Name)
-	go func(c *AutoScaleMeta, curtenant string, v *PodDesc, tsContainer *TimeSeriesContainer) {
-		time.Sleep(time.Duration(MaxUnassignWaitTimeSec) * time.Second)
-		c.mu.Lock()
-		c.PrewarmPool.putWarmedPod(curtenant, v, false)
-		tsContainer.ResetMetricsOfPod(v.Name)
-		c.mu.Unlock()
-		Logger.Infof("[HandleUnassingCase]done. tenant:%v pod:%v", curtenant, v.Name)
-	}(c, curtenant, v, tsContainer)
+	go c.waitUnassignDone(curtenant, v, tsContainer)
 }
 
 // checked
-func (c *AutoScaleMeta) removePodFromTenant(removeCnt int, tenant string, tsContainer *TimeSeriesContainer, isPause bool) int {
+func (c *AutoScaleMeta) removePodFromTenant(removeCnt int, tenant string, tsContainer *TimeSeriesContainer, isPause bool) PodsChangeResult {
 	start := time.Now()
 	MetricOfRemovePodFromTenantCnt.Inc()
 	defer func() {
@@ -1348,8 +1794,9 @@ func (c *AutoScaleMeta) removePodFromTenant(removeCnt int, tenant string, tsCont
 	tenantDesc := c.GetTenantDesc(tenant)
 	// c.mu.Unlock()
 	if tenantDesc == nil {
-		return -1
+		return PodsChangeResult{FailCnt: -1}
 	}
+	c.clearOutstandingDemandOfTenant(tenantDesc) // tenant needs no more pods
 	// tenantDesc.ResizeMu.Lock()
 	// defer tenantDesc.ResizeMu.Unlock()
 	c.mu.Lock() // tenantDesc.ResizeMu.Lock() always before c.mu.Lock(), to prevent dead lock between  tenantDesc.ResizeMu and c.mu.Lock()
@@ -1362,7 +1809,7 @@ func (c *AutoScaleMeta) removePodFromTenant(removeCnt int, tenant string, tsCont
 			// ERROR!!!
 			Logger.Errorf("[error][AutoScaleMeta][resize][removePodFromTenant][%v] failed to pause: 'tenantDesc.GetState() != TenantStatePausing', state:%v \n ", tenant, state)
 			c.mu.Unlock()
-			return -1
+			return PodsChangeResult{FailCnt: -1}
 		}
 	} else {
 		state := tenantDesc.GetState()
@@ -1370,12 +1817,11 @@ func (c *AutoScaleMeta) removePodFromTenant(removeCnt int, tenant string, tsCont
 			// ERROR!!!
 			Logger.Errorf("[error][AutoScaleMeta][resize][removePodFromTenant][%v] failed: 'tenantDesc.GetState() != TenantStateResumed', state:%v \n ", tenant, state)
 			c.mu.Unlock()
-			return -1
+			return PodsChangeResult{FailCnt: -1}
 		}
 	}
 
 	cnt := removeCnt
-	exceptionCnt := 0
 	podsToUnassign := make([]*PodDesc, 0, removeCnt)
 	cnt, podsToUnassign = tenantDesc.PopPods(cnt, podsToUnassign)
 	if isPause {
@@ -1384,30 +1830,43 @@ func (c *AutoScaleMeta) removePodFromTenant(removeCnt int, tenant string, tsCont
 	c.mu.Unlock()
 	for _, pod2unassign := range podsToUnassign {
 		Logger.Debugf("[AutoScaleMeta][resize][removePodFromTenant][%v] podsToUnassign(name, ip): %v %v", tenant, pod2unassign.Name, pod2unassign.IP)
+		pod2unassign.isStateChanging.Store(true)
+	}
+	if !isPause { // pods have left topology of tenant, so scale-in doesn't wait for drain and unassign in analyze loop
+		go c.unassignRemovedPods(tenantDesc, podsToUnassign, tsContainer)
+		return PodsChangeResult{FailCnt: cnt}
+	}
+	c.unassignRemovedPods(tenantDesc, podsToUnassign, tsContainer)
+	return PodsChangeResult{FailCnt: cnt}
+}
+
+// unassignRemovedPods drains and unassigns pods removed from tenant, then puts them into pool.
+// Pods failed to be unassigned by grpc error are quarantined, since they may still serve tenant.
+func (c *AutoScaleMeta) unassignRemovedPods(tenantDesc *TenantDesc, podsToUnassign []*PodDesc, tsContainer *TimeSeriesContainer) {
+	tenant := tenantDesc.Name
+	for _, pod2unassign := range podsToUnassign {
+		defer pod2unassign.isStateChanging.Store(false)
 	}
+	// running tasks are finished before unassign, since pods have left topology of tenant
+	c.drainPods(tenantDesc, podsToUnassign)
 
-	undoList := make([]*PodDesc, 0, removeCnt)
+	var failCnt atomic.Int32
 	// statesDeltaMap := make(map[string]string)
-	var localMu sync.Mutex
 	var apiWg sync.WaitGroup
 
 	apiWg.Add(len(podsToUnassign))
 	for _, v := range podsToUnassign {
-		// TODO async call grpc unassign api
-		v.isStateChanging.Store(true)
-		defer v.isStateChanging.Store(false)
 		go func(v *PodDesc) {
 			defer apiWg.Done()
 			resp, err := v.UnassignTenantWithMockConf(tenant, false)
-			localMu.Lock()
-			defer localMu.Unlock()
 			if err != nil || resp.HasErr {
+				failCnt.Add(1)
 				// HandleUnassignError
-				if err != nil { // grpc error, undo
-					Logger.Errorf("[error][AutoScaleMeta][resize][removePodFromTenant][%v] grpc error, undo , err: %v", tenant, err.Error())
-					undoList = append(undoList, v)
+				if err != nil { // grpc error, quarantine
+					Logger.Errorf("[error][AutoScaleMeta][resize][removePodFromTenant][%v] grpc error, quarantine, pod:%v err: %v", tenant, v.Name, err.Error())
+					c.quarantinePod(v, tenant, "grpc error of UnassignTenant: "+err.Error())
 				} else { // app api error , correct its state
-					Logger.Errorf("[error][AutoScaleMeta][resize][removePodFromTenant][%v] app api error, undo , err: %v", tenant, resp.ErrInfo)
+					Logger.Errorf("[error][AutoScaleMeta][resize][removePodFromTenant][%v] app api error, pod:%v err: %v", tenant, v.Name, resp.ErrInfo)
 					if !resp.IsUnassigning {
 						c.UpdateLocalMetaPodOfTenant(v.Name, v, resp.TenantID, resp.StartTime)
 					} else { /// TODO consider it deeper
@@ -1418,7 +1877,7 @@ func (c *AutoScaleMeta) removePodFromTenant(removeCnt int, tenant string, tsCont
 				c.mu.Lock()
 				// statesDeltaMap[v.Name] = ConfigMapPodStateStr(CmRnPodStateUnassigned, "")
 				tsContainer.ResetMetricsOfPod(v.Name)
-				c.PrewarmPool.putWarmedPod(tenant, v, false)
+				c.putWarmedPodIntoPoolOfClass(tenant, v)
 				c.mu.Unlock()
 			}
 		}(v)
@@ -1426,28 +1885,12 @@ func (c *AutoScaleMeta) removePodFromTenant(removeCnt int, tenant string, tsCont
 	}
 	apiWg.Wait()
 
-	// undo failed works
-	c.mu.Lock()
-	for _, v := range undoList {
-		_, ok := c.PodDescMap[v.Name]
-		if ok {
-			tenantDesc.SetPod(v.Name, v) //no need to set startTimeOfAssign again
-		} else {
-			Logger.Warnf("[AutoScaleMeta][resize][removePodFromTenant][%v]exception case: pod %v has beed deleted by k8s", tenant, v.Name)
-			exceptionCnt++
-		}
-		cnt++
-	}
-
-	c.mu.Unlock()
-	if len(undoList) != 0 || exceptionCnt != 0 {
-		Logger.Warnf("[AutoScaleMeta][resize][removePodFromTenant][%v] exceptionCnt:%v len(undoList):%v", tenant, exceptionCnt, len(undoList))
-	}
 	// c.setConfigMapStateBatch(statesDeltaMap)
-	Logger.Debugf("[AutoScaleMeta][resize][removePodFromTenant][%v]done. warmpool.size:%v pods:%v", tenant, c.WarmedPods.GetCntOfPods(), c.WarmedPods.GetPodNames())
-	MetricOfRemovePodSuccessCnt.Add(float64(removeCnt - len(undoList)))
-	MetricOfRemovePodFailedCnt.Add(float64(len(undoList)))
-	return cnt
+	if pool := c.GetPrewarmPool(tenantDesc.GetPodClass()); pool != nil {
+		Logger.Debugf("[AutoScaleMeta][resize][removePodFromTenant][%v]done. warmpool.size:%v pods:%v", tenant, pool.WarmedPods.GetCntOfPods(), pool.WarmedPods.GetPodNames())
+	}
+	MetricOfRemovePodSuccessCnt.Add(float64(len(podsToUnassign) - int(failCnt.Load())))
+	MetricOfRemovePodFailedCnt.Add(float64(failCnt.Load()))
 }
 
 // checked
@@ -1479,7 +1922,7 @@ func (c *AutoScaleMeta) setupAutoPauseTenantWithStateExtraArgs(tenant string, mi
 	}
 	_, ok := c.tenantMap[tenant]
 	if !ok {
-		c.tenantMap[tenant] = NewAutoPauseTenantDescWithState(tenant, minPods, maxPods, state)
+		c.addTenantWithoutLock(NewAutoPauseTenantDescWithState(tenant, minPods, maxPods, state))
 		return true
 	} else {
 		return false
@@ -1493,18 +1936,29 @@ func (c *AutoScaleMeta) SetupAutoPauseTenantWithPausedState(tenant string, minPo
 
 // checked
 func (c *AutoScaleMeta) SetupTenantWithConfig(tenant string, confHolder *ConfigOfComputeClusterHolder, state int32) bool {
+	confHolder.mu.Lock()
+	fillConfigOfTiDBCluster(tenant, &confHolder.Config)
+	confHolder.Config.parseSchedules()
+	confHolder.mu.Unlock()
 	Logger.Infof("[SetupTenant] SetupTenantWithConfig(%v, %+v)", tenant, confHolder.Config)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	_, ok := c.tenantMap[tenant]
 	if !ok {
-		c.tenantMap[tenant] = NewTenantDescWithConfigAndState(tenant, confHolder, state)
+		c.addTenantWithoutLock(NewTenantDescWithConfigAndState(tenant, c.ConfigManager.SetHolder(tenant, confHolder), state))
 		return true
 	} else {
 		return false
 	}
 }
 
+// addTenantWithoutLock adds tenant into meta with c.mu held, changes of tenant are flushed into tenant store since then
+func (c *AutoScaleMeta) addTenantWithoutLock(tenantDesc *TenantDesc) {
+	tenantDesc.onChanged = c.notifyTenantStoreChanged
+	c.tenantMap[tenantDesc.Name] = tenantDesc
+	c.notifyTenantStoreChanged()
+}
+
 // checked
 func (c *AutoScaleMeta) ComputeStatisticsOfTenant(tenantName string, tsc *TimeSeriesContainer, caller string, metricsTopic MetricsTopic) ([]AvgSigma, map[string]float64 /* avg_map */, map[string]int64 /* cnt_map*/, map[string]*DescOfPodTimeSeries, *DescOfTenantTimeSeries) {
 	c.mu.Lock()
@@ -1550,7 +2004,9 @@ func (c *AutoScaleMeta) ComputeStatisticsOfTenant(tenantName string, tsc *TimeSe
 			}
 			if metricsTopic == MetricsTopicCpu {
 				for i := range statsOfPod { // make weight even between pods
-					statsOfPod[i] = AvgSigma{statsOfPod[i].Avg(), 1}
+					if statsOfPod[i].Cnt() > 0 { // skip metrics which are not collected
+						statsOfPod[i] = AvgSigma{statsOfPod[i].Avg(), 1}
+					}
 				}
 			}
 			if len(statsOfPod) > 0 {
@@ -19,14 +19,22 @@ var (
 	MetricOfDoPodsWarmSnapshot = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "autoscale_do_pods_warm_snapshot",
-			Help: "The snapshot in function DoPodsWarm",
+			Help: "The snapshot in function DoPodsWarm, of default pod class",
 		}, []string{"type"},
 	)
 
-	MetricOfDoPodsWarmFailSnapshot    = MetricOfDoPodsWarmSnapshot.WithLabelValues("fail")
-	MetricOfDoPodsWarmDeltaSnapshot   = MetricOfDoPodsWarmSnapshot.WithLabelValues("delta")
-	MetricOfDoPodsWarmPendingSnapshot = MetricOfDoPodsWarmSnapshot.WithLabelValues("pending")
-	MetricOfDoPodsWarmValidSnapshot   = MetricOfDoPodsWarmSnapshot.WithLabelValues("valid")
+	MetricOfDoPodsWarmFailSnapshot      = MetricOfDoPodsWarmSnapshot.WithLabelValues("fail")
+	MetricOfDoPodsWarmDeltaSnapshot     = MetricOfDoPodsWarmSnapshot.WithLabelValues("delta")
+	MetricOfDoPodsWarmPendingSnapshot   = MetricOfDoPodsWarmSnapshot.WithLabelValues("pending")
+	MetricOfDoPodsWarmValidSnapshot     = MetricOfDoPodsWarmSnapshot.WithLabelValues("valid")
+	MetricOfDoPodsWarmScheduledSnapshot = MetricOfDoPodsWarmSnapshot.WithLabelValues("scheduled")
+
+	MetricOfDoPodsWarmSnapshotOfPodClass = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "autoscale_do_pods_warm_pod_class_snapshot",
+			Help: "The snapshot in function DoPodsWarm, of each pod class",
+		}, []string{"type", "pod_class"},
+	)
 
 	MetricOfWatchPodsLoopEventCnt = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -51,6 +59,7 @@ var (
 
 	MetricOfRpcRequestResumeAndGetTopologyCnt = MetricOfRpcRequestCnt.WithLabelValues("resume_and_get_topology")
 	MetricOfRpcRequestGetTopologyCnt          = MetricOfRpcRequestCnt.WithLabelValues("get_topology")
+	MetricOfRpcRequestTenantConfigCnt         = MetricOfRpcRequestCnt.WithLabelValues("tenant_config")
 
 	MetricOfRpcRequestSeconds = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -63,6 +72,7 @@ var (
 
 	MetricOfRpcRequestResumeAndGetTopologySeconds = MetricOfRpcRequestSeconds.WithLabelValues("resume_and_get_topology")
 	MetricOfRpcRequestGetTopologySeconds          = MetricOfRpcRequestSeconds.WithLabelValues("get_topology")
+	MetricOfRpcRequestTenantConfigSeconds         = MetricOfRpcRequestSeconds.WithLabelValues("tenant_config")
 
 	MetricOfHttpRequestCnt = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -78,6 +88,11 @@ var (
 	MetricOfHttpRequestHttpHandleResumeAndGetTopologyCnt = MetricOfHttpRequestCnt.WithLabelValues("http_handle_resume_and_get_topology")
 	MetricOfHttpRequestHttpHandlePauseForTestCnt         = MetricOfHttpRequestCnt.WithLabelValues("http_handle_pause_for_test")
 	MetricOfHttpRequestDumpMetaCnt                       = MetricOfHttpRequestCnt.WithLabelValues("dump_meta")
+	MetricOfHttpRequestGetDecisionsCnt                   = MetricOfHttpRequestCnt.WithLabelValues("get_decisions")
+	MetricOfHttpRequestGetAuditCnt                       = MetricOfHttpRequestCnt.WithLabelValues("get_audit")
+	MetricOfHttpRequestTenantConfigCnt                   = MetricOfHttpRequestCnt.WithLabelValues("tenant_config")
+	MetricOfHttpRequestGetUpgradeStatusCnt               = MetricOfHttpRequestCnt.WithLabelValues("get_upgrade_status")
+	MetricOfHttpRequestGetAnomalousPodsCnt               = MetricOfHttpRequestCnt.WithLabelValues("get_anomalous_pods")
 
 	MetricOfHttpRequestSeconds = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -94,6 +109,11 @@ var (
 	MetricOfHttpRequestHttpHandleResumeAndGetTopologyMetricSeconds = MetricOfHttpRequestSeconds.WithLabelValues("http_handle_resume_and_get_topology")
 	MetricOfHttpRequestHttpHandlePauseForTestMetricSeconds         = MetricOfHttpRequestSeconds.WithLabelValues("http_handle_pause_for_test")
 	MetricOfHttpRequestDumpMetaSeconds                             = MetricOfHttpRequestSeconds.WithLabelValues("dump_meta")
+	MetricOfHttpRequestGetDecisionsSeconds                         = MetricOfHttpRequestSeconds.WithLabelValues("get_decisions")
+	MetricOfHttpRequestGetAuditSeconds                             = MetricOfHttpRequestSeconds.WithLabelValues("get_audit")
+	MetricOfHttpRequestTenantConfigSeconds                         = MetricOfHttpRequestSeconds.WithLabelValues("tenant_config")
+	MetricOfHttpRequestGetUpgradeStatusSeconds                     = MetricOfHttpRequestSeconds.WithLabelValues("get_upgrade_status")
+	MetricOfHttpRequestGetAnomalousPodsSeconds                     = MetricOfHttpRequestSeconds.WithLabelValues("get_anomalous_pods")
 
 	MetricOfChangeOfPodOnTenantCnt = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -117,6 +137,23 @@ var (
 	MetricOfAddPodIntoTenantSeconds    = MetricOfChangeOfPodOnTenantSeconds.WithLabelValues("add")
 	MetricOfRemovePodFromTenantSeconds = MetricOfChangeOfPodOnTenantSeconds.WithLabelValues("remove")
 
+	MetricOfQuarantinedPodCnt = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "autoscale_quarantined_pod_cnt",
+			Help: "The count of pods quarantined in prewarm pool, since they failed to be assigned or unassigned",
+		},
+		[]string{"pod_class"},
+	)
+
+	MetricOfDrainSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "autoscale_drain_duration_seconds",
+			Help:    "The duration of draining pods before they are unassigned",
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 10),
+		},
+		[]string{"result"},
+	)
+
 	MetricOfChangeOfPodCnt = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "autoscale_changes_of_pod_total",
@@ -174,4 +211,50 @@ var (
 
 	MetricOfClonesetReplicaDelSuccessCnt = MetricOfChangeOfClonesetReplicaCnt.WithLabelValues("delete")
 	MetricOfClonesetReplicaDelFailedCnt  = MetricOfChangeOfClonesetReplicaCnt.WithLabelValues("delete_failed")
+
+	MetricOfPredictiveForecastCores = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "autoscale_predictive_forecast_cores",
+			Help: "The forecasted peak cpu cores of tenant within look-ahead window",
+		},
+		[]string{"tenant"},
+	)
+
+	MetricOfPredictiveForecastErrorRatio = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "autoscale_predictive_forecast_error_ratio",
+			Help: "The relative error between the last due forecast and the actual cpu cores of tenant",
+		},
+		[]string{"tenant"},
+	)
+
+	MetricOfScaleDecisionCnt = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "autoscale_scale_decision_total",
+			Help: "The total number of scale decisions made by analyze loop",
+		},
+		[]string{"action", "dry_run"},
+	)
+
+	MetricOfIsLeader = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "autoscale_is_leader",
+			Help: "Whether this autoscaler replica is the leader, 1 for leader and 0 for follower",
+		},
+	)
+
+	MetricOfForwardToLeaderCnt = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "autoscale_forward_to_leader_total",
+			Help: "The total number of requests forwarded from follower to leader",
+		},
+		[]string{"api"},
+	)
+
+	MetricOfInvalidTenantConfigCnt = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "autoscale_invalid_tenant_config_total",
+			Help: "The total number of invalid tenant configs loaded from tenant store",
+		},
+	)
 )
@@ -0,0 +1,210 @@
+package autoscale
+
+import (
+	"fmt"
+	"time"
+)
+
+// OutstandingDemandExpireSec is how long pods which a request fails to get are kept as outstanding demand of tenant.
+// New pods of pool are assigned to tenants with outstanding demand directly, next request of tenant replaces its demand.
+var OutstandingDemandExpireSec = 300
+
+type outstandingDemand struct {
+	tenant   string
+	isResume bool
+	weight   int
+	seq      uint64
+	cnt      int
+	expireTs int64
+}
+
+// isPrior decides which tenant gets next new pod: resumes first, then higher priority class, then the earlier request
+func (d *outstandingDemand) isPrior(other *outstandingDemand) bool {
+	if d.isResume != other.isResume {
+		return d.isResume
+	}
+	if d.weight != other.weight {
+		return d.weight > other.weight
+	}
+	return d.seq < other.seq
+}
+
+func (p *PrewarmPool) setOutstandingDemandWithoutLock(req *podRequest) {
+	delete(p.outstanding, req.tenant)
+	if req.cnt <= 0 {
+		return
+	}
+	p.outstanding[req.tenant] = &outstandingDemand{
+		tenant:   req.tenant,
+		isResume: req.isResume,
+		weight:   req.weight,
+		seq:      req.seq,
+		cnt:      req.cnt,
+		expireTs: time.Now().Unix() + int64(OutstandingDemandExpireSec),
+	}
+	Logger.Infof("[PrewarmPool][%v]tenant %v has outstanding demand of %v pods", p.PodClass, req.tenant, req.cnt)
+}
+
+// clearOutstandingDemand is called when tenant scales in, pauses, reaches max pods or its target is met, since it doesn't need more pods
+func (p *PrewarmPool) clearOutstandingDemand(tenant string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.outstanding, tenant)
+}
+
+// clearOutstandingDemandOfTenant clears outstanding demand of tenant in the pool of its pod class
+func (c *AutoScaleMeta) clearOutstandingDemandOfTenant(tenant *TenantDesc) {
+	if pool := c.GetPrewarmPool(tenant.GetPodClass()); pool != nil {
+		pool.clearOutstandingDemand(tenant.Name)
+	}
+}
+
+func (p *PrewarmPool) getOutstandingCntOfPodsWithoutLock(now int64) int {
+	ret := 0
+	for tenant, d := range p.outstanding {
+		if d.expireTs <= now {
+			delete(p.outstanding, tenant)
+			continue
+		}
+		ret += d.cnt
+	}
+	return ret
+}
+
+// takeOutstandingTenantWithoutLock decreases outstanding demand of the prior tenant by one, empty if there is no demand
+func (p *PrewarmPool) takeOutstandingTenantWithoutLock(now int64) string {
+	var best *outstandingDemand
+	for tenant, d := range p.outstanding {
+		if d.expireTs <= now {
+			delete(p.outstanding, tenant)
+			continue
+		}
+		if best == nil || d.isPrior(best) {
+			best = d
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	best.cnt--
+	if best.cnt <= 0 {
+		delete(p.outstanding, best.tenant)
+	}
+	return best.tenant
+}
+
+// putNewPod hands new pod to waiting requests or tenants with outstanding demand before it's parked in pool.
+// It returns tenant which the pod should be assigned to, empty if pod is in pool or taken by waiting requests.
+func (p *PrewarmPool) putNewPod(pod *PodDesc) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.decCntOfPendingWithoutLock()
+	if len(p.waitingRequests) == 0 {
+		if tenant := p.takeOutstandingTenantWithoutLock(time.Now().Unix()); tenant != "" {
+			Logger.Infof("[PrewarmPool][%v]new pod %v is handed to tenant %v", p.PodClass, pod.Name, tenant)
+			return tenant
+		}
+	}
+	p.WarmedPods.SetPod(pod.Name, pod)
+	p.dispatchWithoutLock()
+	return ""
+}
+
+// recordDecision records decision made by AutoScaleMeta itself, it's dropped if no DecisionRecorder is set
+func (c *AutoScaleMeta) recordDecision(decision ScaleDecision, success bool, result PodsChangeResult) {
+	if c.decisions == nil {
+		return
+	}
+	decision.setOutcome(success, result)
+	c.decisions.Record(decision)
+}
+
+// assignPodOfOutstandingDemand assigns new pod to tenant in background, pod is returned to pool if tenant doesn't want it any more or has reached its max pods
+func (c *AutoScaleMeta) assignPodOfOutstandingDemand(pool *PrewarmPool, tenant string, pod *PodDesc) {
+	tenantDesc := c.GetTenantDesc(tenant)
+	var err error
+	var tidbStatusAddr, pdAddr string
+	if !c.IsLeader() {
+		err = fmt.Errorf("leadership is lost")
+	} else if tenantDesc == nil {
+		err = fmt.Errorf("no such tenant")
+	} else if state := tenantDesc.GetState(); state != TenantStateResumed {
+		err = fmt.Errorf("tenant is %v", TenantState2String(state))
+	} else if podClass := tenantDesc.GetPodClass(); podClass != pool.PodClass {
+		err = fmt.Errorf("pod class of tenant is changed to %v", podClass)
+	} else if cntOfPods, maxCntOfPod := tenantDesc.GetCntOfPods(), tenantDesc.GetScheduledScaleResult(time.Now()).MaxCntOfPod; cntOfPods >= maxCntOfPod {
+		err = fmt.Errorf("tenant has %v pods, max pods:%v", cntOfPods, maxCntOfPod)
+		pool.clearOutstandingDemand(tenant)
+	} else {
+		tidbStatusAddr, pdAddr, err = tenantDesc.GetAddrsOfTiDBCluster()
+	}
+	if err != nil {
+		Logger.Warnf("[AutoScaleMeta][outstanding][%v]pod %v is returned to pool, err: %v", tenant, pod.Name, err.Error())
+		c.mu.Lock()
+		pool.putWarmedPod("", pod, false)
+		c.mu.Unlock()
+		return
+	}
+
+	pod.isStateChanging.Store(true)
+	defer pod.isStateChanging.Store(false)
+	decision := ScaleDecision{
+		Ts:     time.Now().Unix(),
+		Tenant: tenant,
+		Action: DecisionActionResize,
+		From:   tenantDesc.GetCntOfPods(),
+		DecisionContext: DecisionContext{
+			Rule:   DecisionRuleOutstandingDemand,
+			Reason: fmt.Sprintf("new pod %v of pool %v is handed to outstanding demand", pod.Name, pool.PodClass),
+		},
+	}
+	decision.To = decision.From + 1
+	resp, err := pod.AssignTenantWithAddrs(tenant, tidbStatusAddr, pdAddr)
+	if err != nil {
+		Logger.Errorf("[error][AutoScaleMeta][outstanding][%v] grpc error, pod:%v err: %v", tenant, pod.Name, err.Error())
+		c.recordDecision(decision, false, PodsChangeResult{FailCnt: 1})
+		c.quarantinePod(pod, tenant, "grpc error of AssignTenant: "+err.Error())
+		return
+	} else if resp.HasErr {
+		Logger.Errorf("[error][AutoScaleMeta][outstanding][%v] app api error, pod:%v err: %v", tenant, pod.Name, resp.ErrInfo)
+		c.recordDecision(decision, false, PodsChangeResult{FailCnt: 1})
+		if !resp.IsUnassigning {
+			c.UpdateLocalMetaPodOfTenant(pod.Name, pod, resp.TenantID, resp.StartTime)
+		} else {
+			HandleUnassingCase(c, resp.TenantID, pod, c.tsContainer)
+		}
+		return
+	}
+	c.mu.Lock()
+	var reason string
+	if state := tenantDesc.GetState(); state != TenantStateResumed {
+		reason = fmt.Sprintf("tenant is %v", TenantState2String(state))
+	} else if cntOfPods, maxCntOfPod := tenantDesc.GetCntOfPods(), tenantDesc.GetScheduledScaleResult(time.Now()).MaxCntOfPod; cntOfPods >= maxCntOfPod {
+		reason = fmt.Sprintf("tenant has %v pods, max pods:%v", cntOfPods, maxCntOfPod)
+	}
+	if reason != "" { // paused or scaled out by others while assigning
+		c.mu.Unlock()
+		Logger.Warnf("[AutoScaleMeta][outstanding][%v]%v while assigning pod %v, unassign it", tenant, reason, pod.Name)
+		pool.clearOutstandingDemand(tenant)
+		decision.Reason = fmt.Sprintf("%v; %v", decision.Reason, reason)
+		c.recordDecision(decision, false, PodsChangeResult{FailCnt: 1})
+		unassignResp, err := pod.UnassignTenantWithMockConf(tenant, false)
+		if err != nil || unassignResp.HasErr {
+			c.quarantinePod(pod, tenant, "failed to unassign pod of outstanding demand")
+			return
+		}
+		c.mu.Lock()
+		pool.putWarmedPod(tenant, pod, false)
+		c.mu.Unlock()
+		return
+	}
+	c.tsContainer.ResetMetricsOfPod(pod.Name)
+	tenantDesc.SetPodWithTenantInfo(pod.Name, pod, resp.StartTime)
+	tenantDesc.SetLastResizeTs(time.Now().Unix()) // it's a scale out, so cooldown of stabilizer starts again
+	c.mu.Unlock()
+	Logger.Infof("[AutoScaleMeta][outstanding][%v]pod %v is assigned", tenant, pod.Name)
+	c.recordDecision(decision, true, PodsChangeResult{})
+	if c.drainer != nil {
+		c.drainer.PublishTopology(tenantDesc)
+	}
+}
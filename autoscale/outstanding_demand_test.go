@@ -0,0 +1,60 @@
+package autoscale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutstandingDemand(t *testing.T) {
+	InitTestEnv()
+	pool := NewPrewarmPool(&PodClass{Name: "small", CoresOfPod: 4, PrewarmPoolCap: 4})
+	pool.cntOfPending.Store(5)
+
+	// unmet pods become outstanding demand, next request replaces it
+	_, failCnt := pool.acquireWarmedPods("t1", 3, false, PriorityClassLow, 0)
+	assertEqual(t, failCnt, 3)
+	pool.acquireWarmedPods("t2", 2, false, PriorityClassHigh, 0)
+	pool.acquireWarmedPods("t3", 1, true, PriorityClassLow, 0)
+	pool.acquireWarmedPods("t4", 1, false, "", 0)
+	pool.acquireWarmedPods("t4", 0, false, "", 0)
+	pool.mu.Lock()
+	assertEqual(t, pool.getOutstandingCntOfPodsWithoutLock(time.Now().Unix()), 6)
+	pool.mu.Unlock()
+
+	// new pods go to resume first, then higher priority class
+	assertEqual(t, pool.putNewPod(&PodDesc{Name: "p0"}), "t3")
+	assertEqual(t, pool.putNewPod(&PodDesc{Name: "p1"}), "t2")
+	assertEqual(t, pool.putNewPod(&PodDesc{Name: "p2"}), "t2")
+	assertEqual(t, pool.putNewPod(&PodDesc{Name: "p3"}), "t1")
+	assertEqual(t, pool.cntOfPending.Load(), int32(1))
+	pool.clearOutstandingDemand("t1")
+	assertEqual(t, pool.putNewPod(&PodDesc{Name: "p4"}), "")
+	assertEqual(t, pool.WarmedPods.GetCntOfPods(), 1)
+
+	// demand expires
+	pool.acquireWarmedPods("t5", 2, false, "", 0)
+	pool.mu.Lock()
+	assertEqual(t, pool.getOutstandingCntOfPodsWithoutLock(time.Now().Unix()+int64(OutstandingDemandExpireSec)), 0)
+	pool.mu.Unlock()
+	assertEqual(t, len(pool.outstanding), 0)
+
+	// pod is returned to pool if tenant doesn't want it
+	meta := newMeta4TenantStoreTest(nil)
+	meta.tsContainer = NewTimeSeriesContainer(nil)
+	meta.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	pool = meta.GetPrewarmPool("")
+	meta.assignPodOfOutstandingDemand(pool, "t1", &PodDesc{Name: "p5"})
+	_, ok := pool.WarmedPods.GetPod("p5")
+	assertEqual(t, ok, true)
+
+	// tenant at max pods refuses new pod and drops its demand
+	meta.SetupAutoPauseTenantWithState("t2", 1, 1, TenantStateResumed)
+	tenant := meta.GetTenantDesc("t2")
+	tenant.SetPod("p6", &PodDesc{Name: "p6"})
+	pool.acquireWarmedPods("t2", 1, false, "", 0)
+	meta.assignPodOfOutstandingDemand(pool, "t2", &PodDesc{Name: "p7"})
+	_, ok = pool.WarmedPods.GetPod("p7")
+	assertEqual(t, ok, true)
+	assertEqual(t, len(pool.outstanding), 0)
+
+}
@@ -0,0 +1,158 @@
+package autoscale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Priority class of tenant decides its share of warm pods when tenants compete for them
+const (
+	PriorityClassHigh   = "high"
+	PriorityClassNormal = "normal"
+	PriorityClassLow    = "low"
+)
+
+var (
+	PriorityClassWeights = map[string]int{
+		PriorityClassHigh:   4,
+		PriorityClassNormal: 2,
+		PriorityClassLow:    1,
+	}
+	// PodRequestTimeoutSec is max wait of a request for warm pods when pool is empty, zero means no wait
+	PodRequestTimeoutSec = 30
+)
+
+// NormalizePriorityClassName maps empty name to PriorityClassNormal
+func NormalizePriorityClassName(name string) string {
+	if name == "" {
+		return PriorityClassNormal
+	}
+	return name
+}
+
+func GetWeightOfPriorityClass(name string) int {
+	return PriorityClassWeights[NormalizePriorityClassName(name)]
+}
+
+func getPriorityClassNames() []string {
+	ret := make([]string, 0, len(PriorityClassWeights))
+	for name := range PriorityClassWeights {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func validatePriorityClass(name string) error {
+	if GetWeightOfPriorityClass(name) <= 0 {
+		return fmt.Errorf("must be one of [%v]", strings.Join(getPriorityClassNames(), ", "))
+	}
+	return nil
+}
+
+// podRequest is a request of tenant for warm pods, it waits in pool until it gets enough pods or times out
+type podRequest struct {
+	tenant   string
+	isResume bool
+	weight   int
+	seq      uint64
+	cnt      int // pods still wanted
+	granted  []*PodDesc
+	done     chan struct{}
+}
+
+// isPrior decides which request gets next warm pod: resumes are served before scale-outs,
+// then the request with the least granted pods per weight, then the earliest one
+func (r *podRequest) isPrior(other *podRequest) bool {
+	if r.isResume != other.isResume {
+		return r.isResume
+	}
+	lhs, rhs := len(r.granted)*other.weight, len(other.granted)*r.weight
+	if lhs != rhs {
+		return lhs < rhs
+	}
+	return r.seq < other.seq
+}
+
+// dispatchWithoutLock hands out warm pods to waiting requests one by one, it's called with lock of pool held
+func (p *PrewarmPool) dispatchWithoutLock() {
+	for len(p.waitingRequests) > 0 && p.WarmedPods.GetCntOfPods() > 0 {
+		var best *podRequest
+		for _, req := range p.waitingRequests {
+			if best == nil || req.isPrior(best) {
+				best = req
+			}
+		}
+		podNames := p.WarmedPods.GetPodNames()
+		pod := p.WarmedPods.RemovePod(podNames[0])
+		if pod == nil {
+			Logger.Warnf("[PrewarmPool][%v]dispatch, p.WarmedPods.RemovePod fail, return nil!", p.PodClass)
+			return
+		}
+		best.granted = append(best.granted, pod)
+		best.cnt--
+		if best.cnt == 0 {
+			p.removeWaitingRequestWithoutLock(best)
+			close(best.done)
+		}
+	}
+}
+
+func (p *PrewarmPool) removeWaitingRequestWithoutLock(req *podRequest) {
+	for i, v := range p.waitingRequests {
+		if v == req {
+			p.waitingRequests = append(p.waitingRequests[:i], p.waitingRequests[i+1:]...)
+			return
+		}
+	}
+}
+
+// getWaitingCntOfPodsWithoutLock is pods wanted by waiting requests, pool provisions them in addition to SoftLimit
+func (p *PrewarmPool) getWaitingCntOfPodsWithoutLock() int {
+	ret := 0
+	for _, req := range p.waitingRequests {
+		ret += req.cnt
+	}
+	return ret
+}
+
+// acquireWarmedPods takes cnt warm pods for tenant, it waits at most timeout if pool has not enough pods.
+// Pods are shared among waiting requests by weight of priority class, and resumes are served before scale-outs.
+// It returns pods and count of pods it fails to get.
+func (p *PrewarmPool) acquireWarmedPods(tenant string, cnt int, isResume bool, priorityClass string, timeout time.Duration) ([]*PodDesc, int) {
+	p.mu.Lock()
+	p.demandHistory.record(time.Now(), cnt)
+	p.requestSeq++
+	req := &podRequest{
+		tenant:   tenant,
+		isResume: isResume,
+		weight:   GetWeightOfPriorityClass(priorityClass),
+		seq:      p.requestSeq,
+		cnt:      cnt,
+		granted:  make([]*PodDesc, 0, cnt),
+		done:     make(chan struct{}),
+	}
+	if cnt > 0 {
+		p.waitingRequests = append(p.waitingRequests, req)
+		p.dispatchWithoutLock()
+	}
+	if req.cnt > 0 && timeout > 0 {
+		Logger.Infof("[PrewarmPool][%v]tenant %v waits for %v pods, isResume:%v", p.PodClass, tenant, req.cnt, isResume)
+		p.mu.Unlock()
+		select {
+		case <-req.done:
+		case <-time.After(timeout):
+		}
+		p.mu.Lock()
+	}
+	p.removeWaitingRequestWithoutLock(req)
+	// pods which request fails to get are provisioned and assigned later, so they are not counted as failCnt of tenant
+	p.setOutstandingDemandWithoutLock(req)
+	p.mu.Unlock()
+	if req.cnt > 0 {
+		Logger.Warnf("[PrewarmPool][%v]tenant %v fails to get %v of %v pods, isResume:%v", p.PodClass, tenant, req.cnt, cnt, isResume)
+	}
+	return req.granted, req.cnt
+}
@@ -0,0 +1,74 @@
+package autoscale
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type acquireResult4Test struct {
+	tenant  string
+	pods    []*PodDesc
+	failCnt int
+}
+
+func TestAcquireWarmedPods(t *testing.T) {
+	InitTestEnv()
+	assertEqual(t, validatePriorityClass(""), nil)
+	assertEqual(t, validatePriorityClass(PriorityClassLow), nil)
+	assertEqual(t, validatePriorityClass("urgent") != nil, true)
+	assertEqual(t, GetWeightOfPriorityClass(""), PriorityClassWeights[PriorityClassNormal])
+
+	pool := NewPrewarmPool(&PodClass{Name: "small", CoresOfPod: 4, PrewarmPoolCap: 4})
+	pool.putWarmedPod("", &PodDesc{Name: "p0"}, false)
+	// pods are taken at once if pool has enough pods
+	pods, failCnt := pool.acquireWarmedPods("t0", 1, false, "", time.Hour)
+	assertEqual(t, len(pods), 1)
+	assertEqual(t, failCnt, 0)
+	pods, failCnt = pool.acquireWarmedPods("t0", 1, false, "", 0)
+	assertEqual(t, len(pods), 0)
+	assertEqual(t, failCnt, 1)
+
+	// requests wait when pool is empty
+	resultChan := make(chan acquireResult4Test, 3)
+	acquire := func(tenant string, cnt int, isResume bool, priorityClass string) {
+		go func() {
+			pods, failCnt := pool.acquireWarmedPods(tenant, cnt, isResume, priorityClass, time.Second)
+			resultChan <- acquireResult4Test{tenant: tenant, pods: pods, failCnt: failCnt}
+		}()
+		for {
+			pool.mu.Lock()
+			waitingCnt := len(pool.waitingRequests)
+			isWaiting := waitingCnt > 0 && pool.waitingRequests[waitingCnt-1].tenant == tenant
+			pool.mu.Unlock()
+			if isWaiting {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	acquire("t1", 4, false, PriorityClassLow)
+	acquire("t2", 4, false, PriorityClassHigh)
+	acquire("t3", 2, true, PriorityClassNormal)
+	pool.mu.Lock()
+	assertEqual(t, pool.getWaitingCntOfPodsWithoutLock(), 10)
+	pool.mu.Unlock()
+
+	// resume is served first, then scale-outs share pods by weight
+	for i := 1; i <= 6; i++ {
+		pool.putWarmedPod("", &PodDesc{Name: fmt.Sprintf("p%v", i)}, true)
+	}
+	results := make(map[string]acquireResult4Test)
+	for i := 0; i < 3; i++ {
+		result := <-resultChan
+		results[result.tenant] = result
+	}
+	assertEqual(t, len(results["t3"].pods), 2)
+	assertEqual(t, results["t3"].failCnt, 0)
+	assertEqual(t, len(results["t1"].pods), 1)
+	assertEqual(t, results["t1"].failCnt, 3)
+	assertEqual(t, len(results["t2"].pods), 3)
+	assertEqual(t, results["t2"].failCnt, 1)
+	assertEqual(t, len(pool.waitingRequests), 0)
+	assertEqual(t, pool.outstanding["t1"].cnt, 3)
+}
@@ -0,0 +1,143 @@
+package autoscale
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const DefaultPodClassName = "default"
+
+// PodClassesConf declares compute pod classes besides the default one, format: "name:cores[:warmPoolCap],..."
+// e.g. "small:4:2,large:16:1". Each class has its own cloneset and prewarm pool, tenant picks one by ConfigOfComputeCluster.PodClass
+var PodClassesConf = ""
+
+// PodClass is a kind of compute pod, default class has DefaultCoreOfPod cores and PrewarmPoolCap warmed pods
+type PodClass struct {
+	Name           string
+	CoresOfPod     int
+	PrewarmPoolCap int
+}
+
+var (
+	muOfPodClasses  sync.RWMutex
+	extraPodClasses = make(map[string]*PodClass)
+)
+
+func (p *PodClass) Dump() string {
+	return fmt.Sprintf("PodClass{Name:%v, CoresOfPod:%v, PrewarmPoolCap:%v}", p.Name, p.CoresOfPod, p.PrewarmPoolCap)
+}
+
+// GetCloneSetName returns cloneset of class, default class keeps the name of the original readnode cloneset
+func (p *PodClass) GetCloneSetName() string {
+	return GetCloneSetNameOfPodClass(p.Name)
+}
+
+// ParsePodClasses parses PodClassesConf, warm pool cap is PrewarmPoolCap if it's omitted
+func ParsePodClasses(conf string) (map[string]*PodClass, error) {
+	ret := make(map[string]*PodClass)
+	for _, item := range strings.Split(conf, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		fields := strings.Split(item, ":")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("invalid pod class %q, expect name:cores[:warmPoolCap]", item)
+		}
+		name := fields[0]
+		if name == "" || name == DefaultPodClassName {
+			return nil, fmt.Errorf("invalid name of pod class %q", item)
+		}
+		if _, ok := ret[name]; ok {
+			return nil, fmt.Errorf("duplicated pod class %v", name)
+		}
+		cores, err := strconv.Atoi(fields[1])
+		if err != nil || cores <= 0 {
+			return nil, fmt.Errorf("invalid cores of pod class %q", item)
+		}
+		poolCap := PrewarmPoolCap
+		if len(fields) == 3 {
+			poolCap, err = strconv.Atoi(fields[2])
+			if err != nil || poolCap < 0 {
+				return nil, fmt.Errorf("invalid warm pool cap of pod class %q", item)
+			}
+		}
+		ret[name] = &PodClass{Name: name, CoresOfPod: cores, PrewarmPoolCap: poolCap}
+	}
+	return ret, nil
+}
+
+// InitPodClasses replaces extra pod classes, it should be called before ClusterManager is created
+func InitPodClasses(conf string) error {
+	classes, err := ParsePodClasses(conf)
+	if err != nil {
+		return err
+	}
+	muOfPodClasses.Lock()
+	defer muOfPodClasses.Unlock()
+	extraPodClasses = classes
+	return nil
+}
+
+// NormalizePodClassName maps empty name to DefaultPodClassName
+func NormalizePodClassName(name string) string {
+	if name == "" {
+		return DefaultPodClassName
+	}
+	return name
+}
+
+// GetPodClass returns nil if class is unknown
+func GetPodClass(name string) *PodClass {
+	name = NormalizePodClassName(name)
+	if name == DefaultPodClassName {
+		// built on demand, since DefaultCoreOfPod and PrewarmPoolCap are set by flags
+		return &PodClass{Name: DefaultPodClassName, CoresOfPod: DefaultCoreOfPod, PrewarmPoolCap: PrewarmPoolCap}
+	}
+	muOfPodClasses.RLock()
+	defer muOfPodClasses.RUnlock()
+	return extraPodClasses[name]
+}
+
+// GetPodClassNames returns default class first, then others in order of name
+func GetPodClassNames() []string {
+	muOfPodClasses.RLock()
+	ret := make([]string, 0, len(extraPodClasses)+1)
+	for name := range extraPodClasses {
+		ret = append(ret, name)
+	}
+	muOfPodClasses.RUnlock()
+	sort.Strings(ret)
+	return append([]string{DefaultPodClassName}, ret...)
+}
+
+func GetCloneSetNameOfPodClass(name string) string {
+	name = NormalizePodClassName(name)
+	if name == DefaultPodClassName {
+		return ReadNodeCloneSetName
+	}
+	return ReadNodeCloneSetName + "-" + name
+}
+
+// GetPodClassOfCloneSet returns empty string if cloneset doesn't belong to any class
+func GetPodClassOfCloneSet(cloneSetName string) string {
+	for _, name := range GetPodClassNames() {
+		if GetCloneSetNameOfPodClass(name) == cloneSetName {
+			return name
+		}
+	}
+	return ""
+}
+
+// GetLabelSelectorOfComputePods selects pods of all classes
+func GetLabelSelectorOfComputePods() string {
+	names := GetPodClassNames()
+	cloneSetNames := make([]string, 0, len(names))
+	for _, name := range names {
+		cloneSetNames = append(cloneSetNames, GetCloneSetNameOfPodClass(name))
+	}
+	return fmt.Sprintf("app in (%v)", strings.Join(cloneSetNames, ","))
+}
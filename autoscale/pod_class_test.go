@@ -0,0 +1,113 @@
+package autoscale
+
+import (
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPod4PodClassTest(name string, ip string, cloneSetName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"app": cloneSetName}},
+		Status:     v1.PodStatus{PodIP: ip},
+	}
+}
+
+func TestParsePodClasses(t *testing.T) {
+	InitTestEnv()
+	classes, err := ParsePodClasses(" small:4:2, large:16 ,")
+	assertEqual(t, err, nil)
+	assertEqual(t, len(classes), 2)
+	assertEqual(t, classes["small"].CoresOfPod, 4)
+	assertEqual(t, classes["small"].PrewarmPoolCap, 2)
+	assertEqual(t, classes["large"].CoresOfPod, 16)
+	assertEqual(t, classes["large"].PrewarmPoolCap, PrewarmPoolCap)
+
+	for _, conf := range []string{"small", "small:0", "small:x", "small:4:-1", "default:8", ":8", "small:4,small:8", "small:4:1:1"} {
+		_, err = ParsePodClasses(conf)
+		assertEqual(t, err != nil, true)
+	}
+}
+
+func TestPodClassOfTenant(t *testing.T) {
+	InitTestEnv()
+	assertEqual(t, InitPodClasses("small:4:2,large:16:1"), nil)
+	defer InitPodClasses("")
+
+	assertEqual(t, GetCloneSetNameOfPodClass(""), ReadNodeCloneSetName)
+	assertEqual(t, GetCloneSetNameOfPodClass("small"), ReadNodeCloneSetName+"-small")
+	assertEqual(t, GetPodClassOfCloneSet(ReadNodeCloneSetName+"-large"), "large")
+	assertEqual(t, GetPodClassOfCloneSet("other"), "")
+	assertEqual(t, GetLabelSelectorOfComputePods(), "app in (readnode,readnode-large,readnode-small)")
+
+	// cores of pod follow class of tenant
+	conf := NewDefaultConfigOfComputeCluster("t1", 1, 4)
+	conf.PodClass = "small"
+	conf.MinCores, conf.InitCores, conf.MaxCores = 4, 8, 16
+	assertEqual(t, conf.Validate(), nil)
+	assertEqual(t, conf.GetInitCntOfPod(), 2)
+	conf.MaxCores = 18
+	assertEqual(t, conf.Validate().(FieldErrors)[0].Field, "MaxCores")
+	conf.MaxCores = 16
+	conf.PodClass = "huge"
+	assertEqual(t, conf.Validate().(FieldErrors)[0].Field, "PodClass")
+	conf.PodClass = "small"
+
+	meta := newMeta4TenantStoreTest(nil)
+	assertEqual(t, len(meta.GetPrewarmPools()), 3)
+	assertEqual(t, meta.GetPrewarmPool("large").SoftLimit, 1)
+	assertEqual(t, meta.CreateTenantConfig("t1", conf), nil)
+	tenant := meta.GetTenantDesc("t1")
+	assertEqual(t, tenant.GetPodClass(), "small")
+	assertEqual(t, tenant.GetMinCntOfPod(), 1)
+	assertEqual(t, tenant.GetMaxCntOfPod(), 4)
+
+	// 1 pod of 4 cores at full load, needs 2 pods to be under 80%
+	tenant.SetState(TenantStateResumed)
+	tenant.SetPod("p1", &PodDesc{Name: "p1", Class: "small"})
+	target, _ := ComputeBestPodsInRuleOfCompute(tenant, 4, 0.6, 0.8)
+	assertEqual(t, target, 2)
+
+	// class can't be changed while tenant has pods
+	conf.PodClass = "large"
+	conf.MinCores, conf.InitCores, conf.MaxCores = 16, 16, 32
+	assertEqual(t, meta.UpdateTenantConfig("t1", conf).(FieldErrors)[0].Field, "PodClass")
+	tenant.RemovePod("p1")
+	assertEqual(t, meta.UpdateTenantConfig("t1", conf), nil)
+
+	// pods join pool of their class
+	meta.UpdatePod(newPod4PodClassTest("readnode-small-a", "10.0.0.1", ReadNodeCloneSetName+"-small"))
+	meta.UpdatePod(newPod4PodClassTest("readnode-b", "10.0.0.2", ReadNodeCloneSetName))
+	assertEqual(t, meta.GetPrewarmPool("small").WarmedPods.GetCntOfPods(), 1)
+	assertEqual(t, meta.GetPrewarmPool("").WarmedPods.GetCntOfPods(), 1)
+	assertEqual(t, meta.GetPrewarmPool("large").WarmedPods.GetCntOfPods(), 0)
+	pods, failCnt := meta.GetPrewarmPool("small").getWarmedPods("t1", 2)
+	assertEqual(t, len(pods), 1)
+	assertEqual(t, failCnt, 1)
+	assertEqual(t, pods[0].Name, "readnode-small-a")
+}
+
+func TestResourceRequirementsOfPodClass(t *testing.T) {
+	InitTestEnv()
+	oldCpuRequest, oldCpuLimit, oldMemLimit, oldStorageLimit := PodCpuRequestPerCore, PodCpuLimitPerCore, PodMemLimitPerCore, PodEphemeralStorageLimitPerCore
+	defer func() {
+		PodCpuRequestPerCore, PodCpuLimitPerCore, PodMemLimitPerCore, PodEphemeralStorageLimitPerCore = oldCpuRequest, oldCpuLimit, oldMemLimit, oldStorageLimit
+	}()
+	assertEqual(t, ValidatePodResourcesConf(), nil)
+	podClass := &PodClass{Name: "small", CoresOfPod: 4}
+	resources := podClass.GetResourceRequirements()
+	assertEqual(t, DumpResourceRequirements(resources), fmt.Sprintf("{Requests:{cpu=4,ephemeral-storage=4Gi,memory=%vGi}, Limits:{}}", 4*DefaultMemGiBPerCore))
+	assertEqual(t, resources.Limits == nil, true)
+
+	PodCpuRequestPerCore, PodCpuLimitPerCore, PodMemLimitPerCore, PodEphemeralStorageLimitPerCore = "900m", "1", "0", "2Gi"
+	assertEqual(t, ValidatePodResourcesConf(), nil)
+	resources = podClass.GetResourceRequirements()
+	assertEqual(t, DumpResourceRequirements(resources), fmt.Sprintf("{Requests:{cpu=3600m,ephemeral-storage=4Gi,memory=%vGi}, Limits:{cpu=4,ephemeral-storage=8Gi}}", 4*DefaultMemGiBPerCore))
+
+	PodCpuRequestPerCore = "2"
+	assertEqual(t, ValidatePodResourcesConf() != nil, true)
+	PodCpuRequestPerCore = "x"
+	assertEqual(t, ValidatePodResourcesConf() != nil, true)
+}
@@ -0,0 +1,116 @@
+package autoscale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Requests and limits of supervisor container per core of pod, they are multiplied by cores of pod class.
+// Empty value means the default: 1 cpu, DefaultMemGiBPerCore GiB memory, 1GiB ephemeral storage request and no limit,
+// so that pods are Burstable and not throttled at cores of pod. "0" means the request or limit is not set.
+var (
+	PodCpuRequestPerCore              = ""
+	PodCpuLimitPerCore                = ""
+	PodMemRequestPerCore              = ""
+	PodMemLimitPerCore                = ""
+	PodEphemeralStorageRequestPerCore = ""
+	PodEphemeralStorageLimitPerCore   = ""
+)
+
+type podResourceConf struct {
+	resourceName   v1.ResourceName
+	requestPerCore string
+	limitPerCore   string
+	defaultRequest string
+	defaultLimit   string
+}
+
+func getPodResourceConfs() []podResourceConf {
+	memPerCore := fmt.Sprintf("%vGi", DefaultMemGiBPerCore)
+	return []podResourceConf{
+		{v1.ResourceCPU, PodCpuRequestPerCore, PodCpuLimitPerCore, "1", "0"},
+		{v1.ResourceMemory, PodMemRequestPerCore, PodMemLimitPerCore, memPerCore, "0"},
+		{v1.ResourceEphemeralStorage, PodEphemeralStorageRequestPerCore, PodEphemeralStorageLimitPerCore, "1Gi", "0"},
+	}
+}
+
+func parseQuantityPerCore(conf string, defaultConf string) (resource.Quantity, error) {
+	if conf == "" {
+		conf = defaultConf
+	}
+	q, err := resource.ParseQuantity(conf)
+	if err != nil {
+		return q, err
+	}
+	if q.Sign() < 0 {
+		return q, fmt.Errorf("quantity %v is negative", conf)
+	}
+	return q, nil
+}
+
+// ValidatePodResourcesConf checks resources per core, it should be called before ClusterManager is created
+func ValidatePodResourcesConf() error {
+	for _, conf := range getPodResourceConfs() {
+		request, err := parseQuantityPerCore(conf.requestPerCore, conf.defaultRequest)
+		if err != nil {
+			return fmt.Errorf("invalid request per core of %v: %v", conf.resourceName, err)
+		}
+		limit, err := parseQuantityPerCore(conf.limitPerCore, conf.defaultLimit)
+		if err != nil {
+			return fmt.Errorf("invalid limit per core of %v: %v", conf.resourceName, err)
+		}
+		if !limit.IsZero() && limit.Cmp(request) < 0 {
+			return fmt.Errorf("limit per core of %v is less than request: %v < %v", conf.resourceName, limit.String(), request.String())
+		}
+	}
+	return nil
+}
+
+func addQuantityOfPod(list v1.ResourceList, name v1.ResourceName, conf string, defaultConf string, cores int) {
+	q, err := parseQuantityPerCore(conf, defaultConf)
+	if err != nil {
+		Logger.Errorf("[PodClass]invalid quantity per core of %v: %v, use default %v", name, err.Error(), defaultConf)
+		q = resource.MustParse(defaultConf)
+	}
+	if q.IsZero() {
+		return
+	}
+	list[name] = *resource.NewMilliQuantity(q.MilliValue()*int64(cores), q.Format)
+}
+
+// GetResourceRequirements is resources of supervisor container, so that pods of different classes land on nodes with enough capacity
+func (p *PodClass) GetResourceRequirements() v1.ResourceRequirements {
+	ret := v1.ResourceRequirements{
+		Requests: v1.ResourceList{},
+		Limits:   v1.ResourceList{},
+	}
+	for _, conf := range getPodResourceConfs() {
+		addQuantityOfPod(ret.Requests, conf.resourceName, conf.requestPerCore, conf.defaultRequest, p.CoresOfPod)
+		addQuantityOfPod(ret.Limits, conf.resourceName, conf.limitPerCore, conf.defaultLimit, p.CoresOfPod)
+	}
+	// empty list is omitted by apiserver, keep it nil so that it's not a diff of cloneset
+	if len(ret.Requests) == 0 {
+		ret.Requests = nil
+	}
+	if len(ret.Limits) == 0 {
+		ret.Limits = nil
+	}
+	return ret
+}
+
+func dumpResourceList(list v1.ResourceList) string {
+	items := make([]string, 0, len(list))
+	for name, q := range list {
+		items = append(items, fmt.Sprintf("%v=%v", name, q.String()))
+	}
+	sort.Strings(items)
+	return "{" + strings.Join(items, ",") + "}"
+}
+
+func DumpResourceRequirements(r v1.ResourceRequirements) string {
+	return fmt.Sprintf("{Requests:%v, Limits:%v}", dumpResourceList(r.Requests), dumpResourceList(r.Limits))
+}
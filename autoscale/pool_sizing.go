@@ -0,0 +1,140 @@
+package autoscale
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// In adaptive sizing mode, SoftLimit of pool follows pods taken by resumes and scale-outs, instead of PrewarmPoolCap.
+// Demand is summed in buckets of AdaptivePoolWindowMin minutes and kept for AdaptivePoolHistoryDays days,
+// buckets are weighted by how close their time of day is to now, so that pool grows before the daily peak.
+// History is kept in memory, so PrewarmPoolCap is used until it covers one day after restart or change of leader.
+var (
+	AdaptivePoolSizingEnabled = false
+	AdaptivePoolWindowMin     = 30
+	AdaptivePoolHistoryDays   = 7
+	AdaptivePoolPercentile    = 90.0
+	AdaptivePoolFloor         = 1
+	AdaptivePoolCeiling       = 16
+)
+
+const (
+	secondsOfDay               = 24 * 3600
+	minWeightOfPodDemandBucket = 0.1 // weight of bucket which is 12 hours away from now in time of day
+)
+
+// ValidateAdaptivePoolSizingConf checks flags of adaptive sizing, it should be called before ClusterManager is created
+func ValidateAdaptivePoolSizingConf() error {
+	if AdaptivePoolWindowMin <= 0 {
+		return fmt.Errorf("window of adaptive pool sizing should be positive: %v", AdaptivePoolWindowMin)
+	}
+	if AdaptivePoolHistoryDays <= 0 {
+		return fmt.Errorf("history days of adaptive pool sizing should be positive: %v", AdaptivePoolHistoryDays)
+	}
+	if AdaptivePoolPercentile <= 0 || AdaptivePoolPercentile > 100 {
+		return fmt.Errorf("percentile of adaptive pool sizing should be in (0, 100]: %v", AdaptivePoolPercentile)
+	}
+	if AdaptivePoolFloor < 0 || AdaptivePoolCeiling < AdaptivePoolFloor {
+		return fmt.Errorf("invalid floor and ceiling of adaptive pool sizing: %v, %v", AdaptivePoolFloor, AdaptivePoolCeiling)
+	}
+	return nil
+}
+
+// PodDemandHistory sums pods taken from pool in each bucket, it's guarded by mutex of pool and starts over after restart
+type PodDemandHistory struct {
+	bucketSec   int64
+	historySec  int64
+	startBucket int64 // bucket when history starts, earlier buckets are unknown rather than zero
+	buckets     map[int64]int
+}
+
+func NewPodDemandHistory(now time.Time) *PodDemandHistory {
+	bucketSec := int64(AdaptivePoolWindowMin) * 60
+	return &PodDemandHistory{
+		bucketSec:   bucketSec,
+		historySec:  int64(AdaptivePoolHistoryDays) * secondsOfDay,
+		startBucket: now.Unix() / bucketSec,
+		buckets:     make(map[int64]int),
+	}
+}
+
+// coversOneDay is true if history has at least one day of buckets
+func (h *PodDemandHistory) coversOneDay(now time.Time) bool {
+	return (now.Unix()/h.bucketSec-h.startBucket)*h.bucketSec >= secondsOfDay
+}
+
+func (h *PodDemandHistory) record(now time.Time, cnt int) {
+	if cnt <= 0 {
+		return
+	}
+	h.buckets[now.Unix()/h.bucketSec] += cnt
+	h.gc(now)
+}
+
+func (h *PodDemandHistory) gc(now time.Time) {
+	oldest := (now.Unix() - h.historySec) / h.bucketSec
+	for bucket := range h.buckets {
+		if bucket <= oldest {
+			delete(h.buckets, bucket)
+		}
+	}
+}
+
+// getWeightOfBucket is 1 for the same time of day as the upcoming window, and decreases linearly to minWeightOfPodDemandBucket for 12 hours away
+func getWeightOfBucket(bucketStartTs int64, nowTs int64) float64 {
+	dist := (bucketStartTs - nowTs) % secondsOfDay
+	if dist < 0 {
+		dist += secondsOfDay
+	}
+	if dist > secondsOfDay/2 {
+		dist = secondsOfDay - dist
+	}
+	return minWeightOfPodDemandBucket + (1-minWeightOfPodDemandBucket)*(1-float64(dist)/float64(secondsOfDay/2))
+}
+
+// getDemand is weighted percentile of demand of buckets in history, buckets without demand count as zero
+func (h *PodDemandHistory) getDemand(now time.Time, percentile float64) int {
+	nowTs := now.Unix()
+	curBucket := nowTs / h.bucketSec
+	firstBucket := MaxInt(int(h.startBucket), int((nowTs-h.historySec)/h.bucketSec)+1)
+	type sample struct {
+		demand int
+		weight float64
+	}
+	samples := make([]sample, 0, int(curBucket)-firstBucket+1)
+	totalWeight := 0.0
+	for bucket := int64(firstBucket); bucket <= curBucket; bucket++ {
+		weight := getWeightOfBucket(bucket*h.bucketSec, nowTs)
+		samples = append(samples, sample{demand: h.buckets[bucket], weight: weight})
+		totalWeight += weight
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].demand < samples[j].demand })
+	target := totalWeight * percentile / 100
+	acc := 0.0
+	for _, s := range samples {
+		acc += s.weight
+		if acc >= target-1e-9 {
+			return s.demand
+		}
+	}
+	return samples[len(samples)-1].demand
+}
+
+// getAdaptiveSoftLimit sizes pool to cover demand of AdaptivePoolPercentile, within floor and ceiling
+func (p *PrewarmPool) getAdaptiveSoftLimit(now time.Time) int {
+	if !p.demandHistory.coversOneDay(now) {
+		return p.prewarmPoolCap
+	}
+	demand := p.demandHistory.getDemand(now, AdaptivePoolPercentile)
+	return MinInt(MaxInt(demand, AdaptivePoolFloor), AdaptivePoolCeiling)
+}
+
+// resetPodDemandHistories starts history over when leadership is taken, since follower takes no pods from pools
+func (c *AutoScaleMeta) resetPodDemandHistories(now time.Time) {
+	for _, pool := range c.GetPrewarmPools() {
+		pool.mu.Lock()
+		pool.demandHistory = NewPodDemandHistory(now)
+		pool.mu.Unlock()
+	}
+}
@@ -0,0 +1,57 @@
+package autoscale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptivePoolSizing(t *testing.T) {
+	InitTestEnv()
+	oldWindow, oldFloor, oldCeiling, oldPercentile := AdaptivePoolWindowMin, AdaptivePoolFloor, AdaptivePoolCeiling, AdaptivePoolPercentile
+	defer func() {
+		AdaptivePoolWindowMin, AdaptivePoolFloor, AdaptivePoolCeiling, AdaptivePoolPercentile = oldWindow, oldFloor, oldCeiling, oldPercentile
+	}()
+	assertEqual(t, ValidateAdaptivePoolSizingConf(), nil)
+	AdaptivePoolPercentile = 0
+	assertEqual(t, ValidateAdaptivePoolSizingConf() != nil, true)
+	AdaptivePoolPercentile = 95
+	AdaptivePoolCeiling = AdaptivePoolFloor - 1
+	assertEqual(t, ValidateAdaptivePoolSizingConf() != nil, true)
+	AdaptivePoolFloor, AdaptivePoolCeiling = 2, 8
+
+	nowTs := time.Date(2026, 1, 4, 8, 30, 0, 0, time.UTC).Unix()
+	assertEqual(t, getWeightOfBucket(nowTs-secondsOfDay, nowTs), 1.0)
+	assertEqual(t, getWeightOfBucket(nowTs+secondsOfDay/2, nowTs), minWeightOfPodDemandBucket)
+	assertEqual(t, getWeightOfBucket(nowTs-3*3600, nowTs), getWeightOfBucket(nowTs+3*3600, nowTs))
+
+	// 10 pods are taken at 9:00 every day, 1 pod in other hours
+	AdaptivePoolWindowMin = 60
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	pool := NewPrewarmPool(&PodClass{Name: "small", CoresOfPod: 4, PrewarmPoolCap: 4})
+	pool.demandHistory = NewPodDemandHistory(start)
+	// cap of pod class is kept until history covers one day
+	assertEqual(t, pool.getAdaptiveSoftLimit(start.Add(23*time.Hour)), 4)
+	for day := 0; day < 3; day++ {
+		for hour := 0; hour < 24; hour++ {
+			cnt := 1
+			if hour == 9 {
+				cnt = 10
+			}
+			pool.demandHistory.record(start.Add(time.Duration(day*24+hour)*time.Hour), cnt)
+		}
+	}
+	assertEqual(t, pool.demandHistory.getDemand(time.Date(2026, 1, 4, 8, 30, 0, 0, time.UTC), 95), 10)
+	assertEqual(t, pool.demandHistory.getDemand(time.Date(2026, 1, 4, 20, 30, 0, 0, time.UTC), 95), 1)
+	// pool is within floor and ceiling
+	assertEqual(t, pool.getAdaptiveSoftLimit(time.Date(2026, 1, 4, 8, 30, 0, 0, time.UTC)), 8)
+	assertEqual(t, pool.getAdaptiveSoftLimit(time.Date(2026, 1, 4, 20, 30, 0, 0, time.UTC)), 2)
+	// buckets out of history are dropped
+	pool.demandHistory.record(start.Add(10*24*time.Hour), 1)
+	assertEqual(t, len(pool.demandHistory.buckets), 1)
+
+	// unmet demand of tenants is recorded too
+	pool = NewPrewarmPool(&PodClass{Name: "small", CoresOfPod: 4, PrewarmPoolCap: 4})
+	pool.getWarmedPods("t1", 3)
+	pool.getWarmedPods("", 2)
+	assertEqual(t, pool.demandHistory.getDemand(time.Now(), 100), 3)
+}
@@ -0,0 +1,235 @@
+package autoscale
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	PredictiveScaleIntervalSec = 300 // how often forecasts are refreshed from prometheus
+	PredictiveMaxTrendRatio    = 2.0 // trend adjustment is clamped in [1/PredictiveMaxTrendRatio, PredictiveMaxTrendRatio]
+)
+
+const (
+	DefaultPredictiveTrendWindowSeconds = 1800
+	PredictiveQueryStepSeconds          = 60
+)
+
+// PredictiveScaleRule pre-scales a tenant before the expected peak, the peak is forecasted from the same time of last season
+type PredictiveScaleRule struct {
+	SeasonSeconds      int // 86400 for daily pattern, 604800 for weekly pattern
+	LookAheadSeconds   int // how far the forecast looks ahead, pods are raised to the forecasted peak within it
+	TrendWindowSeconds int // window to compare recent load with last season's, zero means DefaultPredictiveTrendWindowSeconds
+}
+
+func (r *PredictiveScaleRule) Dump() string {
+	if r == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("PredictiveScaleRule{SeasonSec:%v, LookAheadSec:%v, TrendWindowSec:%v}", r.SeasonSeconds, r.LookAheadSeconds, r.TrendWindowSeconds)
+}
+
+func (r *PredictiveScaleRule) GetTrendWindowSeconds() int {
+	if r.TrendWindowSeconds <= 0 {
+		return DefaultPredictiveTrendWindowSeconds
+	}
+	return r.TrendWindowSeconds
+}
+
+func avgOfTimeValPairsInRange(series []TimeValPair, begin int64, end int64) (float64, int) {
+	sum := 0.0
+	cnt := 0
+	for _, p := range series {
+		if p.time >= begin && p.time <= end && !math.IsNaN(p.value) {
+			sum += p.value
+			cnt++
+		}
+	}
+	if cnt == 0 {
+		return 0, 0
+	}
+	return sum / float64(cnt), cnt
+}
+
+func maxOfTimeValPairsInRange(series []TimeValPair, begin int64, end int64) (float64, int) {
+	ret := 0.0
+	cnt := 0
+	for _, p := range series {
+		if p.time >= begin && p.time <= end && !math.IsNaN(p.value) {
+			if cnt == 0 || p.value > ret {
+				ret = p.value
+			}
+			cnt++
+		}
+	}
+	return ret, cnt
+}
+
+// ForecastSeasonalPeak forecasts the peak of (now, now+LookAhead] by the peak of the same range of last season,
+// adjusted by the trend between recent load and the load of the same time of last season.
+// history should cover [now-season-trendWindow, now-season+lookAhead], recent should cover [now-trendWindow, now].
+func ForecastSeasonalPeak(rule *PredictiveScaleRule, history []TimeValPair, recent []TimeValPair, now int64) (float64, bool) {
+	season := int64(rule.SeasonSeconds)
+	trendWindow := int64(rule.GetTrendWindowSeconds())
+	lastSeasonNow := now - season
+	peak, cnt := maxOfTimeValPairsInRange(history, lastSeasonNow, lastSeasonNow+int64(rule.LookAheadSeconds))
+	if cnt == 0 {
+		return 0, false
+	}
+	trend := 1.0
+	baseline, baselineCnt := avgOfTimeValPairsInRange(history, lastSeasonNow-trendWindow, lastSeasonNow)
+	current, currentCnt := avgOfTimeValPairsInRange(recent, now-trendWindow, now)
+	if baselineCnt > 0 && currentCnt > 0 && baseline > 0 {
+		trend = current / baseline
+		trend = math.Max(trend, 1/PredictiveMaxTrendRatio)
+		trend = math.Min(trend, PredictiveMaxTrendRatio)
+	}
+	return peak * trend, true
+}
+
+// ComputeTargetCntOfPodsByForecast returns how many pods are needed to keep the forecasted cores under upper threshold of cpu
+func ComputeTargetCntOfPodsByForecast(forecastCores float64, coresOfPod int, upperLimit float64, minCntOfPod int, maxCntOfPod int) int {
+	if upperLimit <= 0 {
+		upperLimit = DefaultUpperLimit
+	}
+	ret := int(math.Ceil(forecastCores / (float64(coresOfPod) * upperLimit)))
+	return MinInt(MaxInt(ret, minCntOfPod), maxCntOfPod)
+}
+
+// pendingForecast is a forecasted peak of (startTs, dueTs], it's compared with the observed peak of the same range when it's due
+type pendingForecast struct {
+	startTs      int64
+	dueTs        int64
+	cores        float64
+	observedPeak float64
+	observedCnt  int
+}
+
+type tenantForecast struct {
+	cores   float64
+	ts      int64
+	pending []pendingForecast // forecasts waiting for the actual load to evaluate error
+	// error ratio of the latest evaluated forecast, negative if none is evaluated
+	lastErrorRatio float64
+}
+
+// Predictor refreshes forecasts of tenants with PredictiveScaleRule periodically
+type Predictor struct {
+	mu        sync.Mutex
+	forecasts map[string]*tenantForecast
+}
+
+func NewPredictor() *Predictor {
+	return &Predictor{forecasts: make(map[string]*tenantForecast)}
+}
+
+// GetForecast returns the latest forecasted peak cores of tenant, false if there is no fresh forecast
+func (p *Predictor) GetForecast(tenant string, now int64) (float64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, ok := p.forecasts[tenant]
+	if !ok || f.ts < now-int64(2*PredictiveScaleIntervalSec) {
+		return 0, false
+	}
+	return f.cores, true
+}
+
+// update records a new forecast of tenant, and evaluates the error of due forecasts with the observed peak in recent load
+func (p *Predictor) update(tenant string, rule *PredictiveScaleRule, forecast float64, hasForecast bool, recent []TimeValPair, now int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, ok := p.forecasts[tenant]
+	if !ok {
+		f = &tenantForecast{lastErrorRatio: -1}
+		p.forecasts[tenant] = f
+	}
+	remain := f.pending[:0]
+	for _, pf := range f.pending {
+		// recent load overlaps the range of forecast in pieces, since it's refreshed more often than LookAheadSeconds
+		peak, cnt := maxOfTimeValPairsInRange(recent, pf.startTs+1, Min(now, pf.dueTs))
+		if cnt > 0 && (pf.observedCnt == 0 || peak > pf.observedPeak) {
+			pf.observedPeak = peak
+		}
+		pf.observedCnt += cnt
+		if pf.dueTs > now {
+			remain = append(remain, pf)
+			continue
+		}
+		if pf.observedCnt > 0 && pf.observedPeak > 0 {
+			errRatio := math.Abs(pf.cores-pf.observedPeak) / pf.observedPeak
+			f.lastErrorRatio = errRatio
+			MetricOfPredictiveForecastErrorRatio.WithLabelValues(tenant).Set(errRatio)
+			Logger.Infof("[Predictor][%v]forecast error, forecast_peak:%.3f observed_peak:%.3f error_ratio:%.3f", tenant, pf.cores, pf.observedPeak, errRatio)
+		}
+	}
+	f.pending = remain
+	if hasForecast {
+		f.cores = forecast
+		f.ts = now
+		f.pending = append(f.pending, pendingForecast{startTs: now, dueTs: now + int64(rule.LookAheadSeconds), cores: forecast})
+		MetricOfPredictiveForecastCores.WithLabelValues(tenant).Set(forecast)
+	}
+}
+
+func (p *Predictor) removeTenantsNotIn(tenants map[string]bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k := range p.forecasts {
+		if !tenants[k] {
+			delete(p.forecasts, k)
+			MetricOfPredictiveForecastCores.DeleteLabelValues(k)
+			MetricOfPredictiveForecastErrorRatio.DeleteLabelValues(k)
+		}
+	}
+}
+
+// checked
+func (c *ClusterManager) predictiveScaleLoop() {
+	c.wg.Add(1)
+	defer c.wg.Done()
+	lastTs := int64(0)
+	for {
+		if atomic.LoadInt32(&c.shutdown) != 0 {
+			return
+		}
+		if time.Now().Unix() < lastTs+int64(PredictiveScaleIntervalSec) {
+			time.Sleep(time.Second)
+			continue
+		}
+		lastTs = time.Now().Unix()
+		c.refreshForecasts(time.Now())
+	}
+}
+
+func (c *ClusterManager) refreshForecasts(now time.Time) {
+	tenantsWithRule := make(map[string]bool)
+	for _, tenant := range c.AutoScaleMeta.GetTenants() {
+		conf := tenant.GetConf()
+		rule := conf.PredictiveScaleRules
+		if rule == nil || rule.SeasonSeconds <= 0 {
+			continue
+		}
+		tenantsWithRule[tenant.Name] = true
+		trendWindow := time.Duration(rule.GetTrendWindowSeconds()) * time.Second
+		season := time.Duration(rule.SeasonSeconds) * time.Second
+		lookAhead := time.Duration(rule.LookAheadSeconds) * time.Second
+		step := PredictiveQueryStepSeconds * time.Second
+		history, err := c.PromClient.RangeQueryCpuOfTenant(tenant.Name, now.Add(-season-trendWindow), now.Add(-season+lookAhead), step)
+		if err != nil {
+			Logger.Errorf("[error][Predictor][%v]query history fail, err:%v", tenant.Name, err.Error())
+			continue
+		}
+		recent, err := c.PromClient.RangeQueryCpuOfTenant(tenant.Name, now.Add(-trendWindow), now, step)
+		if err != nil {
+			Logger.Errorf("[error][Predictor][%v]query recent load fail, err:%v", tenant.Name, err.Error())
+			continue
+		}
+		forecast, hasForecast := ForecastSeasonalPeak(rule, history, recent, now.Unix())
+		c.predictor.update(tenant.Name, rule, forecast, hasForecast, recent, now.Unix())
+		Logger.Infof("[Predictor][%v]forecast peak cores in next %vs:%.3f, has_forecast:%v, history_points:%v, recent_points:%v", tenant.Name, rule.LookAheadSeconds, forecast, hasForecast, len(history), len(recent))
+	}
+	c.predictor.removeTenantsNotIn(tenantsWithRule)
+}
@@ -0,0 +1,91 @@
+package autoscale
+
+import (
+	"math"
+	"testing"
+)
+
+func newSeries4Test(begin int64, end int64, step int64, f func(ts int64) float64) []TimeValPair {
+	ret := make([]TimeValPair, 0, 64)
+	for ts := begin; ts <= end; ts += step {
+		ret = append(ret, TimeValPair{time: ts, value: f(ts)})
+	}
+	return ret
+}
+
+func TestForecastSeasonalPeak(t *testing.T) {
+	InitTestEnv()
+	rule := &PredictiveScaleRule{SeasonSeconds: 86400, LookAheadSeconds: 600, TrendWindowSeconds: 1800}
+	now := int64(10 * 86400)
+	lastSeasonNow := now - 86400
+	// last season: 4 cores at baseline, peak of 10 cores 5 minutes later
+	history := newSeries4Test(lastSeasonNow-1800, lastSeasonNow+600, 60, func(ts int64) float64 {
+		if ts == lastSeasonNow+300 {
+			return 10
+		}
+		return 4
+	})
+
+	// same load as last season
+	recent := newSeries4Test(now-1800, now, 60, func(ts int64) float64 { return 4 })
+	forecast, ok := ForecastSeasonalPeak(rule, history, recent, now)
+	assertEqual(t, ok, true)
+	assertEqual(t, forecast, 10.0)
+
+	// load grows 50% compared with last season
+	recent = newSeries4Test(now-1800, now, 60, func(ts int64) float64 { return 6 })
+	forecast, _ = ForecastSeasonalPeak(rule, history, recent, now)
+	assertEqual(t, forecast, 15.0)
+
+	// trend is clamped
+	recent = newSeries4Test(now-1800, now, 60, func(ts int64) float64 { return 40 })
+	forecast, _ = ForecastSeasonalPeak(rule, history, recent, now)
+	assertEqual(t, forecast, 10*PredictiveMaxTrendRatio)
+
+	// NaN is ignored, and no recent load means no trend adjustment
+	recent = newSeries4Test(now-1800, now, 60, func(ts int64) float64 { return math.NaN() })
+	forecast, _ = ForecastSeasonalPeak(rule, history, recent, now)
+	assertEqual(t, forecast, 10.0)
+
+	// no history of last season
+	_, ok = ForecastSeasonalPeak(rule, nil, recent, now)
+	assertEqual(t, ok, false)
+}
+
+func TestComputeTargetCntOfPodsByForecast(t *testing.T) {
+	InitTestEnv()
+	cores := float64(DefaultCoreOfPod)
+	assertEqual(t, ComputeTargetCntOfPodsByForecast(cores*0.8*2, DefaultCoreOfPod, 0.8, 1, 4), 2)
+	assertEqual(t, ComputeTargetCntOfPodsByForecast(cores*0.8*2+0.1, DefaultCoreOfPod, 0.8, 1, 4), 3)
+	assertEqual(t, ComputeTargetCntOfPodsByForecast(0, DefaultCoreOfPod, 0.8, 1, 4), 1)
+	assertEqual(t, ComputeTargetCntOfPodsByForecast(cores*100, DefaultCoreOfPod, 0.8, 1, 4), 4)
+}
+
+func TestPredictorForecastError(t *testing.T) {
+	InitTestEnv()
+	p := NewPredictor()
+	rule := &PredictiveScaleRule{SeasonSeconds: 86400, LookAheadSeconds: 600}
+	p.update("t1", rule, 10, true, []TimeValPair{{time: 1000, value: 4}}, 1000)
+	forecast, ok := p.GetForecast("t1", 1000)
+	assertEqual(t, ok, true)
+	assertEqual(t, forecast, 10.0)
+	assertEqual(t, len(p.forecasts["t1"].pending), 1)
+
+	// observed peak is tracked across refreshes, load before forecast is ignored
+	p.update("t1", rule, 0, false, []TimeValPair{{time: 1000, value: 20}, {time: 1200, value: 8}}, 1300)
+	assertEqual(t, p.forecasts["t1"].pending[0].observedPeak, 8.0)
+	assertEqual(t, p.forecasts["t1"].lastErrorRatio, -1.0)
+
+	// forecast is due, its peak is compared with observed peak and dropped
+	p.update("t1", rule, 0, false, []TimeValPair{{time: 1300, value: 5}, {time: 1500, value: 4}, {time: 1700, value: 50}}, 1700)
+	assertEqual(t, len(p.forecasts["t1"].pending), 0)
+	assertEqual(t, p.forecasts["t1"].lastErrorRatio, 0.25)
+
+	// stale forecast is not used
+	_, ok = p.GetForecast("t1", 1000+int64(3*PredictiveScaleIntervalSec))
+	assertEqual(t, ok, false)
+
+	p.removeTenantsNotIn(map[string]bool{})
+	_, ok = p.GetForecast("t1", 1000)
+	assertEqual(t, ok, false)
+}
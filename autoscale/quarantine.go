@@ -0,0 +1,218 @@
+package autoscale
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Pods failed to be assigned or unassigned are quarantined in their pool, instead of being picked again by next resize.
+// They are probed by GetCurrentTenant with exponential backoff, and deleted after QuarantineMaxFailCnt failures in a row.
+var (
+	QuarantineProbeIntervalSec = 5
+	QuarantineMaxFailCnt       = 5
+	quarantineBackoffMin       = 10 * time.Second
+	quarantineBackoffMax       = 5 * time.Minute
+)
+
+type quarantinedPod struct {
+	pod           *PodDesc
+	tenant        string // tenant which pod failed with
+	reason        string
+	failCnt       int
+	since         int64
+	nextProbeTime time.Time
+}
+
+// AnomalousPod is view of a quarantined pod
+type AnomalousPod struct {
+	PodName       string `json:"podName"`
+	PodClass      string `json:"podClass"`
+	PodIP         string `json:"podIP"`
+	Tenant        string `json:"tenant"`
+	Reason        string `json:"reason"`
+	FailCnt       int    `json:"failCnt"`
+	Since         int64  `json:"since"`         // unix seconds
+	NextProbeTime int64  `json:"nextProbeTime"` // unix seconds
+}
+
+func getQuarantineBackoff(failCnt int) time.Duration {
+	backoff := quarantineBackoffMin
+	for i := 1; i < failCnt && backoff < quarantineBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > quarantineBackoffMax {
+		backoff = quarantineBackoffMax
+	}
+	return backoff
+}
+
+// quarantinePod takes pod out of WarmedPods if it's there, its fail count increases if it's quarantined already
+func (p *PrewarmPool) quarantinePod(pod *PodDesc, tenant string, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.WarmedPods.RemovePod(pod.Name)
+	q, ok := p.quarantine[pod.Name]
+	if !ok {
+		q = &quarantinedPod{pod: pod, since: time.Now().Unix()}
+		p.quarantine[pod.Name] = q
+	}
+	if tenant != "" {
+		q.tenant = tenant
+	}
+	q.reason = reason
+	q.failCnt++
+	q.nextProbeTime = time.Now().Add(getQuarantineBackoff(q.failCnt))
+	MetricOfQuarantinedPodCnt.WithLabelValues(p.PodClass).Set(float64(len(p.quarantine)))
+	Logger.Warnf("[PrewarmPool][%v][quarantine]pod %v of tenant %v is quarantined, failCnt:%v reason: %v", p.PodClass, pod.Name, tenant, q.failCnt, reason)
+}
+
+// releaseQuarantinedPod removes pod from quarantine, nil if it's not quarantined
+func (p *PrewarmPool) releaseQuarantinedPod(podName string) *PodDesc {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	q, ok := p.quarantine[podName]
+	if !ok {
+		return nil
+	}
+	delete(p.quarantine, podName)
+	MetricOfQuarantinedPodCnt.WithLabelValues(p.PodClass).Set(float64(len(p.quarantine)))
+	return q.pod
+}
+
+func (p *PrewarmPool) getFailCntOfQuarantinedPod(podName string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if q, ok := p.quarantine[podName]; ok {
+		return q.failCnt
+	}
+	return 0
+}
+
+// getQuarantinedPodsToProbe returns pods whose backoff expires
+func (p *PrewarmPool) getQuarantinedPodsToProbe(now time.Time) []*PodDesc {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ret := make([]*PodDesc, 0)
+	for _, q := range p.quarantine {
+		if !now.Before(q.nextProbeTime) {
+			ret = append(ret, q.pod)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+func (p *PrewarmPool) getQuarantinedPods() []AnomalousPod {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ret := make([]AnomalousPod, 0, len(p.quarantine))
+	for name, q := range p.quarantine {
+		ret = append(ret, AnomalousPod{
+			PodName:       name,
+			PodClass:      p.PodClass,
+			PodIP:         q.pod.IP,
+			Tenant:        q.tenant,
+			Reason:        q.reason,
+			FailCnt:       q.failCnt,
+			Since:         q.since,
+			NextProbeTime: q.nextProbeTime.Unix(),
+		})
+	}
+	return ret
+}
+
+// quarantinePod puts pod into quarantine of its pool if it's not deleted by k8s
+func (c *AutoScaleMeta) quarantinePod(pod *PodDesc, tenant string, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.PodDescMap[pod.Name]; !ok {
+		return
+	}
+	pool := c.GetPrewarmPool(pod.Class)
+	if pool == nil {
+		Logger.Errorf("[error][AutoScaleMeta]unknown pod class:%v pod:%v", pod.Class, pod.Name)
+		return
+	}
+	pool.quarantinePod(pod, tenant, reason)
+}
+
+// GetAnomalousPods returns quarantined pods of all pools in order of pod name
+func (c *AutoScaleMeta) GetAnomalousPods() []AnomalousPod {
+	ret := make([]AnomalousPod, 0)
+	for _, podClass := range GetPodClassNames() {
+		if pool := c.GetPrewarmPool(podClass); pool != nil {
+			ret = append(ret, pool.getQuarantinedPods()...)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].PodName < ret[j].PodName })
+	return ret
+}
+
+// probeQuarantinedPod returns pod to pool if supervisor serves no tenant, it's deleted if it keeps failing or it's of old revision in upgrade
+func (c *ClusterManager) probeQuarantinedPod(pool *PrewarmPool, pod *PodDesc) {
+	c.AutoScaleMeta.mu.Lock()
+	_, ok := c.AutoScaleMeta.PodDescMap[pod.Name]
+	podIP := pod.IP
+	c.AutoScaleMeta.mu.Unlock()
+	if !ok {
+		pool.releaseQuarantinedPod(pod.Name)
+		return
+	}
+	resp, err := getCurrentTenantOfPod(podIP)
+	var reason string
+	if err != nil {
+		reason = fmt.Sprintf("probe failed: %v", err.Error())
+	} else if resp.IsUnassigning {
+		reason = "supervisor is still unassigning"
+	} else {
+		if pool.releaseQuarantinedPod(pod.Name) == nil { // deleted meanwhile
+			return
+		}
+		if resp.TenantID == "" && c.isOldPodInUpgrade(pool.PodClass, pod) { // it's never returned to pool, other tenants may pick it
+			Logger.Infof("[PrewarmPool][%v][quarantine]pod %v of old revision is healthy, delete it", pool.PodClass, pod.Name)
+			if _, err = c.removePods(pool.PodClass, []string{pod.Name}, 2); err != nil {
+				c.AutoScaleMeta.quarantinePod(pod, "", fmt.Sprintf("failed to delete pod of old revision: %v", err.Error()))
+			}
+		} else if resp.TenantID == "" {
+			Logger.Infof("[PrewarmPool][%v][quarantine]pod %v is healthy, return it to pool", pool.PodClass, pod.Name)
+			c.AutoScaleMeta.mu.Lock()
+			pool.putWarmedPod("", pod, false)
+			c.tsContainer.ResetMetricsOfPod(pod.Name)
+			c.AutoScaleMeta.mu.Unlock()
+		} else { // supervisor serves a tenant, correct its state
+			Logger.Warnf("[PrewarmPool][%v][quarantine]pod %v is serving tenant %v", pool.PodClass, pod.Name, resp.TenantID)
+			c.AutoScaleMeta.UpdateLocalMetaPodOfTenant(pod.Name, pod, resp.TenantID, resp.StartTime)
+		}
+		return
+	}
+
+	if pool.getFailCntOfQuarantinedPod(pod.Name)+1 < QuarantineMaxFailCnt {
+		c.AutoScaleMeta.quarantinePod(pod, "", reason)
+		return
+	}
+	if pool.releaseQuarantinedPod(pod.Name) == nil {
+		return
+	}
+	Logger.Errorf("[error][PrewarmPool][%v][quarantine]pod %v keeps failing, delete it, reason: %v", pool.PodClass, pod.Name, reason)
+	if _, err = c.removePods(pool.PodClass, []string{pod.Name}, 2); err != nil {
+		c.AutoScaleMeta.quarantinePod(pod, "", fmt.Sprintf("failed to delete pod: %v", err.Error()))
+	}
+}
+
+func (c *ClusterManager) quarantineProbeLoop() {
+	for {
+		time.Sleep(time.Duration(QuarantineProbeIntervalSec) * time.Second)
+		if atomic.LoadInt32(&c.shutdown) != 0 {
+			return
+		}
+		now := time.Now()
+		for _, podClass := range GetPodClassNames() {
+			pool := c.AutoScaleMeta.GetPrewarmPool(podClass)
+			for _, pod := range pool.getQuarantinedPodsToProbe(now) {
+				c.probeQuarantinedPod(pool, pod)
+			}
+		}
+	}
+}
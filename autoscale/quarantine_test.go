@@ -0,0 +1,74 @@
+package autoscale
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	supervisor "github.com/tikv/pd/supervisor_proto"
+)
+
+func TestQuarantinePod(t *testing.T) {
+	InitTestEnv()
+	oldGet := getCurrentTenantOfPod
+	defer func() {
+		getCurrentTenantOfPod = oldGet
+	}()
+	assertEqual(t, getQuarantineBackoff(1), quarantineBackoffMin)
+	assertEqual(t, getQuarantineBackoff(3), 4*quarantineBackoffMin)
+	assertEqual(t, getQuarantineBackoff(100), quarantineBackoffMax)
+
+	meta := newMeta4TenantStoreTest(nil)
+	meta.UpdatePod(newPod4PodClassTest("p0", "10.0.0.1", ReadNodeCloneSetName))
+	meta.UpdatePod(newPod4PodClassTest("p1", "10.0.0.2", ReadNodeCloneSetName))
+	pool := meta.GetPrewarmPool("")
+	c := &ClusterManager{AutoScaleMeta: meta, tsContainer: NewTimeSeriesContainer(nil)}
+
+	// quarantined pod is not picked by resize
+	pod, _ := pool.WarmedPods.GetPod("p0")
+	meta.quarantinePod(pod, "t1", "grpc error of AssignTenant")
+	pods, failCnt := pool.getWarmedPods("t2", 2)
+	assertEqual(t, len(pods), 1)
+	assertEqual(t, pods[0].Name, "p1")
+	assertEqual(t, failCnt, 1)
+	pool.putWarmedPod("", pods[0], false)
+	anomalousPods := meta.GetAnomalousPods()
+	assertEqual(t, len(anomalousPods), 1)
+	assertEqual(t, anomalousPods[0].Tenant, "t1")
+	assertEqual(t, anomalousPods[0].FailCnt, 1)
+
+	// failed probe backs off more
+	now := time.Now()
+	assertEqual(t, len(pool.getQuarantinedPodsToProbe(now)), 0)
+	probed := pool.getQuarantinedPodsToProbe(now.Add(quarantineBackoffMin))
+	assertEqual(t, len(probed), 1)
+	getCurrentTenantOfPod = func(podIP string) (*supervisor.GetTenantResponse, error) {
+		return nil, fmt.Errorf("grpc timeout")
+	}
+	c.probeQuarantinedPod(pool, probed[0])
+	anomalousPods = meta.GetAnomalousPods()
+	assertEqual(t, anomalousPods[0].Tenant, "t1")
+	assertEqual(t, anomalousPods[0].FailCnt, 2)
+	now = time.Now()
+	assertEqual(t, len(pool.getQuarantinedPodsToProbe(now.Add(quarantineBackoffMin))), 0)
+	assertEqual(t, len(pool.getQuarantinedPodsToProbe(now.Add(3*quarantineBackoffMin))), 1)
+
+	// healthy pod is returned to pool
+	getCurrentTenantOfPod = func(podIP string) (*supervisor.GetTenantResponse, error) {
+		return &supervisor.GetTenantResponse{}, nil
+	}
+	c.probeQuarantinedPod(pool, probed[0])
+	assertEqual(t, len(meta.GetAnomalousPods()), 0)
+	assertEqual(t, pool.WarmedPods.GetCntOfPods(), 2)
+
+	// pod serving a tenant is corrected
+	meta.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	meta.quarantinePod(pod, "t1", "grpc error of AssignTenant")
+	getCurrentTenantOfPod = func(podIP string) (*supervisor.GetTenantResponse, error) {
+		return &supervisor.GetTenantResponse{TenantID: "t1", StartTime: 1}, nil
+	}
+	c.probeQuarantinedPod(pool, pod)
+	assertEqual(t, len(meta.GetAnomalousPods()), 0)
+	assertEqual(t, pod.GetTenantName(), "t1")
+	assertEqual(t, pool.WarmedPods.GetCntOfPods(), 1)
+}
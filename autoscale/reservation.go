@@ -0,0 +1,306 @@
+package autoscale
+
+import (
+	"sort"
+)
+
+// Warm pods are reserved for paused tenant with ReservedWarmPods, they are used by its resume before shared pool.
+// With PreAssignReservedPods, they are assigned to tenant through supervisor in advance, so that resume only publishes topology.
+const (
+	reservedPodStateIdle         = iota // earmarked for tenant
+	reservedPodStatePreAssigning        // being assigned to tenant, it can't be taken or released
+	reservedPodStatePreAssigned
+)
+
+type reservedPod struct {
+	pod               *PodDesc
+	state             int
+	startTimeOfAssign int64 // set if it's pre-assigned
+}
+
+func (r *reservedPod) isPreAssigned() bool {
+	return r.state == reservedPodStatePreAssigned
+}
+
+func (c *TenantDesc) GetReservedWarmPods() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conf.ReservedWarmPods
+}
+
+func (c *TenantDesc) IsPreAssignReservedPods() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conf.PreAssignReservedPods
+}
+
+func (p *PrewarmPool) getCntOfReservedPods(tenant string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.reservations[tenant])
+}
+
+func (p *PrewarmPool) getTenantsOfReservations() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ret := make([]string, 0, len(p.reservations))
+	for tenant := range p.reservations {
+		ret = append(ret, tenant)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// reserveWarmedPods moves at most cnt pods from WarmedPods into reservation of tenant, they are marked as pre-assigning if preAssign
+func (p *PrewarmPool) reserveWarmedPods(tenant string, cnt int, preAssign bool) []*reservedPod {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ret := make([]*reservedPod, 0, cnt)
+	for _, name := range p.WarmedPods.GetPodNames() {
+		if len(ret) >= cnt {
+			break
+		}
+		if pod := p.WarmedPods.RemovePod(name); pod != nil {
+			r := &reservedPod{pod: pod, state: reservedPodStateIdle}
+			if preAssign {
+				r.state = reservedPodStatePreAssigning
+			}
+			p.reservations[tenant] = append(p.reservations[tenant], r)
+			ret = append(ret, r)
+		}
+	}
+	if len(ret) > 0 {
+		Logger.Infof("[PrewarmPool][%v]reserve %v pods for tenant %v", p.PodClass, len(ret), tenant)
+	}
+	return ret
+}
+
+// takeReservedPods removes at most cnt reserved pods of tenant, pre-assigned ones first. Pods being pre-assigned are skipped.
+func (p *PrewarmPool) takeReservedPods(tenant string, cnt int) []*reservedPod {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pods := p.reservations[tenant]
+	sort.SliceStable(pods, func(i, j int) bool { return pods[i].isPreAssigned() && !pods[j].isPreAssigned() })
+	ret := make([]*reservedPod, 0, cnt)
+	remain := make([]*reservedPod, 0, len(pods))
+	for _, r := range pods {
+		if len(ret) < cnt && r.state != reservedPodStatePreAssigning {
+			ret = append(ret, r)
+		} else {
+			remain = append(remain, r)
+		}
+	}
+	p.setReservationsWithoutLock(tenant, remain)
+	return ret
+}
+
+// putReservedPods gives back pods taken by takeReservedPods
+func (p *PrewarmPool) putReservedPods(tenant string, pods []*reservedPod) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setReservationsWithoutLock(tenant, append(p.reservations[tenant], pods...))
+}
+
+func (p *PrewarmPool) setReservationsWithoutLock(tenant string, pods []*reservedPod) {
+	if len(pods) == 0 {
+		delete(p.reservations, tenant)
+	} else {
+		p.reservations[tenant] = pods
+	}
+}
+
+// finishPreAssign sets state of reserved pod after it's assigned, false if it has left reservation of tenant
+func (p *PrewarmPool) finishPreAssign(tenant string, podName string, isSuccessful bool, startTimeOfAssign int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pods := p.reservations[tenant]
+	for i, r := range pods {
+		if r.pod.Name != podName {
+			continue
+		}
+		if isSuccessful {
+			r.state = reservedPodStatePreAssigned
+			r.startTimeOfAssign = startTimeOfAssign
+		} else {
+			p.setReservationsWithoutLock(tenant, append(pods[:i:i], pods[i+1:]...))
+		}
+		return true
+	}
+	return false
+}
+
+func (p *PrewarmPool) isReservedPod(podName string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pods := range p.reservations {
+		for _, r := range pods {
+			if r.pod.Name == podName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// adoptPreAssignedPod puts pod found assigned to tenant into its reservation as a pre-assigned one, false if reservation is full
+func (p *PrewarmPool) adoptPreAssignedPod(tenant string, pod *PodDesc, startTimeOfAssign int64, limit int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, r := range p.reservations[tenant] {
+		if r.pod == pod {
+			return true
+		}
+	}
+	if len(p.reservations[tenant]) >= limit {
+		return false
+	}
+	p.reservations[tenant] = append(p.reservations[tenant], &reservedPod{pod: pod, state: reservedPodStatePreAssigned, startTimeOfAssign: startTimeOfAssign})
+	Logger.Infof("[PrewarmPool][%v]adopt pod %v pre-assigned to tenant %v", p.PodClass, pod.Name, tenant)
+	return true
+}
+
+// removePodOfReservationsWithoutLock is called when pod is deleted by k8s
+func (p *PrewarmPool) removePodOfReservationsWithoutLock(podName string) {
+	for tenant, pods := range p.reservations {
+		for i, r := range pods {
+			if r.pod.Name == podName {
+				p.setReservationsWithoutLock(tenant, append(pods[:i:i], pods[i+1:]...))
+				return
+			}
+		}
+	}
+}
+
+func (p *PrewarmPool) getCntOfAllReservedPodsWithoutLock() int {
+	ret := 0
+	for _, pods := range p.reservations {
+		ret += len(pods)
+	}
+	return ret
+}
+
+// maintainReservations keeps ReservedWarmPods pods for each paused tenant of pool, and releases pods of other tenants.
+// Tenants which are resuming or pausing are skipped, since their reserved pods may be in use.
+func (c *AutoScaleMeta) maintainReservations(pool *PrewarmPool) {
+	wanted := make(map[string]bool)
+	for _, tenantDesc := range c.GetTenants() {
+		if tenantDesc.GetPodClass() != pool.PodClass || tenantDesc.IsDryRun() { // pods of dry-run tenant are never moved, its reservations are released below
+			continue
+		}
+		state := tenantDesc.GetState()
+		if state == TenantStateResuming || state == TenantStatePausing {
+			wanted[tenantDesc.Name] = true
+			continue
+		}
+		want := 0
+		if state == TenantStatePaused {
+			want = tenantDesc.GetReservedWarmPods()
+		}
+		if want > 0 {
+			wanted[tenantDesc.Name] = true
+		}
+		have := pool.getCntOfReservedPods(tenantDesc.Name)
+		if have < want {
+			preAssign := tenantDesc.IsPreAssignReservedPods()
+			for _, r := range pool.reserveWarmedPods(tenantDesc.Name, want-have, preAssign) {
+				if preAssign {
+					go c.preAssignReservedPod(pool, tenantDesc, r.pod)
+				}
+			}
+		} else if have > want {
+			for _, r := range pool.takeReservedPods(tenantDesc.Name, have-want) {
+				go c.releaseReservedPod(pool, tenantDesc.Name, r)
+			}
+		}
+	}
+	for _, tenant := range pool.getTenantsOfReservations() {
+		if !wanted[tenant] { // tenant is removed, its pod class is changed or it's in dry-run mode
+			for _, r := range pool.takeReservedPods(tenant, pool.getCntOfReservedPods(tenant)) {
+				go c.releaseReservedPod(pool, tenant, r)
+			}
+		}
+	}
+}
+
+// isReservedPod is true if pod is kept for a paused tenant, scan of pods skips it since supervisor may serve the tenant already
+func (c *AutoScaleMeta) isReservedPod(pod *PodDesc) bool {
+	pool := c.GetPrewarmPool(pod.Class)
+	return pool != nil && pool.isReservedPod(pod.Name)
+}
+
+// adoptPreAssignedPodWithoutLock keeps pod pre-assigned to paused tenant in reservation, instead of resuming tenant with it.
+// Reservations are kept in memory, they are rebuilt by scan of pods after restart or change of leader.
+func (c *AutoScaleMeta) adoptPreAssignedPodWithoutLock(tenantDesc *TenantDesc, pod *PodDesc, startTimeOfAssign int64) bool {
+	if tenantDesc.GetState() != TenantStatePaused || !tenantDesc.IsPreAssignReservedPods() || tenantDesc.IsDryRun() {
+		return false
+	}
+	pool := c.GetPrewarmPool(pod.Class)
+	if pool == nil || pool != c.GetPrewarmPool(tenantDesc.GetPodClass()) {
+		return false
+	}
+	return pool.adoptPreAssignedPod(tenantDesc.Name, pod, startTimeOfAssign, tenantDesc.GetReservedWarmPods())
+}
+
+func (c *AutoScaleMeta) preAssignReservedPod(pool *PrewarmPool, tenantDesc *TenantDesc, pod *PodDesc) {
+	tidbStatusAddr, pdAddr, err := tenantDesc.GetAddrsOfTiDBCluster()
+	if err != nil { // it's kept as a reserved pod without pre-assignment
+		Logger.Warnf("[AutoScaleMeta][reservation][%v]failed to pre-assign pod %v, err: %v", tenantDesc.Name, pod.Name, err.Error())
+		pool.mu.Lock()
+		for _, r := range pool.reservations[tenantDesc.Name] {
+			if r.pod == pod {
+				r.state = reservedPodStateIdle
+			}
+		}
+		pool.mu.Unlock()
+		return
+	}
+	pod.isStateChanging.Store(true)
+	resp, err := pod.AssignTenantWithAddrs(tenantDesc.Name, tidbStatusAddr, pdAddr)
+	pod.isStateChanging.Store(false)
+	if err == nil && !resp.HasErr {
+		pool.finishPreAssign(tenantDesc.Name, pod.Name, true, resp.StartTime)
+		Logger.Infof("[AutoScaleMeta][reservation][%v]pod %v is pre-assigned", tenantDesc.Name, pod.Name)
+		return
+	}
+	if !pool.finishPreAssign(tenantDesc.Name, pod.Name, false, 0) {
+		return
+	}
+	if err != nil {
+		Logger.Errorf("[error][AutoScaleMeta][reservation][%v] grpc error, pod:%v err: %v", tenantDesc.Name, pod.Name, err.Error())
+		c.quarantinePod(pod, tenantDesc.Name, "grpc error of AssignTenant: "+err.Error())
+	} else {
+		Logger.Errorf("[error][AutoScaleMeta][reservation][%v] app api error, pod:%v err: %v", tenantDesc.Name, pod.Name, resp.ErrInfo)
+		if !resp.IsUnassigning {
+			c.UpdateLocalMetaPodOfTenant(pod.Name, pod, resp.TenantID, resp.StartTime)
+		} else {
+			HandleUnassingCase(c, resp.TenantID, pod, c.tsContainer)
+		}
+	}
+}
+
+// releaseReservedPod returns pod to pool, it's unassigned first if it's pre-assigned
+func (c *AutoScaleMeta) releaseReservedPod(pool *PrewarmPool, tenant string, r *reservedPod) {
+	if r.isPreAssigned() {
+		r.pod.isStateChanging.Store(true)
+		resp, err := r.pod.UnassignTenantWithMockConf(tenant, false)
+		r.pod.isStateChanging.Store(false)
+		if err != nil {
+			c.quarantinePod(r.pod, tenant, "grpc error of UnassignTenant: "+err.Error())
+			return
+		} else if resp.HasErr {
+			Logger.Errorf("[error][AutoScaleMeta][reservation][%v] app api error, pod:%v err: %v", tenant, r.pod.Name, resp.ErrInfo)
+			if !resp.IsUnassigning {
+				c.UpdateLocalMetaPodOfTenant(r.pod.Name, r.pod, resp.TenantID, resp.StartTime)
+			} else {
+				HandleUnassingCase(c, resp.TenantID, r.pod, c.tsContainer)
+			}
+			return
+		}
+	}
+	Logger.Infof("[AutoScaleMeta][reservation][%v]release pod %v", tenant, r.pod.Name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.PodDescMap[r.pod.Name]; ok {
+		pool.putWarmedPod("", r.pod, false)
+	}
+}
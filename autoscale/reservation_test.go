@@ -0,0 +1,114 @@
+package autoscale
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReservedWarmPods(t *testing.T) {
+	InitTestEnv()
+	meta := newMeta4TenantStoreTest(nil)
+	meta.tsContainer = NewTimeSeriesContainer(nil)
+	pool := meta.GetPrewarmPool("")
+	for i := 0; i < 4; i++ {
+		pod := &PodDesc{Name: fmt.Sprintf("p%v", i)}
+		meta.PodDescMap[pod.Name] = pod
+		pool.putWarmedPod("", pod, false)
+	}
+	meta.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	tenant := meta.GetTenantDesc("t1")
+	tenant.conf.ReservedWarmPods = 2
+
+	// paused tenant keeps reserved pods out of pool
+	meta.maintainReservations(pool)
+	assertEqual(t, pool.getCntOfReservedPods("t1"), 2)
+	assertEqual(t, pool.WarmedPods.GetCntOfPods(), 2)
+	meta.maintainReservations(pool)
+	assertEqual(t, pool.getCntOfReservedPods("t1"), 2)
+
+	// pre-assigned pods are taken first, pods being pre-assigned are skipped
+	pool.mu.Lock()
+	pool.reservations["t1"][0].state = reservedPodStatePreAssigning
+	pool.reservations["t1"][1].state = reservedPodStatePreAssigned
+	preAssignedPod := pool.reservations["t1"][1].pod
+	pool.mu.Unlock()
+	reserved := pool.takeReservedPods("t1", 2)
+	assertEqual(t, len(reserved), 1)
+	assertEqual(t, reserved[0].pod, preAssignedPod)
+	assertEqual(t, pool.getCntOfReservedPods("t1"), 1)
+	pool.putReservedPods("t1", reserved)
+	assertEqual(t, pool.finishPreAssign("t1", "p9", true, 0), false)
+	pool.mu.Lock()
+	for _, r := range pool.reservations["t1"] {
+		r.state = reservedPodStateIdle
+	}
+	pool.mu.Unlock()
+
+	// pods deleted by k8s leave reservation
+	meta.mu.Lock()
+	meta.removePodFromClusterWithoutLock(reserved[0].pod)
+	meta.mu.Unlock()
+	assertEqual(t, pool.getCntOfReservedPods("t1"), 1)
+
+	// dry-run tenant holds no reserved pods
+	tenant.conf.DryRun = true
+	meta.maintainReservations(pool)
+	assertEqual(t, pool.getCntOfReservedPods("t1"), 0)
+	for i := 0; i < 1000 && pool.WarmedPods.GetCntOfPods() != 3; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assertEqual(t, pool.WarmedPods.GetCntOfPods(), 3)
+	meta.maintainReservations(pool)
+	assertEqual(t, pool.getCntOfReservedPods("t1"), 0)
+	tenant.conf.DryRun = false
+	meta.maintainReservations(pool)
+	assertEqual(t, pool.getCntOfReservedPods("t1"), 2)
+
+	// reservation is released once tenant is removed
+	meta.mu.Lock()
+	delete(meta.tenantMap, "t1")
+	meta.mu.Unlock()
+	meta.maintainReservations(pool)
+	assertEqual(t, pool.getCntOfReservedPods("t1"), 0)
+	for i := 0; i < 1000 && pool.WarmedPods.GetCntOfPods() != 3; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assertEqual(t, pool.WarmedPods.GetCntOfPods(), 3)
+}
+
+func TestAdoptPreAssignedPods(t *testing.T) {
+	InitTestEnv()
+	meta := newMeta4TenantStoreTest(nil)
+	meta.tsContainer = NewTimeSeriesContainer(nil)
+	pool := meta.GetPrewarmPool("")
+	for i := 0; i < 3; i++ {
+		pod := &PodDesc{Name: fmt.Sprintf("p%v", i)}
+		meta.PodDescMap[pod.Name] = pod
+		pool.putWarmedPod("", pod, false)
+	}
+	meta.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	tenant := meta.GetTenantDesc("t1")
+	tenant.conf.ReservedWarmPods = 2
+	tenant.conf.PreAssignReservedPods = true
+
+	// pods pre-assigned before restart are kept in reservation, instead of resuming tenant
+	meta.UpdateLocalMetaPodOfTenant("p0", meta.PodDescMap["p0"], "t1", 10)
+	meta.UpdateLocalMetaPodOfTenant("p0", meta.PodDescMap["p0"], "t1", 10)
+	meta.UpdateLocalMetaPodOfTenant("p1", meta.PodDescMap["p1"], "t1", 11)
+	assertEqual(t, pool.getCntOfReservedPods("t1"), 2)
+	assertEqual(t, meta.isReservedPod(meta.PodDescMap["p1"]), true)
+	assertEqual(t, meta.isReservedPod(meta.PodDescMap["p2"]), false)
+	assertEqual(t, pool.WarmedPods.GetCntOfPods(), 1)
+	assertEqual(t, tenant.GetCntOfPods(), 0)
+	assertEqual(t, tenant.GetState(), int32(TenantStatePaused))
+	reserved := pool.takeReservedPods("t1", 1)
+	assertEqual(t, reserved[0].isPreAssigned(), true)
+	pool.putReservedPods("t1", reserved)
+
+	// pods out of reservation resume tenant as before
+	meta.UpdateLocalMetaPodOfTenant("p2", meta.PodDescMap["p2"], "t1", 12)
+	assertEqual(t, pool.getCntOfReservedPods("t1"), 2)
+	assertEqual(t, tenant.GetCntOfPods(), 1)
+	assertEqual(t, tenant.GetState(), int32(TenantStateResumed))
+}
@@ -20,6 +20,8 @@ package autoscale
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"net"
 	"time"
@@ -50,8 +52,11 @@ func (s *server) ResumeAndGetTopology(ctx context.Context, req *pb.ResumeAndGetT
 		MetricOfRpcRequestResumeAndGetTopologySeconds.Observe(time.Since(st).Seconds())
 	}()
 	MetricOfRpcRequestResumeAndGetTopologyCnt.Inc()
+	if !Cm4Http.IsLeader() && !isForwardedGrpcCall(ctx) {
+		return forwardResumeAndGetTopologyToLeader(ctx, req)
+	}
 	ret := &pb.ResumeAndGetTopologyResponse{}
-	flag := Cm4Http.Resume(req.GetTidbClusterID())
+	flag, result := Cm4Http.ResumeTenantByApi(req.GetTidbClusterID(), "grpc")
 	if !flag {
 		ret.HasErr = true
 		ret.ErrInfo = ("resume failed")
@@ -64,6 +69,10 @@ func (s *server) ResumeAndGetTopology(ctx context.Context, req *pb.ResumeAndGetT
 	} else {
 		ret.CurState = TenantState2String(curState)
 	}
+	if result.Reason != "" { // rejected, waiting doesn't help
+		ret.ErrInfo = "resume failed: " + result.Reason
+		return ret, nil
+	}
 
 	TimeOutSec := int64(60)
 	for time.Now().Unix()-st.Unix() <= TimeOutSec {
@@ -81,12 +90,103 @@ func (s *server) ResumeAndGetTopology(ctx context.Context, req *pb.ResumeAndGetT
 	return ret, nil
 }
 
+// GetTenantConfig returns config of tenant in json, or configs of all tenants if tidbClusterID is empty
+func (s *server) GetTenantConfig(ctx context.Context, req *pb.GetTenantConfigRequest) (*pb.GetTenantConfigResponse, error) {
+	st := time.Now()
+	defer func() {
+		MetricOfRpcRequestTenantConfigSeconds.Observe(time.Since(st).Seconds())
+	}()
+	MetricOfRpcRequestTenantConfigCnt.Inc()
+	if !Cm4Http.IsLeader() && !isForwardedGrpcCall(ctx) {
+		conn, outCtx, errInfo := dialLeader(ctx, "GetTenantConfig")
+		if conn == nil {
+			return &pb.GetTenantConfigResponse{HasErr: true, ErrInfo: errInfo}, nil
+		}
+		defer conn.Close()
+		return pb.NewAutoScaleClient(conn).GetTenantConfig(outCtx, req)
+	}
+	meta := Cm4Http.AutoScaleMeta
+	var conf interface{}
+	if req.GetTidbClusterID() == "" {
+		conf = meta.GetTenantConfigs()
+	} else {
+		var ok bool
+		if conf, ok = meta.GetTenantConfig(req.GetTidbClusterID()); !ok {
+			return &pb.GetTenantConfigResponse{HasErr: true, ErrInfo: "no such tidb-cluster"}, nil
+		}
+	}
+	data, err := json.Marshal(conf)
+	if err != nil {
+		return &pb.GetTenantConfigResponse{HasErr: true, ErrInfo: err.Error()}, nil
+	}
+	return &pb.GetTenantConfigResponse{ConfigJson: string(data)}, nil
+}
+
+// CreateTenantConfig creates paused tenant with config in json, unspecified fields are defaults of auto-registered tenant
+func (s *server) CreateTenantConfig(ctx context.Context, req *pb.SetTenantConfigRequest) (*pb.TenantConfigResponse, error) {
+	return changeTenantConfig(ctx, "CreateTenantConfig", req.GetTidbClusterID(), func(c pb.AutoScaleClient, outCtx context.Context) (*pb.TenantConfigResponse, error) {
+		return c.CreateTenantConfig(outCtx, req)
+	}, func(meta *AutoScaleMeta) error {
+		return meta.CreateTenantConfigFromJson(req.GetTidbClusterID(), []byte(req.GetConfigJson()))
+	})
+}
+
+// UpdateTenantConfig changes fields of tenant's config specified in json
+func (s *server) UpdateTenantConfig(ctx context.Context, req *pb.SetTenantConfigRequest) (*pb.TenantConfigResponse, error) {
+	return changeTenantConfig(ctx, "UpdateTenantConfig", req.GetTidbClusterID(), func(c pb.AutoScaleClient, outCtx context.Context) (*pb.TenantConfigResponse, error) {
+		return c.UpdateTenantConfig(outCtx, req)
+	}, func(meta *AutoScaleMeta) error {
+		return meta.PatchTenantConfig(req.GetTidbClusterID(), []byte(req.GetConfigJson()))
+	})
+}
+
+// DeleteTenantConfig removes paused tenant
+func (s *server) DeleteTenantConfig(ctx context.Context, req *pb.DeleteTenantConfigRequest) (*pb.TenantConfigResponse, error) {
+	return changeTenantConfig(ctx, "DeleteTenantConfig", req.GetTidbClusterID(), func(c pb.AutoScaleClient, outCtx context.Context) (*pb.TenantConfigResponse, error) {
+		return c.DeleteTenantConfig(outCtx, req)
+	}, func(meta *AutoScaleMeta) error {
+		return meta.DeleteTenantConfig(req.GetTidbClusterID())
+	})
+}
+
+// changeTenantConfig runs change on leader, or forwards it to leader by forward if this replica is a follower
+func changeTenantConfig(ctx context.Context, api string, tenant string,
+	forward func(c pb.AutoScaleClient, outCtx context.Context) (*pb.TenantConfigResponse, error),
+	change func(meta *AutoScaleMeta) error) (*pb.TenantConfigResponse, error) {
+	st := time.Now()
+	defer func() {
+		MetricOfRpcRequestTenantConfigSeconds.Observe(time.Since(st).Seconds())
+	}()
+	MetricOfRpcRequestTenantConfigCnt.Inc()
+	if !Cm4Http.IsLeader() && !isForwardedGrpcCall(ctx) {
+		conn, outCtx, errInfo := dialLeader(ctx, api)
+		if conn == nil {
+			return &pb.TenantConfigResponse{HasErr: true, ErrInfo: errInfo}, nil
+		}
+		defer conn.Close()
+		return forward(pb.NewAutoScaleClient(conn), outCtx)
+	}
+	Logger.Infof("[grpc]%v, tenantName: %v", api, tenant)
+	ret := &pb.TenantConfigResponse{}
+	if err := change(Cm4Http.AutoScaleMeta); err != nil {
+		Logger.Errorf("[error][grpc]%v fail, tenantName: %v, err: %v", api, tenant, err.Error())
+		ret.HasErr = true
+		ret.ErrInfo = err.Error()
+		if fieldErrs, ok := err.(FieldErrors); ok {
+			for _, fieldErr := range fieldErrs {
+				ret.FieldErrors = append(ret.FieldErrors, &pb.FieldError{Field: fieldErr.Field, Value: fmt.Sprint(fieldErr.Value), Reason: fieldErr.Reason})
+			}
+		}
+	}
+	return ret, nil
+}
+
 func GetTopology(tidbClusterID string) []string {
 	return Cm4Http.AutoScaleMeta.GetTopology(tidbClusterID)
 }
 
 func RunGrpcServer() {
-	listener, err := net.Listen("tcp", ":8091")
+	listener, err := net.Listen("tcp", ":"+GrpcServerPort)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
@@ -0,0 +1,129 @@
+package autoscale
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	ScalePolicyNameCpuThreshold = "cpu-threshold"
+	ScalePolicyNameMultiMetric  = "multi-metric"
+	DefaultScalePolicyName      = ScalePolicyNameCpuThreshold
+)
+
+// TenantScaleSnapshot is the input of ScalePolicy, it is a consistent copy of tenant's state at the beginning of an analyze round
+type TenantScaleSnapshot struct {
+	Name        string
+	CntOfPods   int
+	MinCntOfPod int
+	MaxCntOfPod int
+	Stats       map[MetricsTopic][]AvgSigma              // statistics of each metrics topic, aggregated over all pods of tenant
+	MetricDescs map[MetricsTopic]*DescOfTenantTimeSeries // description of timeseries of each metrics topic
+	Conf        ConfigOfComputeCluster
+}
+
+// ScalePolicy decides how many pods a tenant should have
+type ScalePolicy interface {
+	Name() string
+	// return -1 as target if the tenant should keep its current pods
+	ComputeTargetCntOfPods(snapshot *TenantScaleSnapshot) (int /*target*/, string /*reason*/)
+}
+
+var scalePolicyRegistry sync.Map // map[string]ScalePolicy
+
+func init() {
+	RegisterScalePolicy(&CpuThresholdScalePolicy{})
+	RegisterScalePolicy(&MultiMetricScalePolicy{})
+}
+
+// RegisterScalePolicy makes a policy selectable by ConfigOfComputeCluster.ScalePolicyName, the later one wins if names are duplicated
+func RegisterScalePolicy(policy ScalePolicy) {
+	scalePolicyRegistry.Store(policy.Name(), policy)
+}
+
+// GetScalePolicy returns the policy registered by name, fallback to default policy if the name is empty or unknown
+func GetScalePolicy(name string) ScalePolicy {
+	if name == "" {
+		name = DefaultScalePolicyName
+	}
+	v, ok := scalePolicyRegistry.Load(name)
+	if !ok {
+		Logger.Warnf("[GetScalePolicy]unknown scale policy:%v, use default:%v", name, DefaultScalePolicyName)
+		v, _ = scalePolicyRegistry.Load(DefaultScalePolicyName)
+	}
+	return v.(ScalePolicy)
+}
+
+// CpuThresholdScalePolicy keeps avg cpu usage of tenant's pods between lower and upper threshold of CpuScaleRules
+type CpuThresholdScalePolicy struct{}
+
+func (p *CpuThresholdScalePolicy) Name() string {
+	return ScalePolicyNameCpuThreshold
+}
+
+func (p *CpuThresholdScalePolicy) ComputeTargetCntOfPods(snapshot *TenantScaleSnapshot) (int, string) {
+	stats := snapshot.Stats[MetricsTopicCpu]
+	if len(stats) == 0 {
+		return -1, "empty cpu metric"
+	}
+	cpuusage := stats[0].Avg()
+	minCpuUsageThreshold, maxCpuUsageThreshold := snapshot.Conf.GetLowerAndUpperCpuScaleThreshold()
+	target, _ := computeBestPodsInRuleOfCompute(snapshot.Name, snapshot.CntOfPods, snapshot.MinCntOfPod, snapshot.MaxCntOfPod,
+		cpuusage, snapshot.Conf.GetCoresOfPod(), minCpuUsageThreshold, maxCpuUsageThreshold)
+	return target, fmt.Sprintf("cpu usage per pod:%.3f cores, threshold:[%v, %v]", cpuusage, minCpuUsageThreshold, maxCpuUsageThreshold)
+}
+
+// GetMemBytesOfPod returns memory capacity of a pod, which is the base of memory scale rule
+func GetMemBytesOfPod(coresOfPod int) float64 {
+	return float64(coresOfPod) * float64(DefaultMemGiBPerCore) * 1024 * 1024 * 1024
+}
+
+// MultiMetricScalePolicy computes a target for each of cpu, mem and taskcnt rules, and takes the max of them like k8s HPA does.
+// cpu is always considered (with default thresholds if CpuScaleRules is nil), mem and taskcnt are considered only if their rules are set.
+type MultiMetricScalePolicy struct{}
+
+func (p *MultiMetricScalePolicy) Name() string {
+	return ScalePolicyNameMultiMetric
+}
+
+func (p *MultiMetricScalePolicy) ComputeTargetCntOfPods(snapshot *TenantScaleSnapshot) (int, string) {
+	stats := snapshot.Stats[MetricsTopicCpu]
+	if len(stats) == 0 {
+		return -1, "empty cpu metric"
+	}
+	cpuLower, cpuUpper := snapshot.Conf.GetLowerAndUpperCpuScaleThreshold()
+	target := -1
+	reasons := make([]string, 0, 3)
+	check := func(ruleName string, idx int, capacityOfPod float64, lower float64, upper float64) {
+		if idx >= len(stats) || stats[idx].Cnt() == 0 {
+			reasons = append(reasons, fmt.Sprintf("%v:no metric", ruleName))
+			return
+		}
+		usage := stats[idx].Avg()
+		ruleTarget, _ := computeBestPodsInRule(snapshot.Name, snapshot.CntOfPods, snapshot.MinCntOfPod, snapshot.MaxCntOfPod,
+			usage, capacityOfPod, lower, upper)
+		if ruleTarget == -1 {
+			ruleTarget = snapshot.CntOfPods
+		}
+		reasons = append(reasons, fmt.Sprintf("%v:usage per pod %.3f threshold [%v, %v] target %v", ruleName, usage, lower*capacityOfPod, upper*capacityOfPod, ruleTarget))
+		target = MaxInt(target, ruleTarget)
+	}
+
+	coresOfPod := snapshot.Conf.GetCoresOfPod()
+	check(ScaleRuleNameCpu, MetricsIdxOfCpu, float64(coresOfPod), cpuLower, cpuUpper)
+	if snapshot.Conf.MemScaleRules != nil {
+		lower, upper := snapshot.Conf.MemScaleRules.GetLowerAndUpperLimit()
+		check(ScaleRuleNameMem, MetricsIdxOfMem, GetMemBytesOfPod(coresOfPod), lower, upper)
+	}
+	if snapshot.Conf.TaskCntScaleRules != nil {
+		lower, upper := snapshot.Conf.TaskCntScaleRules.GetLowerAndUpperLimit()
+		check(ScaleRuleNameTaskCnt, MetricsIdxOfTaskCnt, 1, lower, upper)
+	}
+
+	reason := strings.Join(reasons, "; ")
+	if target == -1 || target == snapshot.CntOfPods {
+		return -1, reason
+	}
+	return target, reason
+}
@@ -0,0 +1,108 @@
+package autoscale
+
+import (
+	"testing"
+)
+
+type fixedScalePolicy struct {
+	target int
+}
+
+func (p *fixedScalePolicy) Name() string {
+	return "fixed-for-test"
+}
+
+func (p *fixedScalePolicy) ComputeTargetCntOfPods(snapshot *TenantScaleSnapshot) (int, string) {
+	return p.target, "fixed"
+}
+
+func newCpuSnapshot4Test(cntOfPods int, cpuRatio float64, minRatioPercent int, maxRatioPercent int) *TenantScaleSnapshot {
+	stats := make([]AvgSigma, CapacityOfStaticsAvgSigma)
+	stats[0].Add(ComputeCpuUsageCoresPerPod(cpuRatio))
+	rule := NewCpuScaleRule(minRatioPercent, maxRatioPercent, "test")
+	return &TenantScaleSnapshot{
+		Name:        "test1",
+		CntOfPods:   cntOfPods,
+		MinCntOfPod: 1,
+		MaxCntOfPod: 4,
+		Stats:       map[MetricsTopic][]AvgSigma{MetricsTopicCpu: stats},
+		Conf: ConfigOfComputeCluster{
+			CpuScaleRules:       rule,
+			ConfigOfTiDBCluster: &ConfigOfTiDBCluster{Name: "test1"},
+		},
+	}
+}
+
+func newMultiMetricSnapshot4Test(cntOfPods int, cpuRatio float64, memRatio float64, taskCnt float64) *TenantScaleSnapshot {
+	ret := newCpuSnapshot4Test(cntOfPods, cpuRatio, 60, 80)
+	ret.Stats[MetricsTopicCpu][MetricsIdxOfMem].Add(memRatio * GetMemBytesOfPod(DefaultCoreOfPod))
+	ret.Stats[MetricsTopicCpu][MetricsIdxOfTaskCnt].Add(taskCnt)
+	ret.Conf.MemScaleRules = NewMemScaleRule(50, 80, "test")
+	ret.Conf.TaskCntScaleRules = NewTaskCntScaleRule(2, 10, "test")
+	return ret
+}
+
+func TestScalePolicyRegistry(t *testing.T) {
+	InitTestEnv()
+	assertEqual(t, GetScalePolicy("").Name(), DefaultScalePolicyName)
+	// multi-metric is opt-in, existing tenants keep scaling by cpu
+	assertEqual(t, DefaultScalePolicyName, ScalePolicyNameCpuThreshold)
+	assertEqual(t, GetScalePolicy("no-such-policy").Name(), DefaultScalePolicyName)
+
+	RegisterScalePolicy(&fixedScalePolicy{target: 3})
+	policy := GetScalePolicy("fixed-for-test")
+	assertEqual(t, policy.Name(), "fixed-for-test")
+	target, _ := policy.ComputeTargetCntOfPods(newCpuSnapshot4Test(1, 0.5, 60, 80))
+	assertEqual(t, target, 3)
+}
+
+func TestCpuThresholdScalePolicy(t *testing.T) {
+	InitTestEnv()
+	policy := GetScalePolicy(ScalePolicyNameCpuThreshold)
+
+	target, _ := policy.ComputeTargetCntOfPods(newCpuSnapshot4Test(1, 1.0, 60, 80))
+	assertEqual(t, target, 2)
+
+	target, _ = policy.ComputeTargetCntOfPods(newCpuSnapshot4Test(2, 0.7, 60, 80))
+	assertEqual(t, target, -1)
+
+	target, _ = policy.ComputeTargetCntOfPods(newCpuSnapshot4Test(2, 0.1, 60, 80))
+	assertEqual(t, target, 1)
+
+	// empty metric
+	snapshot := newCpuSnapshot4Test(2, 0.1, 60, 80)
+	snapshot.Stats = nil
+	target, _ = policy.ComputeTargetCntOfPods(snapshot)
+	assertEqual(t, target, -1)
+}
+
+func TestMultiMetricScalePolicy(t *testing.T) {
+	InitTestEnv()
+	policy := GetScalePolicy(ScalePolicyNameMultiMetric)
+
+	// all metrics are in range
+	target, _ := policy.ComputeTargetCntOfPods(newMultiMetricSnapshot4Test(2, 0.7, 0.6, 5))
+	assertEqual(t, target, -1)
+
+	// only memory is over threshold
+	target, _ = policy.ComputeTargetCntOfPods(newMultiMetricSnapshot4Test(2, 0.7, 0.95, 5))
+	assertEqual(t, target, 3)
+
+	// task count asks for more pods than cpu
+	target, _ = policy.ComputeTargetCntOfPods(newMultiMetricSnapshot4Test(1, 0.9, 0.6, 35))
+	assertEqual(t, target, 4)
+
+	// cpu wants to scale in, but memory keeps current pods
+	target, _ = policy.ComputeTargetCntOfPods(newMultiMetricSnapshot4Test(2, 0.1, 0.6, 5))
+	assertEqual(t, target, -1)
+
+	// all metrics want to scale in
+	target, _ = policy.ComputeTargetCntOfPods(newMultiMetricSnapshot4Test(2, 0.1, 0.1, 0))
+	assertEqual(t, target, 1)
+
+	// missing metrics are ignored, behave the same as cpu-threshold policy
+	snapshot := newCpuSnapshot4Test(2, 0.1, 60, 80)
+	snapshot.Conf.MemScaleRules = NewMemScaleRule(50, 80, "test")
+	target, _ = policy.ComputeTargetCntOfPods(snapshot)
+	assertEqual(t, target, 1)
+}
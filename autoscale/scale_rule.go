@@ -35,23 +35,29 @@ func ComputeBestPodsInRuleOfCompute(tenantDesc *TenantDesc, cpuUsageCoresPerPod
 		Logger.Infof("[ComputeBestPodsInRuleOfCompute]tenantDesc == nil")
 		return -1, 0
 	}
+	return computeBestPodsInRuleOfCompute(tenantDesc.Name, tenantDesc.GetCntOfPods(), tenantDesc.GetMinCntOfPod(), tenantDesc.GetMaxCntOfPod(),
+		cpuUsageCoresPerPod, tenantDesc.GetCoresOfPod(), cpuLowerlimit, cpuUpperLimit)
+}
+
+// same as ComputeBestPodsInRuleOfCompute, but works on a snapshot of pod counts instead of a live TenantDesc
+func computeBestPodsInRuleOfCompute(tenantname string, oldCntOfPods int, minCntOfPods int, maxCntOfPods int, cpuUsageCoresPerPod float64, coresOfPod int, cpuLowerlimit float64, cpuUpperLimit float64) (int, int /*delta*/) {
+	return computeBestPodsInRule(tenantname, oldCntOfPods, minCntOfPods, maxCntOfPods, cpuUsageCoresPerPod, float64(coresOfPod), cpuLowerlimit, cpuUpperLimit)
+}
+
+// generic form of the compute rule, for any metric whose usage grows linearly with load and is shared evenly by pods.
+// capacityOfPod is the amount of metric one pod owns, e.g. cores for cpu, bytes for memory, and lower/upper limits are ratios of it.
+func computeBestPodsInRule(tenantname string, oldCntOfPods int, minCntOfPods int, maxCntOfPods int, cpuUsageCoresPerPod float64, capacityOfPod float64, cpuLowerlimit float64, cpuUpperLimit float64) (int, int /*delta*/) {
 	if cpuLowerlimit >= cpuUpperLimit {
 		Logger.Errorf("[ComputeBestPodsInRuleOfCompute]cpuLowerlimit >= cpuUpperLimit")
 		return -1, 0
 	}
-	tenantname := tenantDesc.Name
-	coreOfPod := DefaultCoreOfPod
 	lowerLimitOfGlobalPercentage := cpuLowerlimit
 	upperlimitOfGlobalPercentage := cpuUpperLimit
-	lowLimitOfCpuUsage := float64(coreOfPod) * lowerLimitOfGlobalPercentage
-	upLimitOfCpuUsage := float64(coreOfPod) * upperlimitOfGlobalPercentage
+	lowLimitOfCpuUsage := capacityOfPod * lowerLimitOfGlobalPercentage
+	upLimitOfCpuUsage := capacityOfPod * upperlimitOfGlobalPercentage
 	if cpuUsageCoresPerPod >= lowLimitOfCpuUsage && cpuUsageCoresPerPod <= upLimitOfCpuUsage {
 		return -1, 0
 	} else {
-		// mu.Lock()
-		oldCntOfPods := tenantDesc.GetCntOfPods()
-		minCntOfPods := tenantDesc.GetMinCntOfPod()
-		maxCntOfPods := tenantDesc.GetMaxCntOfPod()
 		if lowLimitOfCpuUsage+upLimitOfCpuUsage == 0 {
 			Logger.Infof("[ComputeBestPodsInRuleOfCompute][%v]case#1: lowLimitOfCpuUsage+upLimitOfCpuUsage == 0", tenantname)
 			return -1, 0
@@ -59,14 +65,14 @@ func ComputeBestPodsInRuleOfCompute(tenantDesc *TenantDesc, cpuUsageCoresPerPod
 		logicalTargetCpuUsageInGlobalPercentage := (lowerLimitOfGlobalPercentage + upperlimitOfGlobalPercentage) / 2
 		// cpuusage * oldCntOfPods
 		logicalTargetCpuCores := cpuUsageCoresPerPod * float64(oldCntOfPods) / logicalTargetCpuUsageInGlobalPercentage
-		logicalTargetCntOfPod := logicalTargetCpuCores / float64(coreOfPod)
+		logicalTargetCntOfPod := logicalTargetCpuCores / capacityOfPod
 		var targetCntOfPod int
 		if logicalTargetCntOfPod > float64(oldCntOfPods) && logicalTargetCntOfPod < float64(oldCntOfPods)+1 {
 			targetCntOfPod = int(logicalTargetCntOfPod + 0.99)
 		} else if logicalTargetCntOfPod < float64(oldCntOfPods) && logicalTargetCntOfPod > float64(oldCntOfPods)-1 {
 			targetCntOfPod = int(logicalTargetCntOfPod)
 		} else {
-			targetCntOfPod = int(math.Round(logicalTargetCpuCores / float64(coreOfPod)))
+			targetCntOfPod = int(math.Round(logicalTargetCpuCores / capacityOfPod))
 		}
 		targetCntOfPod = MaxInt(targetCntOfPod, 1)
 		targetCpuUsageCoresPerPod := cpuUsageCoresPerPod * float64(oldCntOfPods) / float64(targetCntOfPod)
@@ -214,3 +214,27 @@ func testComputeBestCoreCommon(t *testing.T, minRatio float64, maxRatio float64)
 	t.Logf("[TestComputeBestCore2]stats: %v %v %v %v %v\n", cnt1, cnt2, cnt3, cnt4, cnt5)
 
 }
+
+func TestLimitScaleStep(t *testing.T) {
+	InitTestEnv()
+	conf := ConfigOfComputeCluster{}
+	assertEqual(t, conf.LimitScaleStep(1, 8), 8)
+	assertEqual(t, conf.LimitScaleStep(8, 1), 1)
+
+	conf.MaxScaleUpStep = 2
+	conf.MaxScaleDownStep = 3
+	assertEqual(t, conf.LimitScaleStep(1, 8), 3)
+	assertEqual(t, conf.LimitScaleStep(1, 2), 2)
+	assertEqual(t, conf.LimitScaleStep(8, 1), 5)
+	assertEqual(t, conf.LimitScaleStep(4, 4), 4)
+
+	// the most restrictive one wins
+	conf.MaxScaleUpStepPercent = 50
+	conf.MaxScaleDownStepPercent = 25
+	assertEqual(t, conf.LimitScaleStep(2, 8), 3)
+	assertEqual(t, conf.LimitScaleStep(8, 4), 6)
+	assertEqual(t, conf.LimitScaleStep(8, 16), 10)
+	// at least one pod per resize
+	assertEqual(t, conf.LimitScaleStep(1, 4), 2)
+	assertEqual(t, conf.LimitScaleStep(2, 1), 1)
+}
@@ -0,0 +1,57 @@
+package autoscale
+
+import "fmt"
+
+type scaleRecommendation struct {
+	ts     int64
+	target int
+}
+
+// ScaleStabilizer smooths targets of ScalePolicy to prevent pods from being unassigned and reassigned frequently.
+//  1. scale out is skipped if the last resize happened within the cooldown
+//  2. scale in uses the highest recommendation within the stabilization window, like k8s HPA does
+type ScaleStabilizer struct {
+	recommendations []scaleRecommendation
+}
+
+// return -1 as target if the tenant should keep its current pods
+func (s *ScaleStabilizer) Stabilize(now int64, cntOfPods int, target int, lastResizeTs int64, scaleUpCooldownSec int, scaleDownStabilizationSec int) (int, string) {
+	recommendation := target
+	if recommendation == -1 {
+		recommendation = cntOfPods
+	}
+	validRecommendations := make([]scaleRecommendation, 0, len(s.recommendations)+1)
+	for _, r := range s.recommendations {
+		if r.ts > now-int64(scaleDownStabilizationSec) {
+			validRecommendations = append(validRecommendations, r)
+		}
+	}
+	validRecommendations = append(validRecommendations, scaleRecommendation{ts: now, target: recommendation})
+	s.recommendations = validRecommendations
+
+	if target == -1 || target == cntOfPods {
+		return -1, ""
+	}
+	if target > cntOfPods {
+		if lastResizeTs != 0 && now-lastResizeTs < int64(scaleUpCooldownSec) {
+			return -1, fmt.Sprintf("scale out is in cooldown, %vs since last resize, cooldown:%vs", now-lastResizeTs, scaleUpCooldownSec)
+		}
+		return target, ""
+	}
+
+	stabilizedTarget := target
+	for _, r := range s.recommendations {
+		stabilizedTarget = MaxInt(stabilizedTarget, r.target)
+	}
+	if stabilizedTarget >= cntOfPods {
+		return -1, fmt.Sprintf("scale in is stabilized, highest recommendation in last %vs is %v", scaleDownStabilizationSec, stabilizedTarget)
+	}
+	if stabilizedTarget != target {
+		return stabilizedTarget, fmt.Sprintf("scale in is stabilized from %v to %v", target, stabilizedTarget)
+	}
+	return target, ""
+}
+
+func (s *ScaleStabilizer) Reset() {
+	s.recommendations = nil
+}
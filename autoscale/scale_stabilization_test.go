@@ -0,0 +1,50 @@
+package autoscale
+
+import (
+	"testing"
+)
+
+func TestScaleStabilizerCooldown(t *testing.T) {
+	InitTestEnv()
+	s := &ScaleStabilizer{}
+	// no resize yet
+	target, _ := s.Stabilize(1000, 1, 2, 0, 60, 300)
+	assertEqual(t, target, 2)
+
+	// resized 30s ago, scale out is held
+	target, reason := s.Stabilize(1030, 2, 3, 1000, 60, 300)
+	assertEqual(t, target, -1)
+	assertEqual(t, reason != "", true)
+
+	// cooldown passed
+	target, _ = s.Stabilize(1060, 2, 3, 1000, 60, 300)
+	assertEqual(t, target, 3)
+
+	// zero cooldown never holds scale out
+	s.Reset()
+	target, _ = s.Stabilize(1001, 2, 3, 1000, 0, 300)
+	assertEqual(t, target, 3)
+}
+
+func TestScaleStabilizerScaleDownWindow(t *testing.T) {
+	InitTestEnv()
+	s := &ScaleStabilizer{}
+	target, _ := s.Stabilize(1000, 4, -1, 0, 0, 300)
+	assertEqual(t, target, -1)
+
+	// the recommendation of keeping 4 pods is still in window
+	target, _ = s.Stabilize(1100, 4, 2, 0, 0, 300)
+	assertEqual(t, target, -1)
+	target, _ = s.Stabilize(1200, 4, 3, 0, 0, 300)
+	assertEqual(t, target, -1)
+
+	// keeping 4 pods is out of window, the highest remaining recommendation is 3
+	target, _ = s.Stabilize(1300, 4, 1, 0, 0, 300)
+	assertEqual(t, target, 3)
+
+	// zero window scales in immediately
+	s.Reset()
+	s.Stabilize(1000, 4, -1, 0, 0, 0)
+	target, _ = s.Stabilize(1001, 4, 1, 0, 0, 0)
+	assertEqual(t, target, 1)
+}
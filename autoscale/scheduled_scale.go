@@ -0,0 +1,252 @@
+package autoscale
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// scanning back for the start of an active schedule is in day step, limit the duration to keep it cheap
+	MaxDurationSecOfScheduledScaleRule = 7 * 24 * 3600
+)
+
+// CronSchedule is a standard 5-field cron expression: minute hour day-of-month month day-of-week.
+// each field supports "*", "a", "a-b", "*/n", "a-b/n" and comma separated lists of them.
+type CronSchedule struct {
+	minutes     []bool
+	hours       []bool
+	daysOfMonth []bool
+	months      []bool
+	daysOfWeek  []bool
+	domIsStar   bool
+	dowIsStar   bool
+}
+
+func parseCronField(field string, lo int, hi int) ([]bool, bool, error) {
+	ret := make([]bool, hi+1)
+	isStar := field == "*"
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[idx+1:])
+			if err != nil || step <= 0 {
+				return nil, false, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:idx]
+		}
+		begin, end := lo, hi
+		if part != "*" {
+			var err error
+			if idx := strings.Index(part, "-"); idx >= 0 {
+				begin, err = strconv.Atoi(part[:idx])
+				if err == nil {
+					end, err = strconv.Atoi(part[idx+1:])
+				}
+			} else {
+				begin, err = strconv.Atoi(part)
+				end = begin
+			}
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid value %q", part)
+			}
+		}
+		if begin < lo || end > hi || begin > end {
+			return nil, false, fmt.Errorf("value %q out of range [%v, %v]", part, lo, hi)
+		}
+		for i := begin; i <= end; i += step {
+			ret[i] = true
+		}
+	}
+	return ret, isStar, nil
+}
+
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q should have 5 fields, got %v", expr, len(fields))
+	}
+	ret := &CronSchedule{}
+	var err error
+	if ret.minutes, _, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute field of %q: %v", expr, err)
+	}
+	if ret.hours, _, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour field of %q: %v", expr, err)
+	}
+	if ret.daysOfMonth, ret.domIsStar, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day-of-month field of %q: %v", expr, err)
+	}
+	if ret.months, _, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month field of %q: %v", expr, err)
+	}
+	if ret.daysOfWeek, ret.dowIsStar, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day-of-week field of %q: %v", expr, err)
+	}
+	if ret.daysOfWeek[7] { // both 0 and 7 are sunday
+		ret.daysOfWeek[0] = true
+	}
+	return ret, nil
+}
+
+// Match reports whether the minute of t is a fire time of the schedule
+func (s *CronSchedule) Match(t time.Time) bool {
+	return s.minutes[t.Minute()] && s.hours[t.Hour()] && s.matchDay(t)
+}
+
+func (s *CronSchedule) matchDay(t time.Time) bool {
+	if !s.months[int(t.Month())] {
+		return false
+	}
+	domMatch := s.daysOfMonth[t.Day()]
+	dowMatch := s.daysOfWeek[int(t.Weekday())]
+	// same as cron: if both day fields are restricted, either of them matches
+	if !s.domIsStar && !s.dowIsStar {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Prev returns the latest fire time within (begin, t], false if there is none
+func (s *CronSchedule) Prev(t time.Time, begin time.Time) (time.Time, bool) {
+	loc := t.Location()
+	lastDay := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	for day := lastDay; day.AddDate(0, 0, 1).After(begin); day = day.AddDate(0, 0, -1) {
+		if !s.matchDay(day) {
+			continue
+		}
+		maxHour := 23
+		if day.Equal(lastDay) {
+			maxHour = t.Hour()
+		}
+		for h := maxHour; h >= 0; h-- {
+			if !s.hours[h] {
+				continue
+			}
+			maxMinute := 59
+			if day.Equal(lastDay) && h == t.Hour() {
+				maxMinute = t.Minute()
+			}
+			for m := maxMinute; m >= 0; m-- {
+				if !s.minutes[m] {
+					continue
+				}
+				// fire times only get earlier from here
+				fireTime := time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, loc)
+				return fireTime, fireTime.After(begin)
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// ScheduledScaleRule raises the floor/ceiling of a tenant's cores during [fire time, fire time + DurationSeconds)
+type ScheduledScaleRule struct {
+	Name               string
+	Cron               string // 5-field cron expression, in local time zone of autoscaler
+	DurationSeconds    int
+	MinCores           int  // zero means not raising the floor
+	MaxCores           int  // zero means not raising the ceiling
+	ForceResume        bool // resume paused tenant when the schedule fires, and suppress auto pause while it's active
+	PrewarmLeadSeconds int  // grow prewarm pool this many seconds ahead of the schedule, zero means no prewarm
+
+	parseOnce sync.Once
+	schedule  *CronSchedule // parsed from Cron, nil if Cron is invalid
+}
+
+// getSchedule parses Cron on first call, configs call it when they are loaded so that analyze rounds don't parse it again
+func (r *ScheduledScaleRule) getSchedule() *CronSchedule {
+	r.parseOnce.Do(func() {
+		schedule, err := ParseCronSchedule(r.Cron)
+		if err != nil {
+			Logger.Errorf("[ScheduledScaleRule][%v]invalid cron, err:%v", r.Name, err.Error())
+			return
+		}
+		r.schedule = schedule
+	})
+	return r.schedule
+}
+
+// parseSchedules parses cron of all schedules of conf, it's called when conf is loaded
+func (c *ConfigOfComputeCluster) parseSchedules() {
+	for _, rule := range c.Schedules {
+		if rule != nil {
+			rule.getSchedule()
+		}
+	}
+}
+
+func (r *ScheduledScaleRule) Dump() string {
+	if r == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("ScheduledScaleRule{Name:%v, Cron:%v, DurationSec:%v, MinCores:%v, MaxCores:%v, ForceResume:%v, PrewarmLeadSec:%v}",
+		r.Name, r.Cron, r.DurationSeconds, r.MinCores, r.MaxCores, r.ForceResume, r.PrewarmLeadSeconds)
+}
+
+// IsActiveAt reports whether the schedule fired within (t - DurationSeconds, t]
+func (r *ScheduledScaleRule) IsActiveAt(t time.Time) bool {
+	return r.firedWithin(t, r.DurationSeconds)
+}
+
+// IsUpcomingAt reports whether the schedule is not active at t but will fire within (t, t + PrewarmLeadSeconds]
+func (r *ScheduledScaleRule) IsUpcomingAt(t time.Time) bool {
+	if r.PrewarmLeadSeconds <= 0 || r.IsActiveAt(t) {
+		return false
+	}
+	return r.firedWithin(t.Add(time.Duration(r.PrewarmLeadSeconds)*time.Second), r.PrewarmLeadSeconds)
+}
+
+func (r *ScheduledScaleRule) firedWithin(t time.Time, durationSec int) bool {
+	if durationSec <= 0 {
+		return false
+	}
+	schedule := r.getSchedule()
+	if schedule == nil {
+		return false
+	}
+	durationSec = MinInt(durationSec, MaxDurationSecOfScheduledScaleRule)
+	_, ok := schedule.Prev(t, t.Add(-time.Duration(durationSec)*time.Second))
+	return ok
+}
+
+// ScheduledScaleResult is the merge of all active schedules of a tenant
+type ScheduledScaleResult struct {
+	MinCntOfPod int
+	MaxCntOfPod int
+	ForceResume bool
+	Active      []string // names of active schedules
+}
+
+// GetScheduledScaleResult merges active schedules with the reactive min/max pods of conf
+func (c *ConfigOfComputeCluster) GetScheduledScaleResult(t time.Time, minCntOfPod int, maxCntOfPod int) ScheduledScaleResult {
+	ret := ScheduledScaleResult{MinCntOfPod: minCntOfPod, MaxCntOfPod: maxCntOfPod}
+	coresOfPod := c.GetCoresOfPod()
+	for _, rule := range c.Schedules {
+		if rule == nil || !rule.IsActiveAt(t) {
+			continue
+		}
+		ret.Active = append(ret.Active, rule.Name)
+		ret.MinCntOfPod = MaxInt(ret.MinCntOfPod, rule.MinCores/coresOfPod)
+		ret.MaxCntOfPod = MaxInt(ret.MaxCntOfPod, rule.MaxCores/coresOfPod)
+		ret.ForceResume = ret.ForceResume || rule.ForceResume
+	}
+	ret.MaxCntOfPod = MaxInt(ret.MaxCntOfPod, ret.MinCntOfPod)
+	return ret
+}
+
+// GetUpcomingScheduledCntOfPod returns the max floor of pods among schedules which will fire soon, zero if there is none
+func (c *ConfigOfComputeCluster) GetUpcomingScheduledCntOfPod(t time.Time) int {
+	ret := 0
+	coresOfPod := c.GetCoresOfPod()
+	for _, rule := range c.Schedules {
+		if rule == nil || !rule.IsUpcomingAt(t) {
+			continue
+		}
+		ret = MaxInt(ret, rule.MinCores/coresOfPod)
+	}
+	return ret
+}
@@ -0,0 +1,76 @@
+package autoscale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	InitTestEnv()
+	s, err := ParseCronSchedule("30 9-17/4 * * 1-5")
+	assertEqual(t, err, nil)
+	// 2023-03-06 is a monday
+	assertEqual(t, s.Match(time.Date(2023, 3, 6, 9, 30, 0, 0, time.Local)), true)
+	assertEqual(t, s.Match(time.Date(2023, 3, 6, 13, 30, 0, 0, time.Local)), true)
+	assertEqual(t, s.Match(time.Date(2023, 3, 6, 11, 30, 0, 0, time.Local)), false)
+	assertEqual(t, s.Match(time.Date(2023, 3, 6, 9, 31, 0, 0, time.Local)), false)
+	assertEqual(t, s.Match(time.Date(2023, 3, 5, 9, 30, 0, 0, time.Local)), false)
+
+	// both day fields are restricted, either matches
+	s, err = ParseCronSchedule("0 0 1 * 0,7")
+	assertEqual(t, err, nil)
+	assertEqual(t, s.Match(time.Date(2023, 3, 1, 0, 0, 0, 0, time.Local)), true)
+	assertEqual(t, s.Match(time.Date(2023, 3, 5, 0, 0, 0, 0, time.Local)), true)
+	assertEqual(t, s.Match(time.Date(2023, 3, 6, 0, 0, 0, 0, time.Local)), false)
+
+	// previous fire time is found across days without scanning every minute
+	s, err = ParseCronSchedule("30 9 * * 1")
+	assertEqual(t, err, nil)
+	prev, ok := s.Prev(time.Date(2023, 3, 12, 8, 0, 0, 0, time.Local), time.Date(2023, 3, 5, 8, 0, 0, 0, time.Local))
+	assertEqual(t, ok, true)
+	assertEqual(t, prev, time.Date(2023, 3, 6, 9, 30, 0, 0, time.Local))
+	_, ok = s.Prev(time.Date(2023, 3, 12, 8, 0, 0, 0, time.Local), time.Date(2023, 3, 6, 9, 30, 0, 0, time.Local))
+	assertEqual(t, ok, false)
+	prev, ok = s.Prev(time.Date(2023, 3, 13, 9, 30, 59, 0, time.Local), time.Date(2023, 3, 13, 9, 0, 0, 0, time.Local))
+	assertEqual(t, ok, true)
+	assertEqual(t, prev, time.Date(2023, 3, 13, 9, 30, 0, 0, time.Local))
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err = ParseCronSchedule(expr)
+		assertEqual(t, err != nil, true)
+	}
+}
+
+func TestScheduledScaleRule(t *testing.T) {
+	InitTestEnv()
+	conf := ConfigOfComputeCluster{
+		MinCores: DefaultCoreOfPod,
+		MaxCores: 2 * DefaultCoreOfPod,
+		Schedules: []*ScheduledScaleRule{
+			{Name: "daily-batch", Cron: "0 2 * * *", DurationSeconds: 3600, MinCores: 3 * DefaultCoreOfPod, MaxCores: 4 * DefaultCoreOfPod, ForceResume: true, PrewarmLeadSeconds: 600},
+		},
+	}
+	rule := conf.Schedules[0]
+	assertEqual(t, rule.IsActiveAt(time.Date(2023, 3, 6, 2, 0, 0, 0, time.Local)), true)
+	assertEqual(t, rule.IsActiveAt(time.Date(2023, 3, 6, 2, 59, 59, 0, time.Local)), true)
+	assertEqual(t, rule.IsActiveAt(time.Date(2023, 3, 6, 3, 0, 0, 0, time.Local)), false)
+	assertEqual(t, rule.IsActiveAt(time.Date(2023, 3, 6, 1, 59, 0, 0, time.Local)), false)
+
+	assertEqual(t, rule.IsUpcomingAt(time.Date(2023, 3, 6, 1, 50, 0, 0, time.Local)), true)
+	assertEqual(t, rule.IsUpcomingAt(time.Date(2023, 3, 6, 1, 49, 0, 0, time.Local)), false)
+	assertEqual(t, rule.IsUpcomingAt(time.Date(2023, 3, 6, 2, 10, 0, 0, time.Local)), false)
+
+	result := conf.GetScheduledScaleResult(time.Date(2023, 3, 6, 2, 30, 0, 0, time.Local), 1, 2)
+	assertEqual(t, result.MinCntOfPod, 3)
+	assertEqual(t, result.MaxCntOfPod, 4)
+	assertEqual(t, result.ForceResume, true)
+	assertEqual(t, len(result.Active), 1)
+
+	result = conf.GetScheduledScaleResult(time.Date(2023, 3, 6, 12, 0, 0, 0, time.Local), 1, 2)
+	assertEqual(t, result.MinCntOfPod, 1)
+	assertEqual(t, result.MaxCntOfPod, 2)
+	assertEqual(t, result.ForceResume, false)
+
+	assertEqual(t, conf.GetUpcomingScheduledCntOfPod(time.Date(2023, 3, 6, 1, 55, 0, 0, time.Local)), 3)
+	assertEqual(t, conf.GetUpcomingScheduledCntOfPod(time.Date(2023, 3, 6, 12, 0, 0, 0, time.Local)), 0)
+}
@@ -22,10 +22,6 @@ var HardCodeEnvTidbStatusAddr string
 var HardCodeEnvPdAddr string
 var HardCodeSupervisorImage string
 
-func AssignTenantHardCodeArgs(podIP string, tenantName string) (resp *supervisor.Result, err error) {
-	return AssignTenant(podIP, tenantName, HardCodeEnvTidbStatusAddr, HardCodeEnvPdAddr)
-}
-
 func AssignTenant(podIP string, tenantName string, tidbStatusAddr string, pdAddr string) (resp *supervisor.Result, err error) {
 	start := time.Now()
 	MetricOfSupervisorClientRequestAssignTenantCnt.Inc()
@@ -0,0 +1,152 @@
+package autoscale
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GetTenantConfig returns the latest config of tenant, which may not be reloaded by analyze task yet
+func (c *AutoScaleMeta) GetTenantConfig(tenant string) (ConfigOfComputeCluster, bool) {
+	if holder := c.ConfigManager.GetHolder(tenant); holder != nil {
+		return holder.DeepCopy(), true
+	}
+	tenantDesc := c.GetTenantDesc(tenant)
+	if tenantDesc == nil {
+		return ConfigOfComputeCluster{}, false
+	}
+	return tenantDesc.GetConf(), true
+}
+
+// GetTenantConfigs returns latest configs of all tenants
+func (c *AutoScaleMeta) GetTenantConfigs() map[string]ConfigOfComputeCluster {
+	ret := c.ConfigManager.GetConfigs()
+	for _, tenant := range c.GetTenants() {
+		if _, ok := ret[tenant.Name]; !ok {
+			ret[tenant.Name] = tenant.GetConf()
+		}
+	}
+	return ret
+}
+
+// CloneConfigOfComputeCluster deep copies conf, including the rules referenced by pointers
+func CloneConfigOfComputeCluster(conf ConfigOfComputeCluster) (ConfigOfComputeCluster, error) {
+	var ret ConfigOfComputeCluster
+	data, err := json.Marshal(conf)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
+
+// fillConfigOfTiDBCluster names tidb cluster after tenant and fills default addresses, on a copy since it may be shared
+func fillConfigOfTiDBCluster(tenant string, conf *ConfigOfComputeCluster) {
+	tidbConf := ConfigOfTiDBCluster{}
+	if conf.ConfigOfTiDBCluster != nil {
+		tidbConf = *conf.ConfigOfTiDBCluster
+	}
+	tidbConf.Name = tenant
+	tidbConf.FillDefaultAddrs()
+	conf.ConfigOfTiDBCluster = &tidbConf
+}
+
+// CreateTenantConfig sets up a new tenant in paused state, it's resumed on demand by ResumeAndGetTopology
+// FieldErrors is returned if conf is invalid
+func (c *AutoScaleMeta) CreateTenantConfig(tenant string, conf ConfigOfComputeCluster) error {
+	if tenant == "" {
+		return fmt.Errorf("empty tenant name")
+	}
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+	holder := &ConfigOfComputeClusterHolder{}
+	holder.Update(conf)
+	if !c.SetupTenantWithConfig(tenant, holder, TenantStatePaused) {
+		return fmt.Errorf("tenant %v already exists", tenant)
+	}
+	Logger.Infof("[TenantConfig][%v]created, conf:%v", tenant, holder.Config.Dump())
+	return nil
+}
+
+// CreateTenantConfigFromJson creates tenant with config in data, unspecified fields are defaults of auto-registered tenant
+func (c *AutoScaleMeta) CreateTenantConfigFromJson(tenant string, data []byte) error {
+	conf := NewDefaultConfigOfComputeCluster(tenant, DefaultMinCntOfPod, DefaultMaxCntOfPod)
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return fmt.Errorf("invalid config: %v", err.Error())
+	}
+	return c.CreateTenantConfig(tenant, conf)
+}
+
+// PatchTenantConfig updates fields of tenant's config which are specified in data
+func (c *AutoScaleMeta) PatchTenantConfig(tenant string, data []byte) error {
+	oldConf, ok := c.GetTenantConfig(tenant)
+	if !ok {
+		return fmt.Errorf("tenant %v does not exist", tenant)
+	}
+	// decode into a deep copy, since rules are pointers shared with the current config
+	conf, err := CloneConfigOfComputeCluster(oldConf)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, &conf); err != nil {
+		return fmt.Errorf("invalid config: %v", err.Error())
+	}
+	return c.UpdateTenantConfig(tenant, conf)
+}
+
+// UpdateTenantConfig replaces config of tenant, analyze task of tenant reloads it before next round
+func (c *AutoScaleMeta) UpdateTenantConfig(tenant string, conf ConfigOfComputeCluster) error {
+	tenantDesc := c.GetTenantDesc(tenant)
+	if tenantDesc == nil {
+		return fmt.Errorf("tenant %v does not exist", tenant)
+	}
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+	// pods of tenant are taken from pool of its class, they can't be moved to another class
+	if podClass := NormalizePodClassName(conf.PodClass); podClass != tenantDesc.GetPodClass() && tenantDesc.GetCntOfPods() > 0 {
+		return FieldErrors{{Field: "PodClass", Value: conf.PodClass, Reason: fmt.Sprintf("can't be changed from %v while tenant has pods", tenantDesc.GetPodClass())}}
+	}
+	fillConfigOfTiDBCluster(tenant, &conf)
+	holder := tenantDesc.getOrAttachConfHolder(c.ConfigManager)
+	holder.Update(conf)
+	Logger.Infof("[TenantConfig][%v]updated, conf:%v", tenant, conf.Dump())
+	return nil
+}
+
+// DeleteTenantConfig removes tenant, only paused tenant can be removed since its pods would be leaked otherwise
+func (c *AutoScaleMeta) DeleteTenantConfig(tenant string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tenantDesc, ok := c.tenantMap[tenant]
+	if !ok {
+		return fmt.Errorf("tenant %v does not exist", tenant)
+	}
+	if state := tenantDesc.GetState(); state != TenantStatePaused || tenantDesc.GetCntOfPods() > 0 {
+		return fmt.Errorf("tenant %v is not paused, state:%v pods:%v", tenant, TenantState2String(state), tenantDesc.GetCntOfPods())
+	}
+	c.removeTenantWithoutLock(tenant)
+	return nil
+}
+
+// RemoveDryRunTenant removes dry-run tenant in any state, since it's never paused.
+// Its pods are never moved by autoscale, so they are left assigned to its tidb cluster.
+func (c *AutoScaleMeta) RemoveDryRunTenant(tenant string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tenantDesc, ok := c.tenantMap[tenant]
+	if !ok {
+		return
+	}
+	if cnt := tenantDesc.GetCntOfPods(); cnt > 0 {
+		Logger.Warnf("[TenantConfig][%v]dry-run tenant is removed with %v pods left assigned: %v", tenant, cnt, tenantDesc.GetPodNames())
+	}
+	c.removeTenantWithoutLock(tenant)
+}
+
+func (c *AutoScaleMeta) removeTenantWithoutLock(tenant string) {
+	delete(c.tenantMap, tenant)
+	c.ConfigManager.RemoveHolder(tenant)
+	c.notifyTenantStoreChanged()
+	Logger.Infof("[TenantConfig][%v]deleted", tenant)
+}
@@ -0,0 +1,253 @@
+package autoscale
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/tikv/pd/auto_scale_proto"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTenantConfigCRUD(t *testing.T) {
+	InitTestEnv()
+	meta := newMeta4TenantStoreTest(nil)
+
+	conf := NewDefaultConfigOfComputeCluster("t1", 1, 4)
+	conf.CpuScaleRules = NewCpuScaleRule(40, 80, "t1")
+	assertEqual(t, meta.CreateTenantConfig("t1", conf), nil)
+	assertEqual(t, meta.CreateTenantConfig("t1", conf) != nil, true)
+	assertEqual(t, meta.GetTenantDesc("t1").GetState(), int32(TenantStatePaused))
+
+	// update is applied on next reload of tenant
+	tenant := meta.GetTenantDesc("t1")
+	newConf, ok := meta.GetTenantConfig("t1")
+	assertEqual(t, ok, true)
+	newConf, err := CloneConfigOfComputeCluster(newConf)
+	assertEqual(t, err, nil)
+	newConf.MaxCores = 8 * DefaultCoreOfPod
+	newConf.CpuScaleRules.Threashold.Max = 90
+	assertEqual(t, meta.UpdateTenantConfig("t1", newConf), nil)
+	assertEqual(t, tenant.GetMaxCntOfPod(), 4)
+	_, upper := tenant.GetLowerAndUpperCpuScaleThreshold()
+	assertEqual(t, upper, 0.8)
+	assertEqual(t, tenant.TryToReloadConf(false), true)
+	assertEqual(t, tenant.GetMaxCntOfPod(), 8)
+	_, upper = tenant.GetLowerAndUpperCpuScaleThreshold()
+	assertEqual(t, upper, 0.9)
+	assertEqual(t, meta.UpdateTenantConfig("t2", newConf) != nil, true)
+
+	// auto-registered tenant gets a holder on first update
+	meta.SetupAutoPauseTenantWithPausedState("t2", 1, 4)
+	assertEqual(t, meta.ConfigManager.GetHolder("t2") == nil, true)
+	t2Conf, ok := meta.GetTenantConfig("t2")
+	assertEqual(t, ok, true)
+	t2Conf.Disabled = true
+	assertEqual(t, meta.UpdateTenantConfig("t2", t2Conf), nil)
+	assertEqual(t, meta.GetTenantDesc("t2").TryToReloadConf(false), true)
+	assertEqual(t, meta.GetTenantDesc("t2").IsDisabled(), true)
+	assertEqual(t, len(meta.GetTenantConfigs()), 2)
+
+	// only paused tenant can be deleted
+	tenant.SetState(TenantStateResumed)
+	assertEqual(t, meta.DeleteTenantConfig("t1") != nil, true)
+	tenant.SetState(TenantStatePaused)
+	assertEqual(t, meta.DeleteTenantConfig("t1"), nil)
+	assertEqual(t, meta.GetTenantDesc("t1") == nil, true)
+	_, ok = meta.GetTenantConfig("t1")
+	assertEqual(t, ok, false)
+}
+
+func TestTenantConfigGrpc(t *testing.T) {
+	InitTestEnv()
+	oldCm := Cm4Http
+	defer func() { Cm4Http = oldCm }()
+	Cm4Http = &ClusterManager{AutoScaleMeta: newMeta4TenantStoreTest(nil)}
+	Cm4Http.isLeader.Store(true)
+	s := &server{}
+	ctx := context.Background()
+
+	resp, _ := s.CreateTenantConfig(ctx, &pb.SetTenantConfigRequest{TidbClusterID: "t1", ConfigJson: `{"MaxCores":32}`})
+	assertEqual(t, resp.HasErr, false)
+	resp, _ = s.UpdateTenantConfig(ctx, &pb.SetTenantConfigRequest{TidbClusterID: "t1", ConfigJson: `{"MinCores":64}`})
+	assertEqual(t, resp.HasErr, true)
+	assertEqual(t, len(resp.FieldErrors), 1)
+	assertEqual(t, resp.FieldErrors[0].Field, "MinCores")
+	resp, _ = s.UpdateTenantConfig(ctx, &pb.SetTenantConfigRequest{TidbClusterID: "t1", ConfigJson: `{"MinCores":16,"InitCores":16}`})
+	assertEqual(t, resp.HasErr, false)
+
+	getResp, _ := s.GetTenantConfig(ctx, &pb.GetTenantConfigRequest{TidbClusterID: "t1"})
+	assertEqual(t, getResp.HasErr, false)
+	assertEqual(t, strings.Contains(getResp.ConfigJson, `"MinCores":16`), true)
+	assertEqual(t, strings.Contains(getResp.ConfigJson, `"MaxCores":32`), true)
+
+	resp, _ = s.DeleteTenantConfig(ctx, &pb.DeleteTenantConfigRequest{TidbClusterID: "t1"})
+	assertEqual(t, resp.HasErr, false)
+	getResp, _ = s.GetTenantConfig(ctx, &pb.GetTenantConfigRequest{TidbClusterID: "t1"})
+	assertEqual(t, getResp.HasErr, true)
+}
+
+func newTiFlashTenant4Test(name string, generation int64, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetName(name)
+	obj.SetGeneration(generation)
+	return obj
+}
+
+func TestTiFlashTenantReconcile(t *testing.T) {
+	InitTestEnv()
+	cm := &ClusterManager{AutoScaleMeta: newMeta4TenantStoreTest(nil)}
+	r := newTenantCRDReconciler(cm)
+
+	r.reconcile(newTiFlashTenant4Test("t1", 1, map[string]interface{}{
+		"autoPauseIntervalSeconds": int64(0),
+		"minCores":                 int64(2 * DefaultCoreOfPod),
+		"maxCores":                 int64(6 * DefaultCoreOfPod),
+		"cpuScaleRule":             map[string]interface{}{"min": int64(40), "max": int64(80)},
+		"schedules":                []interface{}{map[string]interface{}{"name": "daily", "cron": "0 9 * * *", "durationSeconds": int64(3600), "minCores": int64(4 * DefaultCoreOfPod)}},
+		"tidbCluster":              map[string]interface{}{"pdAddr": "pd:2379", "tidbStatusAddr": "tidb:10080"},
+	}))
+	tenant := cm.AutoScaleMeta.GetTenantDesc("t1")
+	assertEqual(t, tenant.GetState(), int32(TenantStatePaused))
+	conf := tenant.GetConf()
+	assertEqual(t, conf.AutoPauseIntervalSeconds, 0)
+	assertEqual(t, conf.GetInitCntOfPod(), 2)
+	assertEqual(t, tenant.GetMaxCntOfPod(), 6)
+	assertEqual(t, conf.CpuScaleRules.Threashold.Max, 80)
+	assertEqual(t, len(conf.Schedules), 1)
+	assertEqual(t, conf.Schedules[0].Cron, "0 9 * * *")
+	assertEqual(t, conf.ScaleUpCooldownSeconds, DefaultScaleUpCooldownSeconds)
+	assertEqual(t, conf.ConfigOfTiDBCluster.PD.Addr, "pd:2379")
+	assertEqual(t, conf.ConfigOfTiDBCluster.TidbStatusAddr, "tidb:10080")
+
+	// status-only change is skipped, spec change is applied on next reload
+	r.reconcile(newTiFlashTenant4Test("t1", 1, map[string]interface{}{"maxCores": int64(8 * DefaultCoreOfPod)}))
+	assertEqual(t, tenant.TryToReloadConf(false), false)
+	r.reconcile(newTiFlashTenant4Test("t1", 2, map[string]interface{}{"maxCores": int64(8 * DefaultCoreOfPod)}))
+	assertEqual(t, tenant.TryToReloadConf(false), true)
+	assertEqual(t, tenant.GetMaxCntOfPod(), 8)
+	assertEqual(t, tenant.GetAutoPauseIntervalSec(), DefaultAutoPauseIntervalSeconds)
+
+	status := cm.AutoScaleMeta.buildTiFlashTenantStatus("t1", 2, "")
+	assertEqual(t, status.State, TenantStatePausedString)
+	assertEqual(t, status.PodCnt, 0)
+	assertEqual(t, status.ObservedGeneration, int64(2))
+	assertEqual(t, status.LastScaleTime, "")
+
+	// paused tenant is removed with its resource
+	r.handleDelete("t1")
+	assertEqual(t, cm.AutoScaleMeta.GetTenantDesc("t1") == nil, true)
+	assertEqual(t, len(r.pendingDeletes), 0)
+
+	// dry-run tenant is never paused, it's removed directly
+	r.reconcile(newTiFlashTenant4Test("t2", 1, map[string]interface{}{"dryRun": true}))
+	cm.AutoScaleMeta.GetTenantDesc("t2").SetState(TenantStateResumed)
+	r.handleDelete("t2")
+	assertEqual(t, cm.AutoScaleMeta.GetTenantDesc("t2") == nil, true)
+	assertEqual(t, len(r.pendingDeletes), 0)
+}
+
+func TestAddrsOfTiDBCluster(t *testing.T) {
+	InitTestEnv()
+	oldPdAddr, oldTidbStatusAddr, oldRunMode := HardCodeEnvPdAddr, HardCodeEnvTidbStatusAddr, OptionRunMode
+	defer func() {
+		HardCodeEnvPdAddr, HardCodeEnvTidbStatusAddr, OptionRunMode = oldPdAddr, oldTidbStatusAddr, oldRunMode
+	}()
+	HardCodeEnvPdAddr, HardCodeEnvTidbStatusAddr, OptionRunMode = "", "", RunModeServeless
+	meta := newMeta4TenantStoreTest(nil)
+
+	// no address at all, resume is rejected and tenant stays paused
+	meta.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	_, _, err := meta.GetTenantDesc("t1").GetAddrsOfTiDBCluster()
+	assertEqual(t, err != nil, true)
+	resultChan, ok := meta.AsyncResume("t1", nil)
+	assertEqual(t, ok, true)
+	result := <-resultChan
+	assertEqual(t, result.FailCnt, -1)
+	assertEqual(t, result.Reason, err.Error())
+	assertEqual(t, meta.GetTenantDesc("t1").GetState(), int32(TenantStatePaused))
+
+	// env addresses are defaults of tenants without their own
+	HardCodeEnvPdAddr = "pd-default:2379"
+	meta.SetupAutoPauseTenantWithPausedState("t2", 1, 4)
+	tidbStatusAddr, pdAddr, err := meta.GetTenantDesc("t2").GetAddrsOfTiDBCluster()
+	assertEqual(t, err, nil)
+	assertEqual(t, pdAddr, "pd-default:2379")
+	assertEqual(t, tidbStatusAddr, "")
+
+	conf := NewDefaultConfigOfComputeCluster("t3", 1, 4)
+	conf.ConfigOfTiDBCluster = &ConfigOfTiDBCluster{PD: &ConfigOfPD{Addr: "pd-t3:2379"}, TidbStatusAddr: "tidb-t3:10080"}
+	assertEqual(t, meta.CreateTenantConfig("t3", conf), nil)
+	tidbStatusAddr, pdAddr, err = meta.GetTenantDesc("t3").GetAddrsOfTiDBCluster()
+	assertEqual(t, err, nil)
+	assertEqual(t, pdAddr, "pd-t3:2379")
+	assertEqual(t, tidbStatusAddr, "tidb-t3:10080")
+	assertEqual(t, meta.GetTenantDesc("t3").GetConf().ConfigOfTiDBCluster.Name, "t3")
+
+	// each tenant of dedicated tier has its own tidb cluster
+	OptionRunMode = RunModeDedicated
+	_, _, err = meta.GetTenantDesc("t2").GetAddrsOfTiDBCluster()
+	assertEqual(t, err != nil, true)
+	_, _, err = meta.GetTenantDesc("t3").GetAddrsOfTiDBCluster()
+	assertEqual(t, err, nil)
+}
+
+func TestValidateConfigOfComputeCluster(t *testing.T) {
+	InitTestEnv()
+	conf := NewDefaultConfigOfComputeCluster("t1", 1, 4)
+	conf.CpuScaleRules = NewCpuScaleRule(40, 80, "t1")
+	assertEqual(t, conf.Validate(), nil)
+
+	fieldsOf := func(err error) string {
+		ret := make([]string, 0)
+		for _, fieldErr := range err.(FieldErrors) {
+			ret = append(ret, fieldErr.Field)
+		}
+		return strings.Join(ret, ",")
+	}
+	invalid := conf
+	invalid.MinCores = 3 * DefaultCoreOfPod
+	invalid.InitCores = 2 * DefaultCoreOfPod
+	assertEqual(t, fieldsOf(invalid.Validate()), "InitCores")
+	invalid.MaxCores = 2 * DefaultCoreOfPod
+	assertEqual(t, fieldsOf(invalid.Validate()), "MinCores")
+
+	invalid = conf
+	invalid.MaxCores = 4*DefaultCoreOfPod + 1
+	invalid.WindowSeconds = 30
+	invalid.AutoPauseIntervalSeconds = -1
+	assertEqual(t, fieldsOf(invalid.Validate()), "AutoPauseIntervalSeconds,MaxCores,WindowSeconds")
+
+	// bad thresholds are kept and reported
+	invalid = conf
+	invalid.CpuScaleRules = NewCpuScaleRule(80, 40, "t1")
+	assertEqual(t, invalid.CpuScaleRules != nil, true)
+	assertEqual(t, fieldsOf(invalid.Validate()), "CpuScaleRules.Threashold")
+
+	invalid = conf
+	invalid.ReservedWarmPods = conf.InitCores/DefaultCoreOfPod + 1
+	assertEqual(t, fieldsOf(invalid.Validate()), "ReservedWarmPods")
+
+	// cores of schedules are checked against cores of pod class
+	invalid = conf
+	invalid.Schedules = []*ScheduledScaleRule{
+		{Name: "ok", Cron: "0 9 * * *", DurationSeconds: 3600, MaxCores: 2 * DefaultCoreOfPod},
+		{Name: "bad", Cron: "0 9 * * *", DurationSeconds: -1, MinCores: 2*DefaultCoreOfPod + 1, MaxCores: -DefaultCoreOfPod, PrewarmLeadSeconds: -1},
+		{Name: "min>max", Cron: "0 9 * * *", MinCores: 2 * DefaultCoreOfPod, MaxCores: DefaultCoreOfPod},
+	}
+	assertEqual(t, fieldsOf(invalid.Validate()), "Schedules[1].MinCores,Schedules[1].MaxCores,Schedules[1].DurationSeconds,Schedules[1].PrewarmLeadSeconds,Schedules[2].MinCores")
+
+	// default window is accepted
+	invalid = conf
+	invalid.WindowSeconds = DefaultScaleIntervalSeconds
+	assertEqual(t, invalid.Validate(), nil)
+
+	invalid = conf
+	invalid.ReservedWarmPods = conf.InitCores/DefaultCoreOfPod + 1
+	meta := newMeta4TenantStoreTest(nil)
+	assertEqual(t, meta.CreateTenantConfig("t1", invalid) != nil, true)
+	assertEqual(t, meta.GetTenantDesc("t1") == nil, true)
+	assertEqual(t, meta.CreateTenantConfig("t1", conf), nil)
+	assertEqual(t, meta.UpdateTenantConfig("t1", invalid) != nil, true)
+	assertEqual(t, meta.GetTenantDesc("t1").TryToReloadConf(false), false)
+}
@@ -0,0 +1,360 @@
+package autoscale
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var (
+	TenantCRDEnabled           = false // if enabled, tenants are declared by TiFlashTenant resources in AutoScaleNamespace
+	TenantCRDStatusIntervalSec = 10
+)
+
+var TiFlashTenantGVR = schema.GroupVersionResource{
+	Group:    "autoscale.tiflash.pingcap.com",
+	Version:  "v1alpha1",
+	Resource: "tiflashtenants",
+}
+
+type ScaleRuleSpec struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+type TiDBClusterSpec struct {
+	Version        string `json:"version,omitempty"`
+	PDAddr         string `json:"pdAddr,omitempty"`
+	TidbStatusAddr string `json:"tidbStatusAddr,omitempty"`
+}
+
+// TiFlashTenantSpec mirrors ConfigOfComputeCluster, zero value means default of auto-registered tenant
+type TiFlashTenantSpec struct {
+	Disabled                      bool                  `json:"disabled,omitempty"`
+	AutoPauseIntervalSeconds      *int                  `json:"autoPauseIntervalSeconds,omitempty"` // zero means manual pause
+	MinCores                      int                   `json:"minCores,omitempty"`
+	MaxCores                      int                   `json:"maxCores,omitempty"`
+	InitCores                     int                   `json:"initCores,omitempty"`
+	WindowSeconds                 int                   `json:"windowSeconds,omitempty"`
+	CpuScaleRule                  *ScaleRuleSpec        `json:"cpuScaleRule,omitempty"`
+	MemScaleRule                  *ScaleRuleSpec        `json:"memScaleRule,omitempty"`
+	TaskCntScaleRule              *ScaleRuleSpec        `json:"taskCntScaleRule,omitempty"`
+	ScalePolicy                   string                `json:"scalePolicy,omitempty"`
+	ScaleUpCooldownSeconds        *int                  `json:"scaleUpCooldownSeconds,omitempty"`
+	ScaleDownStabilizationSeconds *int                  `json:"scaleDownStabilizationSeconds,omitempty"`
+	MaxScaleUpStep                int                   `json:"maxScaleUpStep,omitempty"`
+	MaxScaleDownStep              int                   `json:"maxScaleDownStep,omitempty"`
+	MaxScaleUpStepPercent         int                   `json:"maxScaleUpStepPercent,omitempty"`
+	MaxScaleDownStepPercent       int                   `json:"maxScaleDownStepPercent,omitempty"`
+	DryRun                        bool                  `json:"dryRun,omitempty"`
+	Schedules                     []*ScheduledScaleRule `json:"schedules,omitempty"`
+	PredictiveScaleRules          *PredictiveScaleRule  `json:"predictiveScaleRules,omitempty"`
+	TiDBCluster                   TiDBClusterSpec       `json:"tidbCluster,omitempty"`
+	PodClass                      string                `json:"podClass,omitempty"`
+	PriorityClass                 string                `json:"priorityClass,omitempty"`
+	DrainTimeoutSeconds           *int                  `json:"drainTimeoutSeconds,omitempty"` // zero means default, negative means no drain
+	ReservedWarmPods              int                   `json:"reservedWarmPods,omitempty"`
+	PreAssignReservedPods         bool                  `json:"preAssignReservedPods,omitempty"`
+}
+
+type TiFlashTenantStatus struct {
+	State              string   `json:"state"`
+	PodCnt             int      `json:"podCnt"`
+	Topology           []string `json:"topology"`
+	LastScaleTime      string   `json:"lastScaleTime,omitempty"` // RFC3339
+	ObservedGeneration int64    `json:"observedGeneration"`
+	Message            string   `json:"message,omitempty"` // error of last reconcile
+}
+
+// parseTiFlashTenantSpec decodes spec with encoding/json, so that fields of nested rules are matched case-insensitively
+func parseTiFlashTenantSpec(obj *unstructured.Unstructured) (*TiFlashTenantSpec, error) {
+	data, err := json.Marshal(obj.Object["spec"])
+	if err != nil {
+		return nil, err
+	}
+	spec := &TiFlashTenantSpec{}
+	if err = json.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func (s *TiFlashTenantSpec) ToConfig(name string) ConfigOfComputeCluster {
+	conf := NewDefaultConfigOfComputeCluster(name, DefaultMinCntOfPod, DefaultMaxCntOfPod)
+	conf.Disabled = s.Disabled
+	if s.AutoPauseIntervalSeconds != nil {
+		conf.AutoPauseIntervalSeconds = *s.AutoPauseIntervalSeconds
+	}
+	if s.MinCores != 0 {
+		conf.MinCores = s.MinCores
+		conf.InitCores = s.MinCores
+	}
+	if s.MaxCores != 0 {
+		conf.MaxCores = s.MaxCores
+	}
+	if s.InitCores != 0 {
+		conf.InitCores = s.InitCores
+	}
+	if s.WindowSeconds != 0 {
+		conf.WindowSeconds = s.WindowSeconds
+	}
+	if s.CpuScaleRule != nil {
+		conf.CpuScaleRules = NewCpuScaleRule(s.CpuScaleRule.Min, s.CpuScaleRule.Max, name)
+	}
+	if s.MemScaleRule != nil {
+		conf.MemScaleRules = NewMemScaleRule(s.MemScaleRule.Min, s.MemScaleRule.Max, name)
+	}
+	if s.TaskCntScaleRule != nil {
+		conf.TaskCntScaleRules = NewTaskCntScaleRule(s.TaskCntScaleRule.Min, s.TaskCntScaleRule.Max, name)
+	}
+	conf.ScalePolicyName = s.ScalePolicy
+	if s.ScaleUpCooldownSeconds != nil {
+		conf.ScaleUpCooldownSeconds = *s.ScaleUpCooldownSeconds
+	}
+	if s.ScaleDownStabilizationSeconds != nil {
+		conf.ScaleDownStabilizationSeconds = *s.ScaleDownStabilizationSeconds
+	}
+	if s.DrainTimeoutSeconds != nil {
+		conf.DrainTimeoutSeconds = *s.DrainTimeoutSeconds
+	}
+	conf.MaxScaleUpStep = s.MaxScaleUpStep
+	conf.MaxScaleDownStep = s.MaxScaleDownStep
+	conf.MaxScaleUpStepPercent = s.MaxScaleUpStepPercent
+	conf.MaxScaleDownStepPercent = s.MaxScaleDownStepPercent
+	conf.DryRun = s.DryRun
+	conf.PodClass = s.PodClass
+	conf.PriorityClass = s.PriorityClass
+	conf.ReservedWarmPods = s.ReservedWarmPods
+	conf.PreAssignReservedPods = s.PreAssignReservedPods
+	conf.Schedules = s.Schedules
+	conf.PredictiveScaleRules = s.PredictiveScaleRules
+	conf.ConfigOfTiDBCluster = &ConfigOfTiDBCluster{
+		Name:           name,
+		Version:        s.TiDBCluster.Version,
+		TidbStatusAddr: s.TiDBCluster.TidbStatusAddr,
+	}
+	if s.TiDBCluster.PDAddr != "" {
+		conf.ConfigOfTiDBCluster.PD = &ConfigOfPD{Addr: s.TiDBCluster.PDAddr}
+	}
+	return conf
+}
+
+// tenantCRDReconciler reconciles TiFlashTenant resources into AutoScaleMeta, it's only accessed by tenantCRDLoop
+type tenantCRDReconciler struct {
+	cm                  *ClusterManager
+	observedGenerations map[string]int64
+	messages            map[string]string
+	pendingDeletes      map[string]bool   // tenants whose resources are deleted, they are removed after paused
+	lastStatus          map[string]string // last status written back, to skip unchanged ones
+}
+
+func newTenantCRDReconciler(cm *ClusterManager) *tenantCRDReconciler {
+	return &tenantCRDReconciler{
+		cm:                  cm,
+		observedGenerations: make(map[string]int64),
+		messages:            make(map[string]string),
+		pendingDeletes:      make(map[string]bool),
+		lastStatus:          make(map[string]string),
+	}
+}
+
+// checked
+func (r *tenantCRDReconciler) reconcile(obj *unstructured.Unstructured) {
+	name := obj.GetName()
+	generation := obj.GetGeneration()
+	if g, ok := r.observedGenerations[name]; ok && g == generation {
+		return // only status or metadata is changed
+	}
+	r.observedGenerations[name] = generation
+	delete(r.pendingDeletes, name)
+	spec, err := parseTiFlashTenantSpec(obj)
+	if err == nil {
+		conf := spec.ToConfig(name)
+		if r.cm.AutoScaleMeta.GetTenantDesc(name) == nil {
+			err = r.cm.AutoScaleMeta.CreateTenantConfig(name, conf)
+		} else {
+			err = r.cm.AutoScaleMeta.UpdateTenantConfig(name, conf)
+		}
+	}
+	if err != nil {
+		Logger.Errorf("[error][TenantCRD][%v]reconcile fail, generation:%v err:%v", name, generation, err.Error())
+		r.messages[name] = err.Error()
+	} else {
+		Logger.Infof("[TenantCRD][%v]reconciled, generation:%v", name, generation)
+		delete(r.messages, name)
+	}
+}
+
+func (r *tenantCRDReconciler) handleDelete(name string) {
+	Logger.Infof("[TenantCRD][%v]resource is deleted", name)
+	delete(r.observedGenerations, name)
+	delete(r.messages, name)
+	delete(r.lastStatus, name)
+	r.pendingDeletes[name] = true
+	r.tryToDeletePendingTenants()
+}
+
+// checked
+// tryToDeletePendingTenants pauses tenants of deleted resources, and removes them once paused.
+// Dry-run tenants are removed directly, since pause doesn't change them.
+func (r *tenantCRDReconciler) tryToDeletePendingTenants() {
+	for name := range r.pendingDeletes {
+		tenant := r.cm.AutoScaleMeta.GetTenantDesc(name)
+		if tenant == nil {
+			delete(r.pendingDeletes, name)
+			continue
+		}
+		if tenant.IsDryRun() {
+			r.cm.AutoScaleMeta.RemoveDryRunTenant(name)
+			delete(r.pendingDeletes, name)
+			continue
+		}
+		state := tenant.GetState()
+		if state == TenantStatePaused && tenant.GetCntOfPods() == 0 {
+			if err := r.cm.AutoScaleMeta.DeleteTenantConfig(name); err != nil {
+				Logger.Errorf("[error][TenantCRD][%v]delete tenant fail, err:%v", name, err.Error())
+				continue
+			}
+			delete(r.pendingDeletes, name)
+		} else if state == TenantStateResumed {
+			r.cm.pauseTenant(tenant, DecisionContext{Rule: "crd", Reason: "TiFlashTenant is deleted"})
+		}
+	}
+}
+
+func (c *AutoScaleMeta) buildTiFlashTenantStatus(name string, generation int64, message string) TiFlashTenantStatus {
+	ret := TiFlashTenantStatus{ObservedGeneration: generation, Message: message, Topology: []string{}}
+	tenant := c.GetTenantDesc(name)
+	if tenant == nil {
+		ret.State = TenantState2String(TenantStateUnknown)
+		return ret
+	}
+	ret.State = TenantState2String(tenant.GetState())
+	ret.PodCnt = tenant.GetCntOfPods()
+	ret.Topology = c.GetTopology(name)
+	if ts := tenant.GetLastResizeTs(); ts > 0 {
+		ret.LastScaleTime = time.Unix(ts, 0).UTC().Format(time.RFC3339)
+	}
+	return ret
+}
+
+// checked
+// syncStatus writes status back to resources whose status has changed since last sync
+func (r *tenantCRDReconciler) syncStatus() {
+	for name, generation := range r.observedGenerations {
+		status := r.cm.AutoScaleMeta.buildTiFlashTenantStatus(name, generation, r.messages[name])
+		data, err := json.Marshal(map[string]interface{}{"status": status})
+		if err != nil {
+			Logger.Errorf("[error][TenantCRD][%v]marshal status fail, err:%v", name, err.Error())
+			continue
+		}
+		if r.lastStatus[name] == string(data) {
+			continue
+		}
+		_, err = r.cm.DynamicCli.Resource(TiFlashTenantGVR).Namespace(r.cm.Namespace).Patch(context.TODO(), name, types.MergePatchType, data, metav1.PatchOptions{}, "status")
+		if err != nil {
+			Logger.Errorf("[error][TenantCRD][%v]update status fail, err:%v", name, err.Error())
+			continue
+		}
+		r.lastStatus[name] = string(data)
+	}
+}
+
+// loadAll lists all resources and reconciles them, tenants of resources which are gone are deleted
+func (r *tenantCRDReconciler) loadAll() (string, error) {
+	list, err := r.cm.DynamicCli.Resource(TiFlashTenantGVR).Namespace(r.cm.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	existed := make(map[string]bool)
+	for i := range list.Items {
+		existed[list.Items[i].GetName()] = true
+		r.reconcile(&list.Items[i])
+	}
+	for name := range r.observedGenerations {
+		if !existed[name] {
+			r.handleDelete(name)
+		}
+	}
+	Logger.Infof("[TenantCRD]load %v resources", len(list.Items))
+	return list.GetResourceVersion(), nil
+}
+
+// checked
+// tenantCRDLoop watches TiFlashTenant resources like watchPodsLoop, and writes status back periodically
+func (c *ClusterManager) tenantCRDLoop() {
+	c.wg.Add(1)
+	defer c.wg.Done()
+	r := newTenantCRDReconciler(c)
+	ticker := time.NewTicker(time.Duration(TenantCRDStatusIntervalSec) * time.Second)
+	defer ticker.Stop()
+	resourceVersion := ""
+	for {
+		if atomic.LoadInt32(&c.shutdown) != 0 {
+			return
+		}
+		if resourceVersion == "" {
+			var err error
+			if resourceVersion, err = r.loadAll(); err != nil {
+				Logger.Errorf("[error][TenantCRD]list resources fail, err:%v", err.Error())
+				time.Sleep(time.Second)
+				continue
+			}
+		}
+		watcher, err := c.DynamicCli.Resource(TiFlashTenantGVR).Namespace(c.Namespace).Watch(context.TODO(), metav1.ListOptions{ResourceVersion: resourceVersion})
+		if err != nil {
+			Logger.Errorf("[error][TenantCRD]watch fail, err:%v", err.Error())
+			resourceVersion = ""
+			time.Sleep(time.Second)
+			continue
+		}
+		resourceVersion = r.handleEvents(watcher, ticker.C, &c.shutdown)
+	}
+}
+
+// handleEvents returns the last resource version, or empty if resources need to be reloaded
+func (r *tenantCRDReconciler) handleEvents(watcher watch.Interface, tick <-chan time.Time, shutdown *int32) string {
+	defer watcher.Stop()
+	resourceVersion := ""
+	ch := watcher.ResultChan()
+	for {
+		select {
+		case <-tick:
+			if atomic.LoadInt32(shutdown) != 0 {
+				return resourceVersion
+			}
+			r.tryToDeletePendingTenants()
+			r.syncStatus()
+		case e, more := <-ch:
+			if !more {
+				Logger.Infof("[TenantCRD]watch channel closed")
+				return ""
+			}
+			obj, ok := e.Object.(*unstructured.Unstructured)
+			if !ok {
+				if e.Type == watch.Error {
+					Logger.Errorf("[error][TenantCRD]watch.Error:%v", e.Object)
+					return ""
+				}
+				continue
+			}
+			resourceVersion = obj.GetResourceVersion()
+			switch e.Type {
+			case watch.Added, watch.Modified:
+				r.reconcile(obj)
+			case watch.Deleted:
+				r.handleDelete(obj.GetName())
+			case watch.Error:
+				Logger.Errorf("[error][TenantCRD]watch.Error:%v", obj)
+				return ""
+			}
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package autoscale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	TenantStoreTypeNone      = ""
+	TenantStoreTypeConfigMap = "configmap"
+	TenantStoreTypeFile      = "file"
+)
+
+var (
+	TenantStoreType             = TenantStoreTypeNone
+	TenantStoreFilePath         = "/var/lib/tiflash-autoscale/tenants.json"
+	TenantStoreConfigMapName    = "tiflash-autoscale-tenants"
+	TenantStoreFlushIntervalSec = 60 // tenants are flushed once they are changed, this is the interval of the fallback flush
+)
+
+// TenantRecord is the persisted part of TenantDesc
+type TenantRecord struct {
+	Name  string                 `json:"name"`
+	State int32                  `json:"state"`
+	Conf  ConfigOfComputeCluster `json:"conf"`
+}
+
+// TenantStore persists tenant configs and states, so that they survive restarts of autoscaler
+type TenantStore interface {
+	Load() (map[string]*TenantRecord, error)
+	// Save upserts changed records and removes deleted tenants
+	Save(changed []*TenantRecord, deleted []string) error
+}
+
+// NewTenantStore creates the store of TenantStoreType, nil if persistence is off
+func NewTenantStore(k8sCli kubernetes.Interface) (TenantStore, error) {
+	switch TenantStoreType {
+	case TenantStoreTypeNone:
+		return nil, nil
+	case TenantStoreTypeFile:
+		return NewFileTenantStore(TenantStoreFilePath), nil
+	case TenantStoreTypeConfigMap:
+		return NewConfigMapTenantStore(k8sCli, AutoScaleNamespace, TenantStoreConfigMapName), nil
+	default:
+		return nil, fmt.Errorf("unknown tenant store type: %v", TenantStoreType)
+	}
+}
+
+// the transient states can't be recovered after restart, persist the state they are heading to
+func normalizeStateToPersist(state int32) int32 {
+	switch state {
+	case TenantStatePausing:
+		return TenantStatePaused
+	case TenantStateResuming:
+		return TenantStateResumed
+	default:
+		return state
+	}
+}
+
+func (c *TenantDesc) ToRecord() *TenantRecord {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &TenantRecord{
+		Name:  c.Name,
+		State: normalizeStateToPersist(atomic.LoadInt32(&c.State)),
+		Conf:  c.conf,
+	}
+}
+
+// FileTenantStore keeps all records in one json file, the file is replaced atomically on save
+type FileTenantStore struct {
+	path    string
+	records map[string]*TenantRecord
+}
+
+func NewFileTenantStore(path string) *FileTenantStore {
+	return &FileTenantStore{path: path, records: make(map[string]*TenantRecord)}
+}
+
+func (s *FileTenantStore) Load() (map[string]*TenantRecord, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return make(map[string]*TenantRecord), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string]*TenantRecord)
+	if err = json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	s.records = records
+	ret := make(map[string]*TenantRecord, len(records))
+	for k, v := range records {
+		ret[k] = v
+	}
+	return ret, nil
+}
+
+func (s *FileTenantStore) Save(changed []*TenantRecord, deleted []string) error {
+	for _, r := range changed {
+		s.records[r.Name] = r
+	}
+	for _, name := range deleted {
+		delete(s.records, name)
+	}
+	data, err := json.Marshal(s.records)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err = os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// ConfigMapTenantStore keeps each record as a json value of a key in ConfigMap
+type ConfigMapTenantStore struct {
+	cli       kubernetes.Interface
+	namespace string
+	name      string
+}
+
+func NewConfigMapTenantStore(cli kubernetes.Interface, namespace string, name string) *ConfigMapTenantStore {
+	return &ConfigMapTenantStore{cli: cli, namespace: namespace, name: name}
+}
+
+func (s *ConfigMapTenantStore) Load() (map[string]*TenantRecord, error) {
+	ret := make(map[string]*TenantRecord)
+	cm, err := s.cli.CoreV1().ConfigMaps(s.namespace).Get(context.TODO(), s.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range cm.Data {
+		var r TenantRecord
+		if err = json.Unmarshal([]byte(v), &r); err != nil {
+			Logger.Errorf("[error][ConfigMapTenantStore]invalid record, tenant:%v err:%v", k, err.Error())
+			continue
+		}
+		ret[k] = &r
+	}
+	return ret, nil
+}
+
+func (s *ConfigMapTenantStore) Save(changed []*TenantRecord, deleted []string) error {
+	var err error
+	for retry := 0; retry < 3; retry++ {
+		if err = s.trySave(changed, deleted); err == nil || !errors.IsConflict(err) {
+			return err
+		}
+		Logger.Warnf("[ConfigMapTenantStore]conflict on save, retry:%v", retry)
+	}
+	return err
+}
+
+func (s *ConfigMapTenantStore) trySave(changed []*TenantRecord, deleted []string) error {
+	cm, err := s.cli.CoreV1().ConfigMaps(s.namespace).Get(context.TODO(), s.name, metav1.GetOptions{})
+	notFound := errors.IsNotFound(err)
+	if err != nil && !notFound {
+		return err
+	}
+	if notFound {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+		}
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	for _, r := range changed {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		cm.Data[r.Name] = string(data)
+	}
+	for _, name := range deleted {
+		delete(cm.Data, name)
+	}
+	if notFound {
+		_, err = s.cli.CoreV1().ConfigMaps(s.namespace).Create(context.TODO(), cm, metav1.CreateOptions{})
+	} else {
+		_, err = s.cli.CoreV1().ConfigMaps(s.namespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// checked
+// loadTenantsFromStore restores tenants before pods are scanned, so that custom configs and paused states are kept
+func (c *AutoScaleMeta) loadTenantsFromStore() {
+	if c.tenantStore == nil {
+		return
+	}
+	c.muOfTenantStore.Lock()
+	defer c.muOfTenantStore.Unlock()
+	records, err := c.tenantStore.Load()
+	if err != nil {
+		// disable the store to avoid overwriting records which are failed to load
+		Logger.Errorf("[error][TenantStore]load tenants fail, persistence is disabled, err:%v", err.Error())
+		c.tenantStore = nil
+		return
+	}
+	for name, r := range records {
+		conf := r.Conf
+		if conf.ConfigOfTiDBCluster == nil {
+			conf.ConfigOfTiDBCluster = &ConfigOfTiDBCluster{Name: name}
+		}
+		logIfInvalid(name, &conf)
+		if c.SetupTenantWithConfig(name, &ConfigOfComputeClusterHolder{Config: conf}, r.State) {
+			c.persistedTenants[name] = recordToString(r)
+		}
+	}
+	Logger.Infof("[TenantStore]%v tenants are loaded", len(records))
+}
+
+func recordToString(r *TenantRecord) string {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// refreshTenantsFromStore applies records saved by leader to the view of follower, so that the view is up to date
+// when follower takes over leadership and it doesn't need to reload the store
+func (c *AutoScaleMeta) refreshTenantsFromStore() {
+	if c.tenantStore == nil {
+		return
+	}
+	c.muOfTenantStore.Lock()
+	defer c.muOfTenantStore.Unlock()
+	records, err := c.tenantStore.Load()
+	if err != nil {
+		Logger.Errorf("[error][TenantStore]refresh tenants fail, err:%v", err.Error())
+		return
+	}
+	current := make(map[string]string, len(records))
+	for name, r := range records {
+		str := recordToString(r)
+		current[name] = str
+		if c.persistedTenants[name] == str {
+			continue
+		}
+		conf := r.Conf
+		fillConfigOfTiDBCluster(name, &conf)
+		logIfInvalid(name, &conf)
+		tenantDesc := c.GetTenantDesc(name)
+		if tenantDesc == nil {
+			c.SetupTenantWithConfig(name, &ConfigOfComputeClusterHolder{Config: conf}, r.State)
+			continue
+		}
+		tenantDesc.getOrAttachConfHolder(c.ConfigManager).Replace(conf)
+		tenantDesc.TryToReloadConf(true)
+		tenantDesc.SetState(r.State)
+	}
+	c.mu.Lock()
+	for name := range c.persistedTenants {
+		if _, ok := records[name]; !ok { // deleted by leader
+			delete(c.tenantMap, name)
+			c.ConfigManager.RemoveHolder(name)
+		}
+	}
+	c.mu.Unlock()
+	c.persistedTenants = current
+}
+
+func (c *AutoScaleMeta) notifyTenantStoreChanged() {
+	select {
+	case c.tenantStoreChanged <- struct{}{}:
+	default: // a flush is pending already
+	}
+}
+
+// flushTenantStore saves tenants which are changed since last flush
+func (c *AutoScaleMeta) flushTenantStore() error {
+	if c.tenantStore == nil {
+		return nil
+	}
+	c.muOfTenantStore.Lock()
+	defer c.muOfTenantStore.Unlock()
+	changed := make([]*TenantRecord, 0)
+	current := make(map[string]string)
+	for _, tenant := range c.GetTenants() {
+		r := tenant.ToRecord()
+		str := recordToString(r)
+		current[r.Name] = str
+		if c.persistedTenants[r.Name] != str {
+			changed = append(changed, r)
+		}
+	}
+	deleted := make([]string, 0)
+	for name := range c.persistedTenants {
+		if _, ok := current[name]; !ok {
+			deleted = append(deleted, name)
+		}
+	}
+	if len(changed) == 0 && len(deleted) == 0 {
+		return nil
+	}
+	if err := c.tenantStore.Save(changed, deleted); err != nil {
+		return err
+	}
+	Logger.Infof("[TenantStore]flush tenants, changed:%v deleted:%v", len(changed), deleted)
+	c.persistedTenants = current
+	return nil
+}
+
+// flushTenantStoreLoop saves tenants once they are changed, and every TenantStoreFlushIntervalSec in case a change is not notified
+func (c *ClusterManager) flushTenantStoreLoop() {
+	c.wg.Add(1)
+	defer c.wg.Done()
+	lastTs := int64(0)
+	for {
+		if atomic.LoadInt32(&c.shutdown) != 0 {
+			// last flush before exit
+			if err := c.AutoScaleMeta.flushTenantStore(); err != nil {
+				Logger.Errorf("[error][TenantStore]flush fail, err:%v", err.Error())
+			}
+			return
+		}
+		select {
+		case <-c.AutoScaleMeta.tenantStoreChanged:
+		case <-time.After(time.Second):
+			if time.Now().Unix() < lastTs+int64(TenantStoreFlushIntervalSec) {
+				continue
+			}
+		}
+		lastTs = time.Now().Unix()
+		if err := c.AutoScaleMeta.flushTenantStore(); err != nil {
+			Logger.Errorf("[error][TenantStore]flush fail, err:%v", err.Error())
+		}
+	}
+}
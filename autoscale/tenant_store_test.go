@@ -0,0 +1,130 @@
+package autoscale
+
+import (
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func newMeta4TenantStoreTest(store TenantStore) *AutoScaleMeta {
+	ret := &AutoScaleMeta{
+		tenantMap:        make(map[string]*TenantDesc),
+		PodDescMap:       make(map[string]*PodDesc),
+		tenantStore:      store,
+		persistedTenants: make(map[string]string),
+		ConfigManager:    NewConfigManager(),
+		prewarmPools:     newPrewarmPools(),
+	}
+	ret.loadTenantsFromStore()
+	return ret
+}
+
+func TestFileTenantStore(t *testing.T) {
+	InitTestEnv()
+	path := filepath.Join(t.TempDir(), "tenants.json")
+
+	meta := newMeta4TenantStoreTest(NewFileTenantStore(path))
+	assertEqual(t, meta.GetTenantCnt(), 0)
+	meta.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	meta.setupManualPauseMockTenant("t2", 2, 4, false, 120, NewCpuScaleRule(40, 80, "t2"))
+	meta.GetTenantDesc("t2").SetState(TenantStatePausing)
+	assertEqual(t, meta.flushTenantStore(), nil)
+
+	// restart
+	meta = newMeta4TenantStoreTest(NewFileTenantStore(path))
+	assertEqual(t, meta.GetTenantCnt(), 2)
+	assertEqual(t, meta.GetTenantDesc("t1").GetState(), int32(TenantStatePaused))
+	t2 := meta.GetTenantDesc("t2")
+	assertEqual(t, t2.GetState(), int32(TenantStatePaused))
+	assertEqual(t, t2.GetMinCntOfPod(), 2)
+	assertEqual(t, t2.GetScaleIntervalSec(), 120)
+	lower, upper := t2.GetLowerAndUpperCpuScaleThreshold()
+	assertEqual(t, lower, 0.4)
+	assertEqual(t, upper, 0.8)
+
+	// only changes are saved
+	assertEqual(t, len(meta.persistedTenants), 2)
+	t2.SetState(TenantStateResumed)
+	meta.mu.Lock()
+	delete(meta.tenantMap, "t1")
+	meta.mu.Unlock()
+	assertEqual(t, meta.flushTenantStore(), nil)
+	assertEqual(t, len(meta.persistedTenants), 1)
+	_, ok := meta.persistedTenants["t1"]
+	assertEqual(t, ok, false)
+
+	meta = newMeta4TenantStoreTest(NewFileTenantStore(path))
+	assertEqual(t, meta.GetTenantCnt(), 1)
+	assertEqual(t, meta.GetTenantDesc("t2").GetState(), int32(TenantStateResumed))
+}
+
+func TestTenantStoreChangeAndRefresh(t *testing.T) {
+	InitTestEnv()
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	leader := newMeta4TenantStoreTest(NewFileTenantStore(path))
+	leader.tenantStoreChanged = make(chan struct{}, 1)
+	follower := newMeta4TenantStoreTest(NewFileTenantStore(path))
+
+	// changes of tenants signal flush
+	leader.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	assertEqual(t, len(leader.tenantStoreChanged), 1)
+	<-leader.tenantStoreChanged
+	leader.GetTenantDesc("t1").SetState(TenantStatePaused)
+	assertEqual(t, len(leader.tenantStoreChanged), 0)
+	assertEqual(t, leader.GetTenantDesc("t1").SyncStateResuming(), true)
+	assertEqual(t, len(leader.tenantStoreChanged), 1)
+	assertEqual(t, leader.flushTenantStore(), nil)
+
+	// follower picks up tenants saved by leader
+	follower.refreshTenantsFromStore()
+	assertEqual(t, follower.GetTenantCnt(), 1)
+	assertEqual(t, follower.GetTenantDesc("t1").GetState(), int32(TenantStateResumed))
+	leader.GetTenantDesc("t1").SetState(TenantStatePaused)
+	assertEqual(t, leader.flushTenantStore(), nil)
+	follower.refreshTenantsFromStore()
+	assertEqual(t, follower.GetTenantDesc("t1").GetState(), int32(TenantStatePaused))
+
+	assertEqual(t, leader.DeleteTenantConfig("t1"), nil)
+	assertEqual(t, leader.flushTenantStore(), nil)
+	follower.refreshTenantsFromStore()
+	assertEqual(t, follower.GetTenantCnt(), 0)
+	// nothing is saved again once follower takes over
+	assertEqual(t, follower.flushTenantStore(), nil)
+	assertEqual(t, len(follower.persistedTenants), 0)
+}
+
+func TestFollowerViewIsReadOnly(t *testing.T) {
+	InitTestEnv()
+	oldRunMode := OptionRunMode
+	defer func() { OptionRunMode = oldRunMode }()
+	OptionRunMode = RunModeServeless
+	meta := newMeta4TenantStoreTest(nil)
+	meta.isLeader = &atomic.Bool{}
+	meta.IsRuntimeReady.Store(true)
+	meta.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	pool := meta.GetPrewarmPool("")
+
+	// follower moves pods between known tenants only, without changing states
+	p0, p1 := &PodDesc{Name: "p0"}, &PodDesc{Name: "p1"}
+	meta.UpdateLocalMetaPodOfTenant("p0", p0, "t1", 1)
+	meta.UpdateLocalMetaPodOfTenant("p1", p1, "t2", 1)
+	assertEqual(t, meta.GetTenantDesc("t1").GetCntOfPods(), 1)
+	assertEqual(t, meta.GetTenantDesc("t1").GetState(), int32(TenantStatePaused))
+	assertEqual(t, meta.GetTenantDesc("t2") == nil, true)
+	assertEqual(t, p1.tenantName, "")
+
+	// new pods are not handed out by follower
+	pool.acquireWarmedPods("t1", 1, false, "", 0)
+	meta.mu.Lock()
+	meta.addPreWarmFromPending("p2", &PodDesc{Name: "p2"})
+	meta.mu.Unlock()
+	_, ok := pool.WarmedPods.GetPod("p2")
+	assertEqual(t, ok, true)
+
+	// states are corrected once it becomes leader
+	meta.isLeader.Store(true)
+	meta.correctStatesOfTenants()
+	assertEqual(t, meta.GetTenantDesc("t1").GetState(), int32(TenantStateResumed))
+	meta.UpdateLocalMetaPodOfTenant("p1", p1, "t2", 1)
+	assertEqual(t, meta.GetTenantDesc("t2").GetCntOfPods(), 1)
+}
@@ -4,6 +4,7 @@ import (
 	"container/list"
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"sync"
 	"time"
@@ -194,17 +195,24 @@ func (cur *AvgSigma) Merge(o *AvgSigma) {
 	cur.sum += o.sum
 }
 
+// NaN in values means the metric is missing in this sample, it is skipped in both Add and Sub
 func Sub(cur []AvgSigma, values []float64) {
 	if len(values) == 0 {
 		Logger.Errorf("[error]Sub error empty values")
 	}
 	for i, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
 		cur[i].Sub(value)
 	}
 }
 
 func Add(cur []AvgSigma, values []float64) {
 	for i, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
 		cur[i].Add(value)
 	}
 }
@@ -407,6 +415,23 @@ const (
 	MetricsTopicTaskCnt = MetricsTopic(1)
 )
 
+// index of values in a sample of MetricsTopicCpu, which carries all metrics used by scale rules
+const (
+	MetricsIdxOfCpu     = 0 // cpu usage, unit: cores
+	MetricsIdxOfMem     = 1 // memory working set, unit: bytes
+	MetricsIdxOfTaskCnt = 2 // tiflash_coprocessor_handling_request_count
+	MetricsCntOfSample  = 3
+)
+
+// NewResourceSample builds values of a sample of MetricsTopicCpu, metric which is not collected should be math.NaN()
+func NewResourceSample(cpu float64, mem float64, taskCnt float64) []float64 {
+	ret := make([]float64, MetricsCntOfSample)
+	ret[MetricsIdxOfCpu] = cpu
+	ret[MetricsIdxOfMem] = mem
+	ret[MetricsIdxOfTaskCnt] = taskCnt
+	return ret
+}
+
 func (c *MetricsTopic) String() string {
 	switch *c {
 	case MetricsTopicCpu:
@@ -598,10 +623,9 @@ func (c *PromClient) RangeQueryCpu(scaleInterval time.Duration, step time.Durati
 				if curTime < sTimeOfAssign {
 					continue
 				}
-				writer.InsertWithUserCfg(string(podName), curTime, []float64{
-					float64(valCopy.Value),
-					0.0, //TODO remove this dummy mem metric
-				}, scaleIntervalSec, MetricsTopicCpu)
+				writer.InsertWithUserCfg(string(podName), curTime,
+					NewResourceSample(float64(valCopy.Value), math.NaN(), math.NaN()),
+					scaleIntervalSec, MetricsTopicCpu)
 				cnt++
 			}
 			ret[string(podName)] = cnt
@@ -664,6 +688,81 @@ func (c *PromClient) QueryCpu() (map[string]*TimeValPair, error) {
 	return ret, nil
 }
 
+// checked
+func (c *PromClient) QueryMemWorkingSet() (map[string]*TimeValPair, error) {
+	return c.queryVectorByPod("max by(pod) (container_memory_working_set_bytes{job=\"kube_sd\", pod=~\"readnode.+\",container=\"supervisor\"})", "mem_working_set")
+}
+
+// checked
+func (c *PromClient) QueryHandlingRequestCnt() (map[string]*TimeValPair, error) {
+	return c.queryVectorByPod("sum by(pod) (tiflash_coprocessor_handling_request_count{job=\"kube_sd_tiflash_proc\",metrics_topic=\"tiflash\", pod!=\"\"})", "handling_request_cnt")
+}
+
+// RangeQueryCpuOfTenant returns total cpu cores used by pods of tenant, pods are labeled with tidb_cluster by the metrics proxy
+func (c *PromClient) RangeQueryCpuOfTenant(tenant string, start time.Time, end time.Time, step time.Duration) ([]TimeValPair, error) {
+	v1api := v1.NewAPI(c.cli)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	r := v1.Range{
+		Start: start,
+		End:   end,
+		Step:  step,
+	}
+	query := fmt.Sprintf("sum(irate(container_cpu_usage_seconds_total{metrics_topic=\"cadvisor\", container=\"supervisor\", tidb_cluster=\"%v\"}[1m]))", tenant)
+	result, warnings, err := v1api.QueryRange(ctx, query, r, v1.WithTimeout(20*time.Second))
+	if err != nil {
+		Logger.Errorf("[error][PromClient] querying Prometheus error: %v", err)
+		return nil, err
+	}
+	if len(warnings) > 0 {
+		Logger.Warnf("[warn][PromClient] Warnings: %v", warnings)
+	}
+	ret := make([]TimeValPair, 0, 64)
+	matrix, ok := result.(model.Matrix)
+	if ok {
+		for _, sampleStream := range matrix {
+			for _, val := range sampleStream.Values {
+				ret = append(ret, TimeValPair{
+					time:  val.Timestamp.Unix(),
+					value: float64(val.Value),
+				})
+			}
+		}
+	} else {
+		Logger.Errorf("[error][RangeQueryCpuOfTenant]type cast fail when query cpu of tenant %v, real result:%v ", tenant, result)
+	}
+	return ret, nil
+}
+
+func (c *PromClient) queryVectorByPod(query string, title string) (map[string]*TimeValPair, error) {
+	v1api := v1.NewAPI(c.cli)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, warnings, err := v1api.Query(ctx, query, time.Now(), v1.WithTimeout(5*time.Second))
+	if err != nil {
+		Logger.Errorf("[error][PromClient] querying Prometheus error: %v", err)
+		return nil, err
+	}
+	if len(warnings) > 0 {
+		Logger.Warnf("[warn][PromClient] Warnings: %v", warnings)
+	}
+	vector, ok := result.(model.Vector)
+	ret := make(map[string]*TimeValPair)
+	if ok {
+		for _, sample := range vector {
+			podName := sample.Metric["pod"]
+			ret[string(podName)] = &TimeValPair{
+				time:  sample.Timestamp.Unix(),
+				value: float64(sample.Value),
+			}
+		}
+	} else {
+		Logger.Errorf("[error][Prom]type cast fail when query %v, real result:%v ", title, result)
+	}
+	Logger.Debugf("[Prom]query %v, ret: %v, size:%v ", title, ret, len(ret))
+	return ret, nil
+}
+
 // checked
 func (c *PromClient) QueryComputeTask() (map[string]*TimeValPair, error) {
 
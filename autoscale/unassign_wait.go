@@ -0,0 +1,54 @@
+package autoscale
+
+import (
+	"fmt"
+	"time"
+)
+
+// Supervisor is polled with exponential backoff while it's unassigning, until MaxUnassignWaitTimeSec expires
+var (
+	unassignPollMinInterval = time.Second
+	unassignPollMaxInterval = 8 * time.Second
+	getCurrentTenantOfPod   = GetCurrentTenant
+)
+
+// waitUnassignDone polls supervisor until it has finished unassigning, then pod is returned to warm pool.
+// Pod is quarantined if supervisor is still unassigning or unreachable after MaxUnassignWaitTimeSec.
+func (c *AutoScaleMeta) waitUnassignDone(curtenant string, v *PodDesc, tsContainer *TimeSeriesContainer) {
+	deadline := time.Now().Add(time.Duration(MaxUnassignWaitTimeSec) * time.Second)
+	interval := unassignPollMinInterval
+	lastErr := ""
+	for {
+		time.Sleep(interval)
+		c.mu.Lock()
+		_, ok := c.PodDescMap[v.Name]
+		podIP := v.IP
+		c.mu.Unlock()
+		if !ok {
+			Logger.Warnf("[HandleUnassingCase]pod has been deleted by k8s, tenant:%v pod:%v", curtenant, v.Name)
+			return
+		}
+		resp, err := getCurrentTenantOfPod(podIP)
+		if err != nil {
+			lastErr = err.Error()
+		} else if resp.IsUnassigning {
+			lastErr = "supervisor is still unassigning"
+		} else if resp.TenantID == "" {
+			c.mu.Lock()
+			c.putWarmedPodIntoPoolOfClass(curtenant, v)
+			tsContainer.ResetMetricsOfPod(v.Name)
+			c.mu.Unlock()
+			Logger.Infof("[HandleUnassingCase]done. tenant:%v pod:%v", curtenant, v.Name)
+			return
+		} else { // supervisor serves a tenant, correct its state
+			Logger.Warnf("[HandleUnassingCase]pod is serving tenant %v after unassigning, tenant:%v pod:%v", resp.TenantID, curtenant, v.Name)
+			c.UpdateLocalMetaPodOfTenant(v.Name, v, resp.TenantID, resp.StartTime)
+			return
+		}
+		if !time.Now().Before(deadline) {
+			c.quarantinePod(v, curtenant, fmt.Sprintf("unassign is not done in %vs, last error: %v", MaxUnassignWaitTimeSec, lastErr))
+			return
+		}
+		interval = time.Duration(MinInt(int(interval*2), int(unassignPollMaxInterval)))
+	}
+}
@@ -0,0 +1,64 @@
+package autoscale
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	supervisor "github.com/tikv/pd/supervisor_proto"
+)
+
+func TestWaitUnassignDone(t *testing.T) {
+	InitTestEnv()
+	oldMin, oldMax, oldGet, oldWait := unassignPollMinInterval, unassignPollMaxInterval, getCurrentTenantOfPod, MaxUnassignWaitTimeSec
+	defer func() {
+		unassignPollMinInterval, unassignPollMaxInterval, getCurrentTenantOfPod, MaxUnassignWaitTimeSec = oldMin, oldMax, oldGet, oldWait
+	}()
+	unassignPollMinInterval, unassignPollMaxInterval = time.Millisecond, 4*time.Millisecond
+
+	meta := newMeta4TenantStoreTest(nil)
+	meta.UpdatePod(newPod4PodClassTest("p0", "10.0.0.1", ReadNodeCloneSetName))
+	meta.UpdatePod(newPod4PodClassTest("p1", "10.0.0.2", ReadNodeCloneSetName))
+	pool := meta.GetPrewarmPool("")
+	tsContainer := NewTimeSeriesContainer(nil)
+
+	// pod is returned to pool after supervisor finishes unassigning
+	queryCnt := 0
+	getCurrentTenantOfPod = func(podIP string) (*supervisor.GetTenantResponse, error) {
+		queryCnt++
+		switch queryCnt {
+		case 1:
+			return nil, fmt.Errorf("grpc timeout")
+		case 2:
+			return &supervisor.GetTenantResponse{TenantID: "t1", IsUnassigning: true}, nil
+		}
+		return &supervisor.GetTenantResponse{}, nil
+	}
+	pod := pool.takeWarmedPod("p0")
+	meta.waitUnassignDone("t1", pod, tsContainer)
+	assertEqual(t, queryCnt, 3)
+	_, ok := pool.WarmedPods.GetPod("p0")
+	assertEqual(t, ok, true)
+	assertEqual(t, len(meta.GetAnomalousPods()), 0)
+
+	// pod is anomalous if supervisor is still unassigning after deadline
+	MaxUnassignWaitTimeSec = 0
+	getCurrentTenantOfPod = func(podIP string) (*supervisor.GetTenantResponse, error) {
+		return &supervisor.GetTenantResponse{TenantID: "t1", IsUnassigning: true}, nil
+	}
+	pod = pool.takeWarmedPod("p1")
+	meta.waitUnassignDone("t1", pod, tsContainer)
+	_, ok = pool.WarmedPods.GetPod("p1")
+	assertEqual(t, ok, false)
+	anomalousPods := meta.GetAnomalousPods()
+	assertEqual(t, len(anomalousPods), 1)
+	assertEqual(t, anomalousPods[0].PodName, "p1")
+	assertEqual(t, anomalousPods[0].PodIP, "10.0.0.2")
+	assertEqual(t, anomalousPods[0].Tenant, "t1")
+
+	// anomalous pod is forgotten after it's deleted by k8s
+	meta.mu.Lock()
+	meta.removePodFromClusterWithoutLock(meta.PodDescMap["p1"])
+	meta.mu.Unlock()
+	assertEqual(t, len(meta.GetAnomalousPods()), 0)
+}
@@ -0,0 +1,274 @@
+package autoscale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openkruise/kruise-api/apps/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// LabelKeyOfPodRevision is set on pods by OpenKruise, its value is the revision of cloneset
+const LabelKeyOfPodRevision = "controller-revision-hash"
+
+// In coordinated upgrade, partition of cloneset holds existing pods at old revision when template changes,
+// then autoscaler replaces them one by one: warm pods first, then assigned pods after their tenants get new warm pods
+var (
+	CoordinatedUpgradeEnabled = false
+	UpgradeIntervalSec        = 10
+)
+
+const (
+	UpgradeStateIdle      = "idle"
+	UpgradeStateUpgrading = "upgrading"
+	UpgradeStateRolling   = "rolling" // old pods are rolled by OpenKruise, since partition is not set
+)
+
+type UpgradeStatus struct {
+	PodClass        string            `json:"podClass"`
+	State           string            `json:"state"`
+	UpdateRevision  string            `json:"updateRevision"`
+	Partition       int               `json:"partition"`
+	TotalPods       int               `json:"totalPods"`
+	UpdatedPods     int               `json:"updatedPods"`
+	OldWarmPods     []string          `json:"oldWarmPods"`
+	OldAssignedPods map[string]string `json:"oldAssignedPods"` // pod -> tenant
+	Message         string            `json:"message"`
+	StartTime       int64             `json:"startTime"` // unix seconds when old pods are found, 0 if idle
+	UpdateTime      int64             `json:"updateTime"`
+}
+
+// UpgradeTracker keeps the latest upgrade status of each pod class
+type UpgradeTracker struct {
+	mu       sync.Mutex
+	statuses map[string]*UpgradeStatus
+}
+
+func NewUpgradeTracker() *UpgradeTracker {
+	return &UpgradeTracker{statuses: make(map[string]*UpgradeStatus)}
+}
+
+func (t *UpgradeTracker) set(status *UpgradeStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status.UpdateTime = time.Now().Unix()
+	if status.State != UpgradeStateIdle {
+		if old, ok := t.statuses[status.PodClass]; ok && old.StartTime != 0 {
+			status.StartTime = old.StartTime
+		} else {
+			status.StartTime = status.UpdateTime
+		}
+	}
+	t.statuses[status.PodClass] = status
+}
+
+// GetStatuses returns copies in order of pod class
+func (t *UpgradeTracker) GetStatuses() []UpgradeStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ret := make([]UpgradeStatus, 0, len(t.statuses))
+	for _, status := range t.statuses {
+		ret = append(ret, *status)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].PodClass < ret[j].PodClass })
+	return ret
+}
+
+// isPodOfRevision checks label of pod, which is name of revision, or only its hash in old versions of OpenKruise
+func isPodOfRevision(podRevision string, revision string) bool {
+	if podRevision == "" || revision == "" {
+		return false
+	}
+	return podRevision == revision || strings.HasSuffix(revision, "-"+podRevision)
+}
+
+func getPartitionOfCloneSet(cloneSet *v1alpha1.CloneSet) int {
+	if cloneSet.Spec.UpdateStrategy.Partition == nil || cloneSet.Spec.Replicas == nil {
+		return 0
+	}
+	partition, err := intstr.GetScaledValueFromIntOrPercent(cloneSet.Spec.UpdateStrategy.Partition, int(*cloneSet.Spec.Replicas), true)
+	if err != nil {
+		Logger.Errorf("[Upgrade]invalid partition of cloneset %v, err: %v", cloneSet.Name, err.Error())
+		return 0
+	}
+	return partition
+}
+
+// isOldPodInUpgrade is true if pod is created from old revision while coordinated upgrade of its pod class is in progress
+func (c *ClusterManager) isOldPodInUpgrade(podClass string, pod *PodDesc) bool {
+	revision := pod.GetRevision()
+	c.muOfCloneSet.Lock()
+	defer c.muOfCloneSet.Unlock()
+	cloneSet := c.CloneSets[podClass]
+	if cloneSet == nil || revision == "" || getPartitionOfCloneSet(cloneSet) == 0 {
+		return false
+	}
+	return !isPodOfRevision(revision, cloneSet.Status.UpdateRevision)
+}
+
+// setPartitionOfPatch holds all existing pods at old revision if pod template is changed in coordinated upgrade,
+// otherwise partition left by coordinated upgrade is removed, so that OpenKruise rolls the pods.
+// Patch may contain pod template even if it's unchanged, e.g. containers are replaced as a whole list, so templateChanged is decided by caller.
+func setPartitionOfPatch(patch []byte, live *v1alpha1.CloneSet, templateChanged bool) ([]byte, error) {
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(patch, &obj); err != nil {
+		return nil, err
+	}
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return patch, nil
+	}
+	if _, ok := spec["template"]; !ok {
+		return patch, nil
+	}
+	var partition interface{}
+	if CoordinatedUpgradeEnabled && !templateChanged {
+		return patch, nil
+	} else if CoordinatedUpgradeEnabled {
+		replicas := int32(0)
+		if live.Spec.Replicas != nil {
+			replicas = *live.Spec.Replicas
+		}
+		partition = replicas
+	} else if live.Spec.UpdateStrategy.Partition == nil {
+		return patch, nil
+	}
+	updateStrategy, ok := spec["updateStrategy"].(map[string]interface{})
+	if !ok {
+		updateStrategy = make(map[string]interface{})
+		spec["updateStrategy"] = updateStrategy
+	}
+	updateStrategy["partition"] = partition
+	Logger.Infof("[Upgrade]pod template of cloneset %v changes, partition: %v", live.Name, partition)
+	return json.Marshal(obj)
+}
+
+// collectUpgradeStatus classifies ready pods of class by revision
+func (c *AutoScaleMeta) collectUpgradeStatus(podClass string, updateRevision string) *UpgradeStatus {
+	status := &UpgradeStatus{
+		PodClass:        podClass,
+		State:           UpgradeStateIdle,
+		UpdateRevision:  updateRevision,
+		OldWarmPods:     make([]string, 0),
+		OldAssignedPods: make(map[string]string),
+	}
+	pool := c.GetPrewarmPool(podClass)
+	for name, pod := range c.CopyPodDescMap() {
+		revision := pod.GetRevision()
+		if NormalizePodClassName(pod.Class) != podClass || pod.IP == "" || revision == "" {
+			continue
+		}
+		status.TotalPods++
+		if isPodOfRevision(revision, updateRevision) {
+			status.UpdatedPods++
+		} else if tenant := pod.GetTenantName(); tenant != "" {
+			status.OldAssignedPods[name] = tenant
+		} else if _, ok := pool.WarmedPods.GetPod(name); ok {
+			status.OldWarmPods = append(status.OldWarmPods, name)
+		}
+	}
+	sort.Strings(status.OldWarmPods)
+	return status
+}
+
+func (c *ClusterManager) upgradeLoop() {
+	for {
+		time.Sleep(time.Duration(UpgradeIntervalSec) * time.Second)
+		if atomic.LoadInt32(&c.shutdown) != 0 {
+			return
+		}
+		for _, podClass := range GetPodClassNames() {
+			c.upgradeStepOfPodClass(podClass)
+		}
+	}
+}
+
+// upgradeStepOfPodClass replaces at most one pod of old revision
+func (c *ClusterManager) upgradeStepOfPodClass(podClass string) {
+	cloneSet, err := c.Cli.AppsV1alpha1().CloneSets(c.Namespace).Get(context.TODO(), GetCloneSetNameOfPodClass(podClass), metav1.GetOptions{})
+	if err != nil {
+		Logger.Errorf("[error][Upgrade][%v]failed to get cloneset, err: %v", podClass, err.Error())
+		return
+	}
+	if cloneSet.Status.ObservedGeneration < cloneSet.Generation { // revisions are not updated by OpenKruise yet
+		return
+	}
+	status := c.AutoScaleMeta.collectUpgradeStatus(podClass, cloneSet.Status.UpdateRevision)
+	status.Partition = getPartitionOfCloneSet(cloneSet)
+	defer c.upgrades.set(status)
+	cntOfOldPods := int(cloneSet.Status.Replicas - cloneSet.Status.UpdatedReplicas)
+	if cntOfOldPods <= 0 {
+		if status.Partition != 0 {
+			if _, err = c.removePodsAndSetPartition(podClass, nil, Int32Ptr(0), 2); err != nil {
+				status.Message = "failed to reset partition: " + err.Error()
+			}
+		}
+		return
+	}
+	if status.Partition == 0 {
+		status.State = UpgradeStateRolling
+		return
+	}
+	status.State = UpgradeStateUpgrading
+	pool := c.AutoScaleMeta.GetPrewarmPool(podClass)
+	newPartition := Int32Ptr(int32(MaxInt(cntOfOldPods-1, 0)))
+
+	// warm pods are replaced first, deleted pod is refilled by pool with new revision
+	if len(status.OldWarmPods) > 0 {
+		podName := status.OldWarmPods[0]
+		pod := pool.takeWarmedPod(podName)
+		if pod == nil {
+			status.Message = fmt.Sprintf("warm pod %v has left pool", podName)
+			return
+		}
+		if _, err = c.removePodsAndSetPartition(podClass, []string{podName}, newPartition, 2); err != nil {
+			pool.putWarmedPod("", pod, false)
+			status.Message = fmt.Sprintf("failed to delete warm pod %v: %v", podName, err.Error())
+			return
+		}
+		status.Message = fmt.Sprintf("deleted warm pod %v", podName)
+		Logger.Infof("[Upgrade][%v]%v", podClass, status.Message)
+		return
+	}
+	if len(status.OldAssignedPods) == 0 {
+		status.Message = "waiting for old pods to be ready"
+		return
+	}
+
+	podNames := make([]string, 0, len(status.OldAssignedPods))
+	for podName := range status.OldAssignedPods {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+	podName := podNames[0]
+	tenant := status.OldAssignedPods[podName]
+	newPod := pool.takeWarmedPodOfRevision(cloneSet.Status.UpdateRevision)
+	if newPod == nil {
+		status.Message = "waiting for warm pod of new revision"
+		return
+	}
+	oldPod, err := c.AutoScaleMeta.replacePodOfTenant(tenant, podName, newPod, c.tsContainer)
+	if err != nil {
+		status.Message = fmt.Sprintf("failed to replace pod %v of tenant %v: %v", podName, tenant, err.Error())
+		Logger.Warnf("[Upgrade][%v]%v", podClass, status.Message)
+		return
+	}
+	if oldPod == nil {
+		return
+	}
+	if _, err = c.removePodsAndSetPartition(podClass, []string{podName}, newPartition, 2); err != nil {
+		// it may still serve tenant if unassign failed, so it's quarantined instead of pool, and deleted once it's probed as healthy
+		c.AutoScaleMeta.quarantinePod(oldPod, tenant, "failed to delete old pod of upgrade: "+err.Error())
+		status.Message = fmt.Sprintf("failed to delete pod %v: %v", podName, err.Error())
+		return
+	}
+	status.Message = fmt.Sprintf("pod %v of tenant %v is replaced by %v", podName, tenant, newPod.Name)
+	Logger.Infof("[Upgrade][%v]%v", podClass, status.Message)
+}
@@ -0,0 +1,131 @@
+package autoscale
+
+import (
+	"testing"
+
+	"github.com/openkruise/kruise-api/apps/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCollectUpgradeStatus(t *testing.T) {
+	InitTestEnv()
+	assertEqual(t, isPodOfRevision("readnode-7b9c", "readnode-7b9c"), true)
+	assertEqual(t, isPodOfRevision("7b9c", "readnode-7b9c"), true)
+	assertEqual(t, isPodOfRevision("readnode-5d4f", "readnode-7b9c"), false)
+	assertEqual(t, isPodOfRevision("", "readnode-7b9c"), false)
+
+	meta := newMeta4TenantStoreTest(nil)
+	meta.SetupAutoPauseTenantWithPausedState("t1", 1, 4)
+	for i, revision := range []string{"readnode-old", "readnode-old", "readnode-new", "readnode-old"} {
+		pod := newPod4PodClassTest("p"+string(rune('0'+i)), "10.0.0."+string(rune('1'+i)), ReadNodeCloneSetName)
+		pod.Labels[LabelKeyOfPodRevision] = revision
+		meta.UpdatePod(pod)
+	}
+	// pending pod is not counted
+	meta.UpdatePod(newPod4PodClassTest("p4", "", ReadNodeCloneSetName))
+	pool := meta.GetPrewarmPool("")
+	tenant := meta.GetTenantDesc("t1")
+	pod := pool.takeWarmedPod("p1")
+	tenant.SetPodWithTenantInfo(pod.Name, pod, 1)
+
+	status := meta.collectUpgradeStatus(DefaultPodClassName, "readnode-new")
+	assertEqual(t, status.TotalPods, 4)
+	assertEqual(t, status.UpdatedPods, 1)
+	assertEqual(t, len(status.OldWarmPods), 2)
+	assertEqual(t, status.OldWarmPods[0], "p0")
+	assertEqual(t, status.OldAssignedPods["p1"], "t1")
+
+	// only pod of new revision replaces old ones
+	assertEqual(t, pool.takeWarmedPodOfRevision("readnode-newer") == nil, true)
+	pod = pool.takeWarmedPodOfRevision("readnode-new")
+	assertEqual(t, pod.Name, "p2")
+	assertEqual(t, pool.WarmedPods.GetCntOfPods(), 2)
+
+	tracker := NewUpgradeTracker()
+	tracker.set(status)
+	assertEqual(t, tracker.GetStatuses()[0].StartTime, int64(0))
+	status.State = UpgradeStateUpgrading
+	tracker.set(status)
+	startTime := tracker.GetStatuses()[0].StartTime
+	assertEqual(t, startTime > 0, true)
+	tracker.set(&UpgradeStatus{PodClass: DefaultPodClassName, State: UpgradeStateUpgrading})
+	assertEqual(t, tracker.GetStatuses()[0].StartTime, startTime)
+}
+
+func TestSetPartitionOfPatch(t *testing.T) {
+	InitTestEnv()
+	defer func() {
+		CoordinatedUpgradeEnabled = false
+	}()
+	live := &v1alpha1.CloneSet{}
+	live.Name = ReadNodeCloneSetName
+	live.Spec.Replicas = Int32Ptr(5)
+	imagePatch := []byte(`{"spec":{"template":{"spec":{"containers":[{"name":"supervisor","image":"supervisor:new"}]}}}}`)
+
+	CoordinatedUpgradeEnabled = true
+	patch, err := setPartitionOfPatch(imagePatch, live, true)
+	assertEqual(t, err, nil)
+	assertEqual(t, string(patch), `{"spec":{"template":{"spec":{"containers":[{"image":"supervisor:new","name":"supervisor"}]}},"updateStrategy":{"partition":5}}}`)
+	annotationPatch := []byte(`{"metadata":{"annotations":{"a":"b"}}}`)
+	patch, err = setPartitionOfPatch(annotationPatch, live, true)
+	assertEqual(t, err, nil)
+	assertEqual(t, string(patch), string(annotationPatch))
+	// containers in patch with unchanged template keep partition
+	patch, err = setPartitionOfPatch(imagePatch, live, false)
+	assertEqual(t, err, nil)
+	assertEqual(t, string(patch), string(imagePatch))
+
+	// template is compared with the last applied one
+	c := &ClusterManager{Namespace: "tiflash-autoscale"}
+	desired, err := c.buildDesiredCloneSet(defaultCloneSetTemplate, GetPodClass(""), 1)
+	assertEqual(t, err, nil)
+	applied := desired.DeepCopy()
+	assertEqual(t, isPodTemplateChanged(desired, applied), true)
+	assertEqual(t, setLastAppliedAnnotation(applied), nil)
+	applied.Spec.Template.Spec.Containers[0].TerminationMessagePath = "/dev/termination-log"
+	desired.Labels["a"] = "b"
+	assertEqual(t, isPodTemplateChanged(desired, applied), false)
+	desired.Spec.Template.Spec.Containers[0].Image = "supervisor:new"
+	assertEqual(t, isPodTemplateChanged(desired, applied), true)
+
+	// partition left by coordinated upgrade is removed
+	CoordinatedUpgradeEnabled = false
+	patch, err = setPartitionOfPatch(imagePatch, live, true)
+	assertEqual(t, err, nil)
+	assertEqual(t, string(patch), string(imagePatch))
+	live.Spec.UpdateStrategy.Partition = &intstr.IntOrString{Type: intstr.String, StrVal: "40%"}
+	assertEqual(t, getPartitionOfCloneSet(live), 2)
+	patch, err = setPartitionOfPatch(imagePatch, live, true)
+	assertEqual(t, err, nil)
+	assertEqual(t, string(patch), `{"spec":{"template":{"spec":{"containers":[{"image":"supervisor:new","name":"supervisor"}]}},"updateStrategy":{"partition":null}}}`)
+}
+
+func TestPartitionAfterRemovingPods(t *testing.T) {
+	InitTestEnv()
+	meta := newMeta4TenantStoreTest(nil)
+	for i, revision := range []string{"readnode-old", "readnode-old", "readnode-new"} {
+		pod := newPod4PodClassTest("p"+string(rune('0'+i)), "10.0.0."+string(rune('1'+i)), ReadNodeCloneSetName)
+		pod.Labels[LabelKeyOfPodRevision] = revision
+		meta.UpdatePod(pod)
+	}
+	c := &ClusterManager{AutoScaleMeta: meta}
+	cloneSet := &v1alpha1.CloneSet{}
+	cloneSet.Spec.Replicas = Int32Ptr(3)
+	cloneSet.Status.UpdateRevision = "readnode-new"
+
+	// no upgrade is in progress
+	assertEqual(t, c.getPartitionAfterRemovingPods(cloneSet, []string{"p0"}) == nil, true)
+
+	cloneSet.Spec.UpdateStrategy.Partition = &intstr.IntOrString{Type: intstr.Int, IntVal: 2}
+	assertEqual(t, c.getPartitionAfterRemovingPods(cloneSet, []string{"p2", "p9"}) == nil, true)
+	assertEqual(t, *c.getPartitionAfterRemovingPods(cloneSet, []string{"p0", "p2"}), int32(1))
+	assertEqual(t, *c.getPartitionAfterRemovingPods(cloneSet, []string{"p0", "p1"}), int32(0))
+	cloneSet.Spec.UpdateStrategy.Partition.IntVal = 1
+	assertEqual(t, *c.getPartitionAfterRemovingPods(cloneSet, []string{"p0", "p1"}), int32(0))
+
+	c.CloneSets = map[string]*v1alpha1.CloneSet{DefaultPodClassName: cloneSet}
+	assertEqual(t, c.isOldPodInUpgrade(DefaultPodClassName, meta.PodDescMap["p0"]), true)
+	assertEqual(t, c.isOldPodInUpgrade(DefaultPodClassName, meta.PodDescMap["p2"]), false)
+	cloneSet.Spec.UpdateStrategy.Partition = nil
+	assertEqual(t, c.isOldPodInUpgrade(DefaultPodClassName, meta.PodDescMap["p0"]), false)
+}
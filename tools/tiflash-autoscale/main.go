@@ -440,11 +440,53 @@ func main() {
 	flag.IntVar(&autoscale.DefaultMinCntOfPod, "default-min-pods", autoscale.DefaultMinCntOfPod, "DefaultMinCntOfPod")
 	flag.IntVar(&autoscale.DefaultMaxCntOfPod, "default-max-pods", autoscale.DefaultMaxCntOfPod, "DefaultMaxCntOfPod")
 	flag.IntVar(&autoscale.DefaultCoreOfPod, "default-cores-of-pods", autoscale.DefaultCoreOfPod, "DefaultCoreOfPod")
+	flag.IntVar(&autoscale.DefaultMemGiBPerCore, "default-mem-gib-per-core", autoscale.DefaultMemGiBPerCore, "DefaultMemGiBPerCore")
 	flag.Float64Var(&autoscale.DefaultLowerLimit, "default-lowerlimit", autoscale.DefaultLowerLimit, "DefaultLowerLimit")
 	flag.Float64Var(&autoscale.DefaultUpperLimit, "default-upperlimit", autoscale.DefaultUpperLimit, "DefaultUpperLimit")
 	flag.IntVar(&autoscale.MetricResolutionSeconds, "metric-resolution-sec", autoscale.MetricResolutionSeconds, "MetricResolutionSeconds")
 	flag.IntVar(&autoscale.DefaultAutoPauseIntervalSeconds, "default-autopause-intervalsec", autoscale.DefaultAutoPauseIntervalSeconds, "DefaultAutoPauseIntervalSeconds")
 	flag.IntVar(&autoscale.DefaultScaleIntervalSeconds, "default-autoscale-intervalsec", autoscale.DefaultScaleIntervalSeconds, "DefaultScaleIntervalSeconds")
+	flag.IntVar(&autoscale.DefaultScaleUpCooldownSeconds, "default-scaleup-cooldown-sec", autoscale.DefaultScaleUpCooldownSeconds, "DefaultScaleUpCooldownSeconds")
+	flag.IntVar(&autoscale.DefaultScaleDownStabilizationSeconds, "default-scaledown-stabilization-sec", autoscale.DefaultScaleDownStabilizationSeconds, "DefaultScaleDownStabilizationSeconds")
+	flag.IntVar(&autoscale.PredictiveScaleIntervalSec, "predictive-scale-intervalsec", autoscale.PredictiveScaleIntervalSec, "PredictiveScaleIntervalSec")
+	flag.BoolVar(&autoscale.DryRunMode, "dry-run", autoscale.DryRunMode, "DryRunMode")
+	flag.IntVar(&autoscale.DecisionRingCapOfTenant, "decision-ring-cap-of-tenant", autoscale.DecisionRingCapOfTenant, "DecisionRingCapOfTenant")
+	flag.StringVar(&autoscale.DecisionAuditLogPath, "decision-audit-log", autoscale.DecisionAuditLogPath, "DecisionAuditLogPath")
+	flag.StringVar(&autoscale.TenantStoreType, "tenant-store", autoscale.TenantStoreType, "TenantStoreType, empty/configmap/file")
+	flag.StringVar(&autoscale.TenantStoreFilePath, "tenant-store-file", autoscale.TenantStoreFilePath, "TenantStoreFilePath")
+	flag.StringVar(&autoscale.TenantStoreConfigMapName, "tenant-store-configmap", autoscale.TenantStoreConfigMapName, "TenantStoreConfigMapName")
+	flag.BoolVar(&autoscale.LeaderElectionEnabled, "leader-elect", autoscale.LeaderElectionEnabled, "LeaderElectionEnabled")
+	flag.StringVar(&autoscale.LeaderElectionLeaseName, "leader-elect-lease-name", autoscale.LeaderElectionLeaseName, "LeaderElectionLeaseName")
+	flag.IntVar(&autoscale.LeaderElectionLeaseDurationSec, "leader-elect-lease-duration-sec", autoscale.LeaderElectionLeaseDurationSec, "LeaderElectionLeaseDurationSec")
+	flag.IntVar(&autoscale.LeaderElectionRenewDeadlineSec, "leader-elect-renew-deadline-sec", autoscale.LeaderElectionRenewDeadlineSec, "LeaderElectionRenewDeadlineSec")
+	flag.IntVar(&autoscale.LeaderElectionRetryPeriodSec, "leader-elect-retry-period-sec", autoscale.LeaderElectionRetryPeriodSec, "LeaderElectionRetryPeriodSec")
+	flag.IntVar(&autoscale.FollowerSyncIntervalSec, "follower-sync-intervalsec", autoscale.FollowerSyncIntervalSec, "FollowerSyncIntervalSec")
+	flag.BoolVar(&autoscale.TenantCRDEnabled, "tenant-crd", autoscale.TenantCRDEnabled, "TenantCRDEnabled")
+	flag.IntVar(&autoscale.TenantCRDStatusIntervalSec, "tenant-crd-status-intervalsec", autoscale.TenantCRDStatusIntervalSec, "TenantCRDStatusIntervalSec")
+	flag.StringVar(&autoscale.PodClassesConf, "pod-classes", autoscale.PodClassesConf, "PodClassesConf, name:cores[:warmPoolCap] separated by comma")
+	flag.StringVar(&autoscale.PodCpuRequestPerCore, "pod-cpu-request-per-core", autoscale.PodCpuRequestPerCore, "PodCpuRequestPerCore")
+	flag.StringVar(&autoscale.PodCpuLimitPerCore, "pod-cpu-limit-per-core", autoscale.PodCpuLimitPerCore, "PodCpuLimitPerCore")
+	flag.StringVar(&autoscale.PodMemRequestPerCore, "pod-mem-request-per-core", autoscale.PodMemRequestPerCore, "PodMemRequestPerCore")
+	flag.StringVar(&autoscale.PodMemLimitPerCore, "pod-mem-limit-per-core", autoscale.PodMemLimitPerCore, "PodMemLimitPerCore")
+	flag.StringVar(&autoscale.PodEphemeralStorageRequestPerCore, "pod-ephemeral-storage-request-per-core", autoscale.PodEphemeralStorageRequestPerCore, "PodEphemeralStorageRequestPerCore")
+	flag.StringVar(&autoscale.PodEphemeralStorageLimitPerCore, "pod-ephemeral-storage-limit-per-core", autoscale.PodEphemeralStorageLimitPerCore, "PodEphemeralStorageLimitPerCore")
+	flag.StringVar(&autoscale.CloneSetTemplateFile, "cloneset-template-file", autoscale.CloneSetTemplateFile, "CloneSetTemplateFile")
+	flag.StringVar(&autoscale.CloneSetTemplateConfigMap, "cloneset-template-configmap", autoscale.CloneSetTemplateConfigMap, "CloneSetTemplateConfigMap")
+	flag.StringVar(&autoscale.CloneSetTemplateConfigMapKey, "cloneset-template-configmap-key", autoscale.CloneSetTemplateConfigMapKey, "CloneSetTemplateConfigMapKey")
+	flag.BoolVar(&autoscale.CoordinatedUpgradeEnabled, "coordinated-upgrade", autoscale.CoordinatedUpgradeEnabled, "CoordinatedUpgradeEnabled")
+	flag.IntVar(&autoscale.UpgradeIntervalSec, "upgrade-intervalsec", autoscale.UpgradeIntervalSec, "UpgradeIntervalSec")
+	flag.IntVar(&autoscale.DefaultDrainTimeoutSeconds, "default-drain-timeout-sec", autoscale.DefaultDrainTimeoutSeconds, "DefaultDrainTimeoutSeconds")
+	flag.IntVar(&autoscale.MaxUnassignWaitTimeSec, "max-unassign-wait-sec", autoscale.MaxUnassignWaitTimeSec, "MaxUnassignWaitTimeSec")
+	flag.IntVar(&autoscale.QuarantineProbeIntervalSec, "quarantine-probe-intervalsec", autoscale.QuarantineProbeIntervalSec, "QuarantineProbeIntervalSec")
+	flag.IntVar(&autoscale.QuarantineMaxFailCnt, "quarantine-max-fail-cnt", autoscale.QuarantineMaxFailCnt, "QuarantineMaxFailCnt")
+	flag.BoolVar(&autoscale.AdaptivePoolSizingEnabled, "adaptive-pool-sizing", autoscale.AdaptivePoolSizingEnabled, "AdaptivePoolSizingEnabled")
+	flag.IntVar(&autoscale.AdaptivePoolWindowMin, "adaptive-pool-window-min", autoscale.AdaptivePoolWindowMin, "AdaptivePoolWindowMin")
+	flag.IntVar(&autoscale.AdaptivePoolHistoryDays, "adaptive-pool-history-days", autoscale.AdaptivePoolHistoryDays, "AdaptivePoolHistoryDays")
+	flag.Float64Var(&autoscale.AdaptivePoolPercentile, "adaptive-pool-percentile", autoscale.AdaptivePoolPercentile, "AdaptivePoolPercentile")
+	flag.IntVar(&autoscale.AdaptivePoolFloor, "adaptive-pool-floor", autoscale.AdaptivePoolFloor, "AdaptivePoolFloor")
+	flag.IntVar(&autoscale.AdaptivePoolCeiling, "adaptive-pool-ceiling", autoscale.AdaptivePoolCeiling, "AdaptivePoolCeiling")
+	flag.IntVar(&autoscale.PodRequestTimeoutSec, "pod-request-timeout-sec", autoscale.PodRequestTimeoutSec, "PodRequestTimeoutSec")
+	flag.IntVar(&autoscale.OutstandingDemandExpireSec, "outstanding-demand-expire-sec", autoscale.OutstandingDemandExpireSec, "OutstandingDemandExpireSec")
 	flag.IntVar(&autoscale.HardCodeMaxScaleIntervalSecOfCfg, "maxscale-intervalsec-of-cfg", autoscale.HardCodeMaxScaleIntervalSecOfCfg, "HardCodeMaxScaleIntervalSecOfCfg")
 	flag.StringVar(&autoscale.ReadNodeLogUploadS3Bucket, "s3-bucket-for-readnode-log", autoscale.ReadNodeLogUploadS3Bucket, "ReadNodeUpdateS3Bucket")
 	flag.BoolVar(&autoscale.UseSpecialTenantAsFixPool, "use-special-tenant-as-fixpool", autoscale.UseSpecialTenantAsFixPool, "UseSpecialTenantAsFixPool")
@@ -455,16 +497,67 @@ func main() {
 	autoscale.Logger.Infof("[config]DefaultMinCntOfPod: %v", autoscale.DefaultMinCntOfPod)
 	autoscale.Logger.Infof("[config]DefaultMaxCntOfPod: %v", autoscale.DefaultMaxCntOfPod)
 	autoscale.Logger.Infof("[config]DefaultCoreOfPod: %v", autoscale.DefaultCoreOfPod)
+	autoscale.Logger.Infof("[config]DefaultMemGiBPerCore: %v", autoscale.DefaultMemGiBPerCore)
 	autoscale.Logger.Infof("[config]DefaultLowerLimit: %v", autoscale.DefaultLowerLimit)
 	autoscale.Logger.Infof("[config]DefaultUpperLimit: %v", autoscale.DefaultUpperLimit)
 	autoscale.Logger.Infof("[config]MetricResolutionSeconds: %v", autoscale.MetricResolutionSeconds)
 	autoscale.Logger.Infof("[config]DefaultAutoPauseIntervalSeconds: %v", autoscale.DefaultAutoPauseIntervalSeconds)
 	autoscale.Logger.Infof("[config]DefaultScaleIntervalSeconds: %v", autoscale.DefaultScaleIntervalSeconds)
+	autoscale.Logger.Infof("[config]DefaultScaleUpCooldownSeconds: %v", autoscale.DefaultScaleUpCooldownSeconds)
+	autoscale.Logger.Infof("[config]DefaultScaleDownStabilizationSeconds: %v", autoscale.DefaultScaleDownStabilizationSeconds)
+	autoscale.Logger.Infof("[config]PredictiveScaleIntervalSec: %v", autoscale.PredictiveScaleIntervalSec)
+	autoscale.Logger.Infof("[config]DryRunMode: %v", autoscale.DryRunMode)
+	autoscale.Logger.Infof("[config]DecisionRingCapOfTenant: %v", autoscale.DecisionRingCapOfTenant)
+	autoscale.Logger.Infof("[config]DecisionAuditLogPath: %v", autoscale.DecisionAuditLogPath)
+	autoscale.Logger.Infof("[config]TenantStoreType: %v", autoscale.TenantStoreType)
+	autoscale.Logger.Infof("[config]TenantStoreFilePath: %v", autoscale.TenantStoreFilePath)
+	autoscale.Logger.Infof("[config]TenantStoreConfigMapName: %v", autoscale.TenantStoreConfigMapName)
+	autoscale.Logger.Infof("[config]LeaderElectionEnabled: %v", autoscale.LeaderElectionEnabled)
+	autoscale.Logger.Infof("[config]LeaderElectionLeaseName: %v", autoscale.LeaderElectionLeaseName)
+	autoscale.Logger.Infof("[config]LeaderElectionLeaseDurationSec: %v", autoscale.LeaderElectionLeaseDurationSec)
+	autoscale.Logger.Infof("[config]LeaderElectionRenewDeadlineSec: %v", autoscale.LeaderElectionRenewDeadlineSec)
+	autoscale.Logger.Infof("[config]LeaderElectionRetryPeriodSec: %v", autoscale.LeaderElectionRetryPeriodSec)
+	autoscale.Logger.Infof("[config]FollowerSyncIntervalSec: %v", autoscale.FollowerSyncIntervalSec)
+	autoscale.Logger.Infof("[config]TenantCRDEnabled: %v", autoscale.TenantCRDEnabled)
+	autoscale.Logger.Infof("[config]TenantCRDStatusIntervalSec: %v", autoscale.TenantCRDStatusIntervalSec)
+	autoscale.Logger.Infof("[config]PodClassesConf: %v", autoscale.PodClassesConf)
+	autoscale.Logger.Infof("[config]PodCpuRequestPerCore: %v", autoscale.PodCpuRequestPerCore)
+	autoscale.Logger.Infof("[config]PodCpuLimitPerCore: %v", autoscale.PodCpuLimitPerCore)
+	autoscale.Logger.Infof("[config]PodMemRequestPerCore: %v", autoscale.PodMemRequestPerCore)
+	autoscale.Logger.Infof("[config]PodMemLimitPerCore: %v", autoscale.PodMemLimitPerCore)
+	autoscale.Logger.Infof("[config]PodEphemeralStorageRequestPerCore: %v", autoscale.PodEphemeralStorageRequestPerCore)
+	autoscale.Logger.Infof("[config]PodEphemeralStorageLimitPerCore: %v", autoscale.PodEphemeralStorageLimitPerCore)
+	autoscale.Logger.Infof("[config]CloneSetTemplateFile: %v", autoscale.CloneSetTemplateFile)
+	autoscale.Logger.Infof("[config]CloneSetTemplateConfigMap: %v", autoscale.CloneSetTemplateConfigMap)
+	autoscale.Logger.Infof("[config]CloneSetTemplateConfigMapKey: %v", autoscale.CloneSetTemplateConfigMapKey)
+	autoscale.Logger.Infof("[config]CoordinatedUpgradeEnabled: %v", autoscale.CoordinatedUpgradeEnabled)
+	autoscale.Logger.Infof("[config]UpgradeIntervalSec: %v", autoscale.UpgradeIntervalSec)
+	autoscale.Logger.Infof("[config]DefaultDrainTimeoutSeconds: %v", autoscale.DefaultDrainTimeoutSeconds)
+	autoscale.Logger.Infof("[config]MaxUnassignWaitTimeSec: %v", autoscale.MaxUnassignWaitTimeSec)
+	autoscale.Logger.Infof("[config]QuarantineProbeIntervalSec: %v", autoscale.QuarantineProbeIntervalSec)
+	autoscale.Logger.Infof("[config]QuarantineMaxFailCnt: %v", autoscale.QuarantineMaxFailCnt)
+	autoscale.Logger.Infof("[config]AdaptivePoolSizingEnabled: %v", autoscale.AdaptivePoolSizingEnabled)
+	autoscale.Logger.Infof("[config]AdaptivePoolWindowMin: %v", autoscale.AdaptivePoolWindowMin)
+	autoscale.Logger.Infof("[config]AdaptivePoolHistoryDays: %v", autoscale.AdaptivePoolHistoryDays)
+	autoscale.Logger.Infof("[config]AdaptivePoolPercentile: %v", autoscale.AdaptivePoolPercentile)
+	autoscale.Logger.Infof("[config]AdaptivePoolFloor: %v", autoscale.AdaptivePoolFloor)
+	autoscale.Logger.Infof("[config]AdaptivePoolCeiling: %v", autoscale.AdaptivePoolCeiling)
+	autoscale.Logger.Infof("[config]PodRequestTimeoutSec: %v", autoscale.PodRequestTimeoutSec)
+	autoscale.Logger.Infof("[config]OutstandingDemandExpireSec: %v", autoscale.OutstandingDemandExpireSec)
 	autoscale.Logger.Infof("[config]HardCodeMaxScaleIntervalSecOfCfg: %v", autoscale.HardCodeMaxScaleIntervalSecOfCfg)
 
 	if autoscale.DefaultAutoPauseIntervalSeconds == 0 {
 		panic("DefaultAutoPauseIntervalSeconds is zero!")
 	}
+	if err := autoscale.InitPodClasses(autoscale.PodClassesConf); err != nil {
+		panic(err)
+	}
+	if err := autoscale.ValidatePodResourcesConf(); err != nil {
+		panic(err)
+	}
+	if err := autoscale.ValidateAdaptivePoolSizingConf(); err != nil {
+		panic(err)
+	}
 
 	cm := autoscale.NewClusterManager(autoscale.EnvRegion, isSnsEnabled)
 	autoscale.Cm4Http = cm